@@ -2,70 +2,130 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 	"test/internal/app"
+	"test/internal/config"
+	"test/internal/database"
+	"test/internal/logging"
+	"test/internal/tracing"
 	"time"
 )
 
-func Config() *pgxpool.Config {
-	// const defaultMaxConns = int32(4)
-	// const defaultMinConns = int32(0)
-	const defaultMaxConnLifetime = time.Hour
-	const defaultMaxConnIdleTime = time.Minute * 30
-	const defaultHealthCheckPeriod = time.Minute
-	const defaultConnectTimeout = time.Second * 5
-	dbConfig, err := pgxpool.ParseConfig(os.Getenv("DATABASE_URL"))
+// Config returns the config of the pool, the connections get the tenant of the request when the
+// tenants are enforced with row level security.
+func Config(db config.Database) (*pgxpool.Config, error) {
+	dbConfig, err := pgxpool.ParseConfig(db.URL)
 	if err != nil {
-		log.Fatal("Failed to create a config, error: ", err)
+		return nil, err
 	}
 
-	// dbConfig.MaxConns = defaultMaxConns
-	// dbConfig.MinConns = defaultMinConns
-	dbConfig.MaxConnLifetime = defaultMaxConnLifetime
-	dbConfig.MaxConnIdleTime = defaultMaxConnIdleTime
-	dbConfig.HealthCheckPeriod = defaultHealthCheckPeriod
-	dbConfig.ConnConfig.ConnectTimeout = defaultConnectTimeout
+	dbConfig.MaxConns = int32(db.MaxConns)
+	dbConfig.MinConns = int32(db.MinConns)
+	dbConfig.MaxConnLifetime = db.MaxConnLifetime
+	dbConfig.MaxConnIdleTime = db.MaxConnIdleTime
+	dbConfig.HealthCheckPeriod = db.HealthCheckPeriod
+	dbConfig.ConnConfig.ConnectTimeout = db.ConnectTimeout
+	dbConfig.ConnConfig.Tracer = tracing.QueryTracer{}
 
-	dbConfig.BeforeAcquire = func(ctx context.Context, c *pgx.Conn) bool {
-		log.Println("Before acquiring the connection pool to the database!!")
-		return true
-	}
-
-	dbConfig.AfterRelease = func(c *pgx.Conn) bool {
-		log.Println("After releasing the connection pool to the database!!")
-		return true
+	if db.TenantRLS {
+		dbConfig.BeforeAcquire = database.SetTenant
 	}
 
 	dbConfig.BeforeClose = func(c *pgx.Conn) {
-		log.Println("Closed the connection pool to the database!!")
+		slog.Debug("Closed a connection to the database", "pid", c.PgConn().PID())
 	}
 
-	return dbConfig
+	return dbConfig, nil
 }
 
 // @title Go Music
 // @version 1.0
-// @description This is a sample server for music library
+// @description This is a sample server for music library. Every request acts on the library of a tenant: the tenant of the user of the API key or of the tenant claim of the JWT, otherwise the default tenant. Naming another tenant in the X-Tenant header is forbidden, except for the JWTs with the tenants:select scope and no tenant claim.
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued by /issuekey or musicctl adduser, also accepted as "Authorization: Bearer <key>"
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer <JWT>" signed with RS256 or ES256 by a key of the JWKS, with the scope of the route: songs:read, songs:write, songs:delete, playlists:read, playlists:write, library:dump or library:restore
+
+// fatal logs the error and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// load returns the configuration of the file, the environment and the flags of args, it exits on
+// -help and on an invalid configuration.
+func load(args []string) config.Config {
+	cfg, err := config.Load(args, os.Getenv)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return cfg
+}
 
 func main() {
-	var err error
-	connPool, err := pgxpool.NewWithConfig(context.Background(), Config())
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "config" {
+		if len(args) < 2 || args[1] != "print" {
+			fmt.Fprintln(os.Stderr, "Usage: app config print [flags]")
+			os.Exit(2)
+		}
+		if err := config.Print(os.Stdout, load(args[2:])); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	cfg := load(args)
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	slog.SetDefault(logging.New(os.Stderr, level))
+	shutdown, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.File)
+	if err != nil {
+		fatal("Failed to set up tracing", "error", err)
+	}
+	// SIGINT and SIGTERM drain the requests in flight, then the spans are flushed and the pool is
+	// closed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	dbConfig, err := Config(cfg.Database)
+	if err != nil {
+		fatal("Failed to create a config", "error", err)
+	}
+	connPool, err := pgxpool.NewWithConfig(context.Background(), dbConfig)
 	if err != nil {
-		log.Fatal("Error while creating connection to the database!!", err)
+		fatal("Failed to create the connection pool to the database", "error", err)
 	}
-	defer connPool.Close()
-	connection, err := connPool.Acquire(context.Background())
+	err = connPool.Ping(ctx)
 	if err != nil {
-		log.Fatal("Error while acquiring connection from the database pool!!", err)
+		fatal("Failed to ping the database", "error", err)
+	}
+	application := app.NewApp(connPool, cfg)
+	err = application.Run(ctx)
+	stop()
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if flushErr := shutdown(flushCtx); flushErr != nil {
+		slog.Error("Failed to flush the spans", "error", flushErr)
 	}
-	defer connection.Release()
-	err = connection.Ping(context.Background())
+	connPool.Close()
 	if err != nil {
-		log.Fatal("Could not ping database", err)
+		fatal("The server stopped", "error", err)
 	}
-	application := app.NewApp(connPool, os.Getenv("SERVER_IP"), os.Getenv("PORT"), os.Getenv("API_URL"))
-	log.Fatal(application.Run())
+	slog.Info("Stopped")
 }
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"test/internal/auth"
+	"test/internal/database"
+	"test/internal/importer"
+	"test/internal/logging"
+	"test/internal/models"
+	"test/internal/services"
+	"test/internal/tenant"
+	"time"
+)
+
+const usage = `Usage: musicctl <command> [flags]
+
+Commands:
+  migrate up|status|down
+        migrate the tables to this build, print their schema version or roll
+        them back by one version for an older build
+  import [-format csv|json|ndjson] [-dry-run] [-tenant name] <file>
+        add the songs of the file and report the outcome of every row
+  export [-format csv|json|ndjson] [-o songs.csv] [-tenant name]
+        write the songs of the library in a format they can be imported from
+  groups [-tenant name]
+        list the groups of the library with the number of their songs
+  enrich [-tenant name] <group> <song>
+        fetch the details of a stored song from the /info route of API_URL again
+  dump [-o library.zip] [-tenant name]
+        write an archive of the whole library of the tenant
+  restore [-replace] [-tenant name] <library.zip>
+        load an archive into the library, merging into it unless -replace is given
+  adduser [-role viewer|editor|admin] [-tenant name] <name>
+        create a user and print its first API key
+  issuekey [-tenant name] <name>
+        print a new API key of the user
+  reindex
+        rebuild the indexes the songs are searched with
+  purge [-before 720h] [-tenant name]
+        delete the groups without songs and the API keys revoked longer ago
+
+The database is given by the DATABASE_URL environment variable. The commands act on the
+default tenant unless -tenant is given. The tables are migrated before every command but
+migrate status and migrate down.
+`
+
+func main() {
+	// the service logs every call, only the reports are printed unless LOG_LEVEL is set
+	logs := io.Discard
+	level, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if os.Getenv("LOG_LEVEL") != "" {
+		logs = os.Stderr
+	}
+	slog.SetDefault(logging.New(logs, level))
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "migrate":
+		err = migrate(os.Args[2:])
+	case "import":
+		err = importSongs(os.Args[2:])
+	case "export":
+		err = exportSongs(os.Args[2:])
+	case "groups":
+		err = listGroups(os.Args[2:])
+	case "enrich":
+		err = enrichSong(os.Args[2:])
+	case "dump":
+		err = dumpLibrary(os.Args[2:])
+	case "restore":
+		err = restoreLibrary(os.Args[2:])
+	case "adduser":
+		err = addUser(os.Args[2:])
+	case "issuekey":
+		err = issueKey(os.Args[2:])
+	case "reindex":
+		err = reindex(os.Args[2:])
+	case "purge":
+		err = purge(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "musicctl %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// open returns the database of DATABASE_URL as it is. The connections get the tenant of the
+// context in case the server enforces the tenants with row level security.
+func open(ctx context.Context) (*database.PGXDatabase, func(), error) {
+	config, err := pgxpool.ParseConfig(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return nil, nil, err
+	}
+	config.BeforeAcquire = database.SetTenant
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return database.NewPGXDatabase(pool), pool.Close, nil
+}
+
+// migrated returns the database of DATABASE_URL, the tables are created when they are missing.
+func migrated(ctx context.Context) (*database.PGXDatabase, func(), error) {
+	db, release, err := open(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = db.CreateTableQuery(ctx); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return db, release, nil
+}
+
+// connect returns the service over the database of DATABASE_URL, the tables are created when
+// they are missing.
+func connect(ctx context.Context) (*services.Service, func(), error) {
+	db, release, err := migrated(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return services.NewService(db, os.Getenv("API_URL"), &http.Client{Timeout: 30 * time.Second}), release, nil
+}
+
+// migrate creates the missing tables and migrates the others to the schema version of this
+// build, or rolls them back by one version. The tables rolled back are migrated again by the
+// next command or start of this build, so the older build has to be deployed first.
+func migrate(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected up, status or down")
+	}
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		db, release, err := open(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+		if err = db.CreateTableQuery(ctx); err != nil {
+			return err
+		}
+		fmt.Printf("migrated to schema version %d\n", database.SchemaVersion)
+		return nil
+	case "status":
+		db, release, err := open(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+		version, err := db.SchemaVersionQuery(ctx)
+		if err != nil {
+			return fmt.Errorf("the schema version is unknown, the tables may not be migrated: %w", err)
+		}
+		state := "up to date"
+		if version < database.SchemaVersion {
+			state = "run musicctl migrate up"
+		} else if version > database.SchemaVersion {
+			state = "migrated by a newer build"
+		}
+		fmt.Printf("schema version %d, this build expects %d (%s)\n", version, database.SchemaVersion, state)
+		return nil
+	case "down":
+		db, release, err := open(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+		version, err := db.MigrateDownQuery(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back to schema version %d\n", version)
+		return nil
+	}
+	return fmt.Errorf("unknown migration %q, expected up, status or down", args[0])
+}
+
+func importSongs(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "format of the file, by its extension when not given")
+	dryRun := flags.Bool("dry-run", false, "only report the outcome of the rows")
+	tenantName := tenantFlag(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one file, got %d", flags.NArg())
+	}
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	if !slices.Contains(importer.Formats, *format) {
+		return fmt.Errorf("unknown format %q, expected csv, json or ndjson", *format)
+	}
+	ctx, err := operatorContext(*tenantName)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	service, release, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	report, err, _ := service.ImportSongs(ctx, file, *format, *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, row := range report.Rows {
+		line := fmt.Sprintf("row %d\t%s\t%s - %s", row.Row, row.Status, row.Group, row.Song)
+		if row.Error != "" {
+			line += "\t" + row.Error
+		}
+		fmt.Println(line)
+	}
+	mode := "committed"
+	if report.DryRun {
+		mode = "dry run, nothing stored"
+	}
+	fmt.Printf("inserted %d, skipped %d, conflicting %d, invalid %d (%s)\n", report.Inserted, report.Skipped, report.Conflicting, report.Invalid, mode)
+	return nil
+}
+
+func exportSongs(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "", "format of the file, by the extension of -o when not given")
+	output := flags.String("o", "", "file to write, stdout when not given")
+	tenantName := tenantFlag(flags)
+	flags.Parse(args)
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*output), ".")
+	}
+	if *format == "" {
+		*format = "ndjson"
+	}
+	if !slices.Contains(importer.Formats, *format) {
+		return fmt.Errorf("Unknown format %q, expected %s", *format, strings.Join(importer.Formats, ", "))
+	}
+	ctx, err := operatorContext(*tenantName)
+	if err != nil {
+		return err
+	}
+	service, release, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	// the file is only created once the songs can be read, a failed export leaves no file behind
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	writer, err := importer.NewWriter(out, *format)
+	if err == nil {
+		err, _ = service.StreamSongs(ctx, models.SongsQuery{}, writer.Write)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		if *output != "" {
+			os.Remove(*output)
+		}
+		return err
+	}
+	if *output != "" {
+		if err = out.Close(); err != nil {
+			os.Remove(*output)
+			return err
+		}
+		fmt.Printf("wrote %d songs to %s\n", writer.Count(), *output)
+	}
+	return nil
+}
+
+func listGroups(args []string) error {
+	flags := flag.NewFlagSet("groups", flag.ExitOnError)
+	tenantName := tenantFlag(flags)
+	flags.Parse(args)
+	ctx, err := operatorContext(*tenantName)
+	if err != nil {
+		return err
+	}
+	service, release, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	groups, err, _ := service.GetGroups(ctx)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		fmt.Printf("%s\t%d songs\n", group.Name, group.Songs)
+	}
+	return nil
+}
+
+func enrichSong(args []string) error {
+	flags := flag.NewFlagSet("enrich", flag.ExitOnError)
+	tenantName := tenantFlag(flags)
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected a group and a song, got %d arguments", flags.NArg())
+	}
+	if os.Getenv("API_URL") == "" {
+		return fmt.Errorf("API_URL is required to fetch the details of the song")
+	}
+	ctx, err := operatorContext(*tenantName)
+	if err != nil {
+		return err
+	}
+	service, release, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err, _ = service.EnrichSong(ctx, flags.Arg(0), flags.Arg(1)); err != nil {
+		return err
+	}
+	fmt.Printf("enriched %s - %s\n", flags.Arg(0), flags.Arg(1))
+	return nil
+}
+
+func dumpLibrary(args []string) error {
+	flags := flag.NewFlagSet("dump", flag.ExitOnError)
+	output := flags.String("o", "library-"+time.Now().UTC().Format("20060102-150405")+".zip", "archive to write")
+	tenantName := tenantFlag(flags)
+	flags.Parse(args)
+	ctx, err := operatorContext(*tenantName)
+	if err != nil {
+		return err
+	}
+	service, release, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	manifest, err, _ := service.DumpLibrary(ctx, file)
+	if err != nil {
+		os.Remove(*output)
+		return err
+	}
+	if err = file.Close(); err != nil {
+		os.Remove(*output)
+		return err
+	}
+	printManifest(manifest)
+	fmt.Printf("wrote %s\n", *output)
+	return nil
+}
+
+func restoreLibrary(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	replace := flags.Bool("replace", false, "empty the library before loading the archive")
+	tenantName := tenantFlag(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one archive, got %d", flags.NArg())
+	}
+	ctx, err := operatorContext(*tenantName)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	service, release, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	manifest, err, _ := service.RestoreLibrary(ctx, file, info.Size(), *replace)
+	if err != nil {
+		return err
+	}
+	printManifest(manifest)
+	fmt.Printf("restored %s\n", flags.Arg(0))
+	return nil
+}
+
+// operator is the principal of musicctl, which has the database at hand and acts as an admin.
+var operator = models.PrincipalData{User: "musicctl", Role: models.RoleAdmin, Permissions: models.Permissions}
+
+// tenantFlag adds the -tenant flag to the flags of a command.
+func tenantFlag(flags *flag.FlagSet) *string {
+	return flags.String("tenant", tenant.Default, "tenant whose library and users the command acts on")
+}
+
+// operatorContext returns the context of the commands, the operator acts on the tenant.
+func operatorContext(name string) (context.Context, error) {
+	if err := tenant.Validate(name); err != nil {
+		return nil, err
+	}
+	principal := operator
+	principal.Tenant = name
+	return tenant.With(auth.WithPrincipal(context.Background(), principal), name), nil
+}
+
+func reindex(args []string) error {
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	flags.Parse(args)
+	ctx := context.Background()
+	db, release, err := migrated(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err = db.ReindexQuery(ctx); err != nil {
+		return err
+	}
+	fmt.Println("rebuilt the indexes of the groups, songs and lyrics")
+	return nil
+}
+
+func purge(args []string) error {
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	age := flags.Duration("before", 30*24*time.Hour, "age of the revoked API keys to delete")
+	tenantName := tenantFlag(flags)
+	flags.Parse(args)
+	ctx, err := operatorContext(*tenantName)
+	if err != nil {
+		return err
+	}
+	db, release, err := migrated(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	groups, keys, err := db.PurgeQuery(ctx, time.Now().Add(-*age))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("deleted %d groups without songs and %d revoked API keys\n", groups, keys)
+	return nil
+}
+
+func addUser(args []string) error {
+	flags := flag.NewFlagSet("adduser", flag.ExitOnError)
+	role := flags.String("role", models.RoleViewer, "role of the user, viewer, editor, admin or a role added with /setrole")
+	tenantName := tenantFlag(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one name, got %d", flags.NArg())
+	}
+	ctx, err := operatorContext(*tenantName)
+	if err != nil {
+		return err
+	}
+	service, release, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	user, err, _ := service.AddUser(ctx, flags.Arg(0), *role)
+	if err != nil {
+		return err
+	}
+	key, err, _ := service.IssueKey(ctx, user.Name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("added %s user %s (id %d)\n", user.Role, user.Name, user.ID)
+	printKey(key)
+	return nil
+}
+
+func issueKey(args []string) error {
+	flags := flag.NewFlagSet("issuekey", flag.ExitOnError)
+	tenantName := tenantFlag(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one name, got %d", flags.NArg())
+	}
+	ctx, err := operatorContext(*tenantName)
+	if err != nil {
+		return err
+	}
+	service, release, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	key, err, _ := service.IssueKey(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	printKey(key)
+	return nil
+}
+
+func printKey(key models.APIKeyData) {
+	fmt.Printf("key %d of %s, it is not shown again:\n%s\n", key.ID, key.User, key.Key)
+}
+
+func printManifest(manifest models.ManifestData) {
+	fmt.Printf("%s version %d, schema version %d, created %s\n", manifest.Format, manifest.Version, manifest.SchemaVersion, manifest.CreatedAt.Format(time.RFC3339))
+	for _, file := range manifest.Files {
+		fmt.Printf("  %s\t%d records\tsha256 %s\n", file.Name, file.Records, file.SHA256)
+	}
+}
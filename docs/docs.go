@@ -15,9 +15,17 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/addsong": {
+        "/addchords": {
             "post": {
-                "description": "Add song based on group and song provided as json.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the song text with the lyrics of the chord sheet in the ChordPro format and store its chords separately, based on group, song and chordpro provided as json. Directives and lines with chords but no lyrics are skipped. A changed text deletes the LRC lines of the song.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,17 +33,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "song"
+                    "chords"
                 ],
-                "summary": "Add song",
+                "summary": "Add a chord sheet",
                 "parameters": [
                     {
-                        "description": "JSON with group and song",
+                        "description": "JSON with group, song and chordpro",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.AddDeleteRequestData"
+                            "$ref": "#/definitions/models.ChordsRequestData"
                         }
                     }
                 ],
@@ -49,6 +57,24 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -58,9 +84,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/deletesong": {
+        "/addlrc": {
             "post": {
-                "description": "Delete song based on group and song provided as json.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the time-synced lines of the song with the lyrics in the LRC format (enhanced word-level tags are supported) based on group, song and lrc provided as json.",
                 "consumes": [
                     "application/json"
                 ],
@@ -68,17 +102,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "song"
+                    "lrc"
                 ],
-                "summary": "Delete song",
+                "summary": "Add time-synced lyrics",
                 "parameters": [
                     {
-                        "description": "JSON with group and song",
+                        "description": "JSON with group, song and lrc",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.AddDeleteRequestData"
+                            "$ref": "#/definitions/models.LrcRequestData"
                         }
                     }
                 ],
@@ -92,6 +126,24 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -101,9 +153,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/editsong": {
+        "/addlyrics": {
             "post": {
-                "description": "Edit song releaseDate, text and link based on group and song provided as json.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add or replace the lyrics version of the song based on group, song, BCP 47 language tag lang, kind (original, translation or transliteration) and text provided as json.",
                 "consumes": [
                     "application/json"
                 ],
@@ -111,17 +171,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "song"
+                    "lyrics"
                 ],
-                "summary": "Edit song text",
+                "summary": "Add lyrics version",
                 "parameters": [
                     {
-                        "description": "JSON with group, song, releaseDate, text, and link",
+                        "description": "JSON with group, song, lang, kind and text",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.EditRequestData"
+                            "$ref": "#/definitions/models.LyricsRequestData"
                         }
                     }
                 ],
@@ -135,6 +195,24 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -144,74 +222,115 @@ const docTemplate = `{
                 }
             }
         },
-        "/getdata": {
-            "get": {
-                "description": "Retrieve songs and their details with pagination based on the page and items and filtration based on group, song, releaseDate, text and link provided as query parameters.",
+        "/addplaylist": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create an empty playlist based on title and description provided as json. The playlist belongs to the caller, the owner of the json names another user for the callers with users:manage.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "playlists"
                 ],
-                "summary": "Get all songs and their information with pagination",
+                "summary": "Add a playlist",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "example": 1,
-                        "description": "Current page",
-                        "name": "page",
-                        "in": "query",
-                        "required": true
+                        "description": "JSON with owner, title and description",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistData"
+                        }
                     },
-                    {
-                        "type": "integer",
-                        "example": 10,
-                        "description": "Number of elements on the page",
-                        "name": "items",
-                        "in": "query",
-                        "required": true
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "example": "\"Muse\"",
-                        "description": "Group",
-                        "name": "group",
-                        "in": "query"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "example": "\"Supermassive Black Hole\"",
-                        "description": "Song name",
-                        "name": "song",
-                        "in": "query"
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "example": "\"16.07.2006\"",
-                        "description": "Release date in format DD.MM.YYYY",
-                        "name": "releaseDate",
-                        "in": "query"
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/addplaylistsong": {
+            "post": {
+                "security": [
                     {
-                        "type": "string",
-                        "example": "\"Ooh baby, don't you know I suffer?\\nOoh baby, can you hear me moan?\\nYou caught me under false pretenses\\nHow long before you let me go?\\n\\nOoh\\nYou set my soul alight\\nOoh\\nYou set my soul alight\"",
-                        "description": "Song text (multiline allowed)",
-                        "name": "text",
-                        "in": "query"
+                        "ApiKeyAuth": []
                     },
                     {
-                        "type": "string",
-                        "example": "\"https://www.youtube.com/watch?v=Xsp3_a-PMTw\"",
-                        "description": "Song link",
-                        "name": "link",
-                        "in": "query"
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Insert the song at the position of the playlist moving the following songs down, or append it without a position, based on id, group, song and position provided as json. The same song can be added several times.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Add a song to a playlist",
+                "parameters": [
+                    {
+                        "description": "JSON with id, group, song and position",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistSongRequestData"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.AnswerData"
+                            "$ref": "#/definitions/models.PlaylistData"
                         }
                     },
                     "400": {
@@ -220,6 +339,30 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -229,48 +372,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/getsongtext": {
-            "get": {
-                "description": "Retrieve song text with pagination based on the group, song and couplet provided as query parameters.",
+        "/addsong": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add song based on group and song provided as json. The release date, text and link are fetched from the /info route of the metadata provider, after repeated failures of the provider the songs are rejected with 503 for a while without calling it.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "song"
                 ],
-                "summary": "Get songs text with pagination",
+                "summary": "Add song",
                 "parameters": [
                     {
-                        "type": "string",
-                        "example": "\"Muse\"",
-                        "description": "Group",
-                        "name": "group",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "example": "\"Supermassive Black Hole\"",
-                        "description": "Song name",
-                        "name": "song",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "example": 1,
-                        "description": "Couplet",
-                        "name": "couplet",
-                        "in": "query",
-                        "required": true
+                        "description": "JSON with group and song",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddDeleteRequestData"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.AnswerCoupletData"
-                        }
+                        "description": "OK"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -278,21 +414,2922 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable, the circuit of the metadata provider is open",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.AddDeleteRequestData": {
-            "type": "object",
-            "required": [
-                "group",
+        },
+        "/adduser": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a user with a role based on name and role provided as json, the role is viewer by default. Needs the users:manage permission. The user gets no key, issue one with /issuekey.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Add a user",
+                "parameters": [
+                    {
+                        "description": "JSON with name and role",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/assignrole": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Give the user the role based on user and role provided as json, the keys of the user get the permissions of the role on their next request. Needs the users:manage permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Assign a role",
+                "parameters": [
+                    {
+                        "description": "JSON with user and role",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AssignRoleRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/deletegroup": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the group with all of its songs based on group provided as json. Needs the groups:manage permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Delete group",
+                "parameters": [
+                    {
+                        "description": "JSON with group",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/deletelyrics": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the lyrics version of the song based on group, song, lang and kind provided as json.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lyrics"
+                ],
+                "summary": "Delete lyrics version",
+                "parameters": [
+                    {
+                        "description": "JSON with group, song, lang and kind",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LyricsRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/deleteplaylist": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the playlist based on id provided as json, its songs are kept in the library.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Delete a playlist",
+                "parameters": [
+                    {
+                        "description": "JSON with id",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/deletesong": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete song based on group and song provided as json.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Delete song",
+                "parameters": [
+                    {
+                        "description": "JSON with group and song",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddDeleteRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dumplibrary": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Export groups, songs, time-synced lines, chords, lyrics versions and playlists as a zip archive with a JSON lines file per table and a manifest.json with the format version, the schema version and the record count and SHA-256 checksum of every file. The archive is taken from a single snapshot and streamed.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "Dump the library",
+                "responses": {
+                    "200": {
+                        "description": "Library archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/editplaylist": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the title and description of the playlist based on id, title and description provided as json. Only the owner and the callers with users:manage change a playlist.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Edit a playlist",
+                "parameters": [
+                    {
+                        "description": "JSON with id, title and description",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/editsong": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Edit song releaseDate, text and link based on group and song provided as json. A new text deletes the chords and the LRC lines of the song, they were kept by line number.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Edit song text",
+                "parameters": [
+                    {
+                        "description": "JSON with group, song, releaseDate, text, and link",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EditRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/exportplaylist": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Export the playlist as M3U8 or XSPF based on id and format provided as query parameters. Songs are located by their links, or by \"group - song\" without one.",
+                "produces": [
+                    "application/vnd.apple.mpegurl",
+                    "application/xspf+xml"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Export a playlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Playlist id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "m3u8",
+                            "xspf"
+                        ],
+                        "type": "string",
+                        "description": "Format",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Playlist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getchords": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Render the song text with chords over the lyric lines, or export it in the ChordPro format, transposed by the number of semitones, based on the group, song, transpose and format provided as query parameters.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "chords"
+                ],
+                "summary": "Get the chord sheet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"Muse\"",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Supermassive Black Hole\"",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 2,
+                        "description": "Semitones to transpose by, negative to transpose down",
+                        "name": "transpose",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"text\"",
+                        "description": "text (default) or chordpro",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getdata": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve songs and their details with pagination based on the page and items, filtration and sorting provided as query parameters. The songs are exported as CSV, NDJSON or XML by the format parameter or by the Accept header when that type ranks above every other accepted type, JSON otherwise, the exports are streamed and return all the matching songs when items is not given. A plain field=value parameter is an exact match, the other operators are given as field[operator]=value: prefix and contains (case-insensitive) and in for group and song, gt, gte, lt and lte for releaseDate, contains for text and in for lang. Values of in are given by repeating the parameter. Without sort songs are returned in the order they were added. The cursors of the next and previous pages are returned in the pagination and the Link header, the total is counted on request.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/xml"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get all songs and their information with pagination",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "xml"
+                        ],
+                        "type": "string",
+                        "description": "Format of the songs, overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Current page, required without cursor",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 10,
+                        "description": "Number of elements on the page, at most 1000, required for JSON",
+                        "name": "items",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Muse\"",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Supermassive Black Hole\"",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"16.07.2006\"",
+                        "description": "Release date in format DD.MM.YYYY",
+                        "name": "releaseDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Ooh baby, don't you know I suffer?\\nOoh baby, can you hear me moan?\\nYou caught me under false pretenses\\nHow long before you let me go?\\n\\nOoh\\nYou set my soul alight\\nOoh\\nYou set my soul alight\"",
+                        "description": "Song text (multiline allowed)",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"https://www.youtube.com/watch?v=Xsp3_a-PMTw\"",
+                        "description": "Song link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"en\"",
+                        "description": "Detected language of the song text as a BCP 47 tag",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Mu\"",
+                        "description": "Group name starts with",
+                        "name": "group[prefix]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Black\"",
+                        "description": "Song name contains",
+                        "name": "song[contains]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Group is one of",
+                        "name": "group[in]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"01.01.2006\"",
+                        "description": "Released on or after the date in format DD.MM.YYYY",
+                        "name": "releaseDate[gte]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"01.01.2010\"",
+                        "description": "Released before the date in format DD.MM.YYYY",
+                        "name": "releaseDate[lt]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only songs with (true) or without (false) a link",
+                        "name": "hasLink",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only songs with (true) or without (false) lyrics",
+                        "name": "hasLyrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"-releaseDate,song\"",
+                        "description": "Comma-separated sort keys of group, song and releaseDate, prefixed with - for the descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next or previous page from the pagination or the Link header, replaces page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "estimate"
+                        ],
+                        "type": "string",
+                        "description": "Count the matching songs exactly or estimate the number",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"group,song,releaseDate\"",
+                        "description": "Comma-separated fields of the songs to return, of group, song, releaseDate, text, link, lang and langConfidence, all by default",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"group\"",
+                        "description": "Comma-separated related resources to add to the songs, group adds groupInfo",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerData"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the previous and next pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getkeys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the API keys of the user provided as query parameter, of the caller without one, with their prefixes and revocation times but without the keys. Listing the keys of other users, and of every user with user=*, needs the users:manage permission.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get API keys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"alice\"",
+                        "description": "User",
+                        "name": "user",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerKeysData"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getlrc": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Export the time-synced lines of the song in the LRC format based on the group and song provided as query parameters.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "lrc"
+                ],
+                "summary": "Get time-synced lyrics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"Muse\"",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Supermassive Black Hole\"",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getlyrics": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all lyrics versions of the song based on the group and song provided as query parameters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lyrics"
+                ],
+                "summary": "Get lyrics versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"Muse\"",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Supermassive Black Hole\"",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerLyricsData"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getplaylist": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the playlist with its songs in their order based on id provided as query parameter.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Get a playlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Playlist id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getplaylists": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the playlists of the owner provided as query parameter, or of every owner without it, without their songs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Get playlists",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"alice\"",
+                        "description": "Owner",
+                        "name": "owner",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerPlaylistsData"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getroles": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the roles with their permissions. Needs the users:manage permission.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get roles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerRolesData"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getsongline": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the time-synced line active at the playback offset in milliseconds and the time the next line starts, based on the group, song and offset provided as query parameters. Index is 0 before the first line.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lrc"
+                ],
+                "summary": "Get the lyric line at a playback offset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"Muse\"",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Supermassive Black Hole\"",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 12700,
+                        "description": "Playback offset in milliseconds",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerLineData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getsongstructure": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the verses of the song with repeated and near-repeated verses replaced by references, the number of unique verses and repeats, and the hook (the most repeated verse), based on the group and song provided as query parameters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Get song structure",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"Muse\"",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Supermassive Black Hole\"",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerStructureData"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/getsongtext": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve song text with pagination based on the group, song and couplet provided as query parameters. The verse of the lyrics version in the language of the lang parameter, or the Accept-Language header when it is missing, is returned alongside as translation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Get songs text with pagination",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"Muse\"",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Supermassive Black Hole\"",
+                        "description": "Song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Couplet",
+                        "name": "couplet",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "Replace repeated verses with references",
+                        "name": "compact",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"ru\"",
+                        "description": "BCP 47 language tag of the lyrics version",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"translation\"",
+                        "description": "Kind of the lyrics version: translation, transliteration or original",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"ru, en;q=0.5\"",
+                        "description": "Languages of the lyrics version when lang is missing",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerCoupletData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Tell that the process is alive and serving, the dependencies are not checked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthData"
+                        }
+                    }
+                }
+            }
+        },
+        "/importplaylist": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a playlist of the caller, or of the owner for the callers with users:manage, from an M3U8 or XSPF file, given by the format parameter or the Content-Type header. The entries are matched to the songs of the library by group and song name ignoring the case, from the \"group - song\" titles of M3U8 and the creator and title of XSPF tracks, the entries without a match are returned as unresolved. The title of the file is used without the title parameter.",
+                "consumes": [
+                    "application/vnd.apple.mpegurl",
+                    "application/xspf+xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Import a playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"alice\"",
+                        "description": "Owner",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Friday setlist\"",
+                        "description": "Title, the title of the file by default",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "m3u8",
+                            "xspf"
+                        ],
+                        "type": "string",
+                        "description": "Format of the file, overrides the Content-Type header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Playlist file",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistImportData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/importsongs": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add the songs of a CSV file with a header of group, song, releaseDate, text and link columns, a JSON array or NDJSON of songs with the same keys. The format is given by the format parameter or the Content-Type header. The songs are copied in one transaction, the report lists every row as inserted, skipped when the song is already stored or given earlier with the same data, conflicting when it is given with other data or invalid. Nothing is stored with dryRun=true.",
+                "consumes": [
+                    "text/csv",
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Import songs from a file",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "json",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Format of the file, overrides the Content-Type header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report the outcome of the rows",
+                        "name": "dryRun",
+                        "in": "query"
+                    },
+                    {
+                        "description": "File of songs",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReportData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/issuekey": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Issue a new API key to the user provided as json, to the caller without one. Issuing keys to other users needs the users:manage permission. The key is only returned once, the service keeps its hash.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Issue an API key",
+                "parameters": [
+                    {
+                        "description": "JSON with user",
+                        "name": "data",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.KeyRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/moveplaylistsong": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move the song of the playlist from a position to another, the songs between them move by one position, based on id, from and to provided as json.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Reorder a playlist",
+                "parameters": [
+                    {
+                        "description": "JSON with id, from and to",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistMoveRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Check that the database answers within the timeout, that its tables are migrated to the schema version of the server and that the circuit of the metadata provider is not open. Every dependency is reported with its status, the time its check took and its error.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthData"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthData"
+                        }
+                    }
+                }
+            }
+        },
+        "/removeplaylistsong": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the song at the position of the playlist moving the following songs up, based on id and position provided as json.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Remove a song from a playlist",
+                "parameters": [
+                    {
+                        "description": "JSON with id and position",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistSongRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/renamegroup": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename the group based on group and name provided as json, the name must not be taken by another group. Needs the groups:manage permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Rename group",
+                "parameters": [
+                    {
+                        "description": "JSON with group and name",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/restorelibrary": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Load a library archive of /dumplibrary in a single transaction after checking its manifest and checksums. By default the archive is merged into the library, the songs of the archive replace the stored ones with their lines, chords and lyrics and the other songs are kept, the playlists of the archive replace the stored ones of the same owner and title. With mode=replace the library and the playlists are emptied first.",
+                "consumes": [
+                    "application/zip"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "library"
+                ],
+                "summary": "Restore the library",
+                "parameters": [
+                    {
+                        "enum": [
+                            "merge",
+                            "replace"
+                        ],
+                        "type": "string",
+                        "description": "Merge into the library or replace it",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Library archive",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Manifest of the restored archive",
+                        "schema": {
+                            "$ref": "#/definitions/models.ManifestData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/revokekey": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke the API key based on id provided as json, its requests are rejected from then on. Revoking the keys of other users needs the users:manage permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "description": "JSON with id",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.KeyRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rotatekey": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke the API key based on id provided as json and issue a new one to its user. Rotating the keys of other users needs the users:manage permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Rotate an API key",
+                "parameters": [
+                    {
+                        "description": "JSON with id",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.KeyRequestData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/setrole": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create the role or replace its permissions based on name and permissions provided as json. The roles are shared by the tenants, so only the users of the default tenant change them. Needs the users:manage permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set a role",
+                "parameters": [
+                    {
+                        "description": "JSON with name and permissions",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RoleData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKeyData": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "example": "2024-09-01T12:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "key": {
+                    "type": "string",
+                    "example": "sl_9f86d081_PnN0bJ0vQm2m1lO0wYq2mZ0p8o9mC0l0vXo2cW1uQk4"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "9f86d081"
+                },
+                "revokedAt": {
+                    "type": "string",
+                    "example": "2024-09-02T12:00:00Z"
+                },
+                "user": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "models.AddDeleteRequestData": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                }
+            }
+        },
+        "models.AnswerCoupletData": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "example": "translation"
+                },
+                "lang": {
+                    "type": "string",
+                    "example": "ru"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?"
+                },
+                "translation": {
+                    "type": "string",
+                    "example": "О, детка, разве ты не знаешь, что я страдаю?\nО, детка, ты слышишь мой стон?"
+                }
+            }
+        },
+        "models.AnswerData": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RowDbData"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/models.PaginationData"
+                }
+            }
+        },
+        "models.AnswerKeysData": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIKeyData"
+                    }
+                }
+            }
+        },
+        "models.AnswerLineData": {
+            "type": "object",
+            "required": [
+                "index"
+            ],
+            "properties": {
+                "index": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "nextTime": {
+                    "type": "integer",
+                    "example": 16000
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?"
+                },
+                "time": {
+                    "type": "integer",
+                    "example": 12000
+                },
+                "words": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimedWordData"
+                    }
+                }
+            }
+        },
+        "models.AnswerLyricsData": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LyricsData"
+                    }
+                }
+            }
+        },
+        "models.AnswerPlaylistsData": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlaylistData"
+                    }
+                }
+            }
+        },
+        "models.AnswerRolesData": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RoleData"
+                    }
+                }
+            }
+        },
+        "models.AnswerStructureData": {
+            "type": "object",
+            "required": [
+                "repeats",
+                "unique",
+                "verses"
+            ],
+            "properties": {
+                "hook": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "repeats": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "unique": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VerseData"
+                    }
+                }
+            }
+        },
+        "models.AssignRoleRequestData": {
+            "type": "object",
+            "required": [
+                "role",
+                "user"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "example": "editor"
+                },
+                "user": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "models.ChordsRequestData": {
+            "type": "object",
+            "required": [
+                "chordpro",
+                "group",
+                "song"
+            ],
+            "properties": {
+                "chordpro": {
+                    "type": "string",
+                    "example": "[Em]Ooh baby, don't you [G]know I suffer?\nOoh [Em]baby, can you hear me [A7]moan?"
+                },
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                }
+            }
+        },
+        "models.EditRequestData": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "link": {
+                    "type": "string",
+                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+                },
+                "releaseDate": {
+                    "type": "string",
+                    "example": "16.07.2006"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
+                }
+            }
+        },
+        "models.GroupData": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "songs": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "models.GroupRequestData": {
+            "type": "object",
+            "required": [
+                "group"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "MUSE"
+                }
+            }
+        },
+        "models.HealthCheckData": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "schema version 3"
+                },
+                "duration": {
+                    "type": "string",
+                    "example": "1.2ms"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "context deadline exceeded"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "models.HealthData": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.HealthCheckData"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "models.ImportReportData": {
+            "type": "object",
+            "properties": {
+                "conflicting": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "dryRun": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "inserted": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "invalid": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowData"
+                    }
+                },
+                "skipped": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "models.ImportRowData": {
+            "type": "object",
+            "required": [
+                "row",
+                "status"
+            ],
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "The song is required"
+                },
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "inserted"
+                }
+            }
+        },
+        "models.KeyRequestData": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "user": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "models.LrcRequestData": {
+            "type": "object",
+            "required": [
+                "group",
+                "lrc",
                 "song"
             ],
             "properties": {
@@ -300,44 +3337,223 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Muse"
                 },
+                "lrc": {
+                    "type": "string",
+                    "example": "[00:12.00]\u003c00:12.00\u003eOoh \u003c00:12.50\u003ebaby, \u003c00:13.00\u003edon't \u003c00:13.20\u003eyou \u003c00:13.40\u003eknow \u003c00:13.60\u003eI \u003c00:13.80\u003esuffer?\n[00:16.00]Ooh baby, can you hear me moan?"
+                },
                 "song": {
                     "type": "string",
                     "example": "Supermassive Black Hole"
                 }
             }
         },
-        "models.AnswerCoupletData": {
+        "models.LyricsData": {
             "type": "object",
             "required": [
+                "kind",
+                "lang",
                 "text"
             ],
             "properties": {
+                "kind": {
+                    "type": "string",
+                    "example": "translation"
+                },
+                "lang": {
+                    "type": "string",
+                    "example": "ru"
+                },
                 "text": {
                     "type": "string",
-                    "example": "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?"
+                    "example": "О, детка, разве ты не знаешь, что я страдаю?\nО, детка, ты слышишь мой стон?"
                 }
             }
         },
-        "models.AnswerData": {
+        "models.LyricsRequestData": {
             "type": "object",
             "required": [
-                "items"
+                "group",
+                "kind",
+                "lang",
+                "song"
             ],
             "properties": {
-                "items": {
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "translation"
+                },
+                "lang": {
+                    "type": "string",
+                    "example": "ru"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "О, детка, разве ты не знаешь, что я страдаю?\nО, детка, ты слышишь мой стон?"
+                }
+            }
+        },
+        "models.ManifestData": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "example": "2024-01-02T15:04:05Z"
+                },
+                "files": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.RowDbData"
+                        "$ref": "#/definitions/models.ManifestFileData"
                     }
+                },
+                "format": {
+                    "type": "string",
+                    "example": "song-library"
+                },
+                "schemaVersion": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "models.EditRequestData": {
+        "models.ManifestFileData": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "songs.jsonl"
+                },
+                "records": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                }
+            }
+        },
+        "models.PaginationData": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "type": "string",
+                    "example": "eyJzIjoiIiwidiI6W10sImkiOjEwfQ"
+                },
+                "prev": {
+                    "type": "string",
+                    "example": "eyJzIjoiIiwidiI6W10sImkiOjEsImIiOnRydWV9"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "totalEstimated": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.PlaylistData": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Encore included"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlaylistSongData"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Friday setlist"
+                }
+            }
+        },
+        "models.PlaylistImportData": {
+            "type": "object",
+            "properties": {
+                "playlist": {
+                    "$ref": "#/definitions/models.PlaylistData"
+                },
+                "unresolved": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlaylistSongData"
+                    }
+                }
+            }
+        },
+        "models.PlaylistMoveRequestData": {
             "type": "object",
             "required": [
-                "group",
-                "song"
+                "from",
+                "id",
+                "to"
+            ],
+            "properties": {
+                "from": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "to": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.PlaylistRequestData": {
+            "type": "object",
+            "required": [
+                "title"
             ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Encore included"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Friday setlist"
+                }
+            }
+        },
+        "models.PlaylistSongData": {
+            "type": "object",
             "properties": {
                 "group": {
                     "type": "string",
@@ -345,19 +3561,58 @@ const docTemplate = `{
                 },
                 "link": {
                     "type": "string",
-                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+                    "example": "https://www.youtube.com/watch?v=w8KQmps-Sog"
                 },
-                "releaseDate": {
-                    "type": "string",
-                    "example": "16.07.2006"
+                "position": {
+                    "type": "integer",
+                    "example": 1
                 },
                 "song": {
                     "type": "string",
-                    "example": "Supermassive Black Hole"
+                    "example": "Uprising"
+                }
+            }
+        },
+        "models.PlaylistSongRequestData": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
                 },
-                "text": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "song": {
                     "type": "string",
-                    "example": "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
+                    "example": "Uprising"
+                }
+            }
+        },
+        "models.RoleData": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "editor"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "songs:read",
+                        "songs:write"
+                    ]
                 }
             }
         },
@@ -375,6 +3630,17 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Muse"
                 },
+                "groupInfo": {
+                    "$ref": "#/definitions/models.GroupData"
+                },
+                "lang": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "langConfidence": {
+                    "type": "number",
+                    "example": 0.98
+                },
                 "link": {
                     "type": "string",
                     "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
@@ -392,6 +3658,87 @@ const docTemplate = `{
                     "example": "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
                 }
             }
+        },
+        "models.TimedWordData": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "example": "baby, "
+                },
+                "time": {
+                    "type": "integer",
+                    "example": 12500
+                }
+            }
+        },
+        "models.UserData": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "viewer"
+                }
+            }
+        },
+        "models.UserRequestData": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "viewer"
+                }
+            }
+        },
+        "models.VerseData": {
+            "type": "object",
+            "properties": {
+                "exact": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "number": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "repeatOf": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "text": {
+                    "type": "string",
+                    "example": "[Repeat verse 2]"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API key issued by /issuekey or musicctl adduser, also accepted as \"Authorization: Bearer \u003ckey\u003e\"",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "\"Bearer \u003cJWT\u003e\" signed with RS256 or ES256 by a key of the JWKS, with the scope of the route: songs:read, songs:write, songs:delete, playlists:read, playlists:write, library:dump or library:restore",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -403,7 +3750,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "",
 	Schemes:          []string{},
 	Title:            "Go Music",
-	Description:      "This is a sample server for music library",
+	Description:      "This is a sample server for music library. Every request acts on the library of a tenant: the tenant of the user of the API key or of the tenant claim of the JWT, otherwise the default tenant. Naming another tenant in the X-Tenant header is forbidden, except for the JWTs with the tenants:select scope and no tenant claim.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
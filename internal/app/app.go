@@ -2,37 +2,262 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+	"log/slog"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"test/internal/auth"
+	"test/internal/breaker"
+	"test/internal/certs"
+	"test/internal/config"
 	"test/internal/database"
+	"test/internal/health"
+	"test/internal/metrics"
+	"test/internal/policy"
+	"test/internal/ratelimit"
 	"test/internal/services"
+	"test/internal/tracing"
 	"test/internal/transport/rest"
+	"test/internal/transport/rpc"
+	"test/internal/transport/rpc/musicpb"
+	"time"
 )
 
+// ReadOnlyRoutes are the routes that can be made public, they only read the library. The
+// anonymous callers need the permission of the route in the viewer role.
+var ReadOnlyRoutes = []string{
+	"/getdata", "/getsongtext", "/getsongstructure", "/getlrc", "/getchords", "/getsongline",
+	"/getlyrics", "/getplaylist", "/getplaylists", "/exportplaylist",
+}
+
 type App struct {
 	pool   database.DBPool
-	ip     string
-	port   string
-	apiurl string
+	config config.Config
+	// circuit guards the calls to the metadata provider
+	circuit *breaker.Breaker
+}
+
+// NewApp returns the application of the configuration. The public routes are served without an
+// API key and have to be among ReadOnlyRoutes. JWTs are accepted when a JWKS is configured. With
+// row level security the pool has to set the tenant of its connections with database.SetTenant.
+// The requests are limited per client by the limits of their classes. The Prometheus metrics are
+// served on /metrics, the liveness on /healthz and the readiness on /readyz without an API key.
+// The gRPC API is served on its own port with the same keys, tenants and limits, and reflection.
+func NewApp(pool database.DBPool, config config.Config) *App {
+	circuit := breaker.New(config.API.BreakerFailures, config.API.BreakerCooldown, nil)
+	return &App{pool: pool, config: config, circuit: circuit}
+}
+
+// tokens returns the verifier of the JWTs signed by the keys of the JWKS, a file path or a URL,
+// or nil when it is not configured.
+func (a *App) tokens() *auth.Verifier {
+	if a.config.Auth.JWKS == "" {
+		return nil
+	}
+	return &auth.Verifier{
+		Keys:     auth.NewKeySet(a.config.Auth.JWKS, &http.Client{Timeout: 10 * time.Second}, 10*time.Minute),
+		Issuer:   a.config.Auth.Issuer,
+		Audience: a.config.Auth.Audience,
+		Skew:     a.config.Auth.ClockSkew,
+	}
 }
 
-func NewApp(pool database.DBPool, ip string, port string, apiurl string) *App {
-	return &App{pool: pool, ip: ip, port: port, apiurl: apiurl}
+// quotaPruneInterval is how often the quotas of the days that are over are deleted.
+const quotaPruneInterval = time.Hour
+
+// pruneQuotas deletes the quotas of the days that are over on every tick until the context is
+// done, a failure is retried on the next tick.
+func pruneQuotas(ctx context.Context, db *database.PGXDatabase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := db.DeleteQuotasQuery(ctx, now.UTC()); err != nil {
+				slog.Warn("Failed to delete the expired quotas", "error", err)
+			}
+		}
+	}
 }
-func (a *App) Run() error {
+
+// Run serves the requests until the context is done, then it stops accepting connections and
+// waits for the requests in flight for at most the shutdown timeout. It returns nil once they
+// are drained, the pool is left to the caller to close.
+func (a *App) Run(ctx context.Context) error {
+	public := map[string]bool{}
+	for _, route := range a.config.Server.PublicRoutes {
+		if !slices.Contains(ReadOnlyRoutes, route) {
+			return fmt.Errorf("The route %s can not be public, expected one of %s", route, strings.Join(ReadOnlyRoutes, ", "))
+		}
+		public[route] = true
+	}
 	db := database.NewPGXDatabase(a.pool)
 	err := db.CreateTableQuery(context.Background())
 	if err != nil {
 		return err
 	}
-	client := &http.Client{}
-	tokenservice := services.NewService(db, a.apiurl, client)
-	handler := rest.NewHandler(tokenservice)
-	http.HandleFunc("/addsong", handler.AddSong)
-	http.HandleFunc("/deletesong", handler.DeleteSong)
-	http.HandleFunc("/editsong", handler.EditSong)
-	http.HandleFunc("/getdata", handler.GetSongs)
-	http.HandleFunc("/getsongtext", handler.GetSongText)
-	// http.HandleFunc("/info", handler.Info)
-	err = http.ListenAndServe(a.ip+":"+a.port, nil)
-	return err
+	err = db.RowSecurityQuery(context.Background(), a.config.Database.TenantRLS)
+	if err != nil {
+		return err
+	}
+	err = db.DeleteQuotasQuery(context.Background(), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	// the days that are over are pruned while serving too, the pool is only left to the caller
+	// once the pruning is stopped
+	workers, stopWorkers := context.WithCancel(context.Background())
+	pruned := make(chan struct{})
+	go func() {
+		pruneQuotas(workers, db, quotaPruneInterval)
+		close(pruned)
+	}()
+	defer func() {
+		stopWorkers()
+		<-pruned
+	}()
+	metric := metrics.New()
+	if pool, ok := a.pool.(metrics.PoolStats); ok {
+		metric.RegisterPool(pool)
+	}
+	client := &http.Client{Transport: tracing.Transport(metric.Transport(a.circuit.Transport(http.DefaultTransport)))}
+	tokenservice := services.NewService(db, a.config.API.URL, client)
+	service := policy.New(tokenservice)
+	handler := rest.NewHandler(service)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/addsong", handler.AddSong)
+	mux.HandleFunc("/importsongs", handler.ImportSongs)
+	mux.HandleFunc("/deletesong", handler.DeleteSong)
+	mux.HandleFunc("/deletegroup", handler.DeleteGroup)
+	mux.HandleFunc("/renamegroup", handler.RenameGroup)
+	mux.HandleFunc("/editsong", handler.EditSong)
+	mux.HandleFunc("/getdata", handler.GetSongs)
+	mux.HandleFunc("/getsongtext", handler.GetSongText)
+	mux.HandleFunc("/getsongstructure", handler.GetSongStructure)
+	mux.HandleFunc("/addlrc", handler.AddLrc)
+	mux.HandleFunc("/getlrc", handler.GetLrc)
+	mux.HandleFunc("/addchords", handler.AddChords)
+	mux.HandleFunc("/getchords", handler.GetChords)
+	mux.HandleFunc("/getsongline", handler.GetSongLine)
+	mux.HandleFunc("/addlyrics", handler.AddLyrics)
+	mux.HandleFunc("/deletelyrics", handler.DeleteLyrics)
+	mux.HandleFunc("/getlyrics", handler.GetLyrics)
+	mux.HandleFunc("/addplaylist", handler.AddPlaylist)
+	mux.HandleFunc("/editplaylist", handler.EditPlaylist)
+	mux.HandleFunc("/deleteplaylist", handler.DeletePlaylist)
+	mux.HandleFunc("/getplaylist", handler.GetPlaylist)
+	mux.HandleFunc("/getplaylists", handler.GetPlaylists)
+	mux.HandleFunc("/addplaylistsong", handler.AddPlaylistSong)
+	mux.HandleFunc("/removeplaylistsong", handler.RemovePlaylistSong)
+	mux.HandleFunc("/moveplaylistsong", handler.MovePlaylistSong)
+	mux.HandleFunc("/exportplaylist", handler.ExportPlaylist)
+	mux.HandleFunc("/importplaylist", handler.ImportPlaylist)
+	mux.HandleFunc("/adduser", handler.AddUser)
+	mux.HandleFunc("/issuekey", handler.IssueKey)
+	mux.HandleFunc("/rotatekey", handler.RotateKey)
+	mux.HandleFunc("/revokekey", handler.RevokeKey)
+	mux.HandleFunc("/getkeys", handler.GetKeys)
+	mux.HandleFunc("/getroles", handler.GetRoles)
+	mux.HandleFunc("/setrole", handler.SetRole)
+	mux.HandleFunc("/assignrole", handler.AssignRole)
+	mux.HandleFunc("/dumplibrary", handler.DumpLibrary)
+	mux.HandleFunc("/restorelibrary", handler.RestoreLibrary)
+	// mux.HandleFunc("/info", handler.Info)
+	limiter := ratelimit.New(a.config.Limits.Map(), nil)
+	// a nil *auth.Verifier would not be a nil TokenVerifier
+	var tokens auth.TokenVerifier
+	if verifier := a.tokens(); verifier != nil {
+		tokens = verifier
+	}
+	server := handler.Admit(rest.RateLimit(limiter, db, mux), tokens, public)
+	top := http.NewServeMux()
+	top.Handle("/metrics", metric.Handler())
+	top.HandleFunc("/healthz", rest.Healthz)
+	top.Handle("/readyz", rest.Readyz(&health.Checker{Timeout: a.config.Server.ReadyTimeout, Checks: map[string]health.Check{
+		"database":   health.Database(db),
+		"migrations": health.Migrations(db, database.SchemaVersion),
+		"metadata":   health.Circuit(a.circuit),
+	}}))
+	top.Handle("/", rest.Trace(mux, rest.RequestLog(rest.Instrument(metric, mux, server))))
+	httpServer := &http.Server{
+		Addr:              a.config.Server.IP + ":" + a.config.Server.Port,
+		Handler:           top,
+		ReadTimeout:       a.config.Server.ReadTimeout,
+		ReadHeaderTimeout: a.config.Server.ReadHeaderTimeout,
+		WriteTimeout:      a.config.Server.WriteTimeout,
+		IdleTimeout:       a.config.Server.IdleTimeout,
+		MaxHeaderBytes:    a.config.Server.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	guard := &rpc.Guard{Keys: service, Tokens: tokens, Public: public, Limiter: limiter, Quotas: db}
+	options := []grpc.ServerOption{grpc.ChainUnaryInterceptor(guard.Unary()), grpc.ChainStreamInterceptor(guard.Stream())}
+	secure := a.config.Server.TLSCertFile != "" && a.config.Server.TLSKeyFile != ""
+	if secure {
+		reloader, err := certs.NewReloader(a.config.Server.TLSCertFile, a.config.Server.TLSKeyFile, nil)
+		if err != nil {
+			return err
+		}
+		httpServer.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: reloader.GetCertificate}
+		options = append(options, grpc.Creds(credentials.NewTLS(httpServer.TLSConfig.Clone())))
+	}
+	grpcServer := grpc.NewServer(options...)
+	musicpb.RegisterMusicServer(grpcServer, rpc.NewServer(service))
+	reflection.Register(grpcServer)
+	served := make(chan error, 2)
+	if a.config.Server.GRPCPort != "" {
+		listener, err := net.Listen("tcp", a.config.Server.IP+":"+a.config.Server.GRPCPort)
+		if err != nil {
+			return err
+		}
+		go func() {
+			slog.Info("Serving gRPC", "address", listener.Addr().String(), "tls", secure)
+			served <- grpcServer.Serve(listener)
+		}()
+	}
+	go func() {
+		slog.Info("Serving", "address", httpServer.Addr, "tls", secure)
+		if secure {
+			served <- httpServer.ListenAndServeTLS("", "")
+		} else {
+			served <- httpServer.ListenAndServe()
+		}
+	}()
+	select {
+	case err := <-served:
+		grpcServer.Stop()
+		httpServer.Close()
+		return err
+	case <-ctx.Done():
+	}
+	slog.Info("Shutting down, draining the requests in flight", "timeout", a.config.Server.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.config.Server.ShutdownTimeout)
+	defer cancel()
+	// the streams in flight are drained like the requests, then they are cut
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	go func() {
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+		}
+	}()
+	err = httpServer.Shutdown(shutdownCtx)
+	<-stopped
+	if err != nil {
+		httpServer.Close()
+		return fmt.Errorf("Failed to drain the requests in flight: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,197 @@
+package archive
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"test/internal/models"
+	"time"
+)
+
+// Format names the library archives in their manifests.
+const Format = "song-library"
+
+// Version is the version of the layout of the archives, archives of later versions are not read.
+const Version = 1
+
+// ManifestName is the name of the manifest in the archive.
+const ManifestName = "manifest.json"
+
+// maxRecord is the largest record of an archive, a song with its text.
+const maxRecord = 16 << 20
+
+// Writer writes a library archive: a zip file with a JSON lines file per section and the
+// manifest, which is written last as the checksums are only known once the files are written.
+// The files are streamed, so the size of the library does not matter.
+type Writer struct {
+	zip      *zip.Writer
+	manifest models.ManifestData
+	file     io.Writer
+	hash     hash.Hash
+	encoder  *json.Encoder
+}
+
+func NewWriter(w io.Writer, schemaVersion int, createdAt time.Time) *Writer {
+	return &Writer{
+		zip: zip.NewWriter(w),
+		manifest: models.ManifestData{
+			Format:        Format,
+			Version:       Version,
+			SchemaVersion: schemaVersion,
+			CreatedAt:     createdAt.UTC(),
+			Files:         []models.ManifestFileData{},
+		},
+	}
+}
+
+// Section starts the file of the section, closing the previous one.
+func (w *Writer) Section(name string) error {
+	w.closeFile()
+	file, err := w.zip.CreateHeader(&zip.FileHeader{Name: name + ".jsonl", Method: zip.Deflate, Modified: w.manifest.CreatedAt})
+	if err != nil {
+		return err
+	}
+	w.hash = sha256.New()
+	w.file = file
+	w.encoder = json.NewEncoder(io.MultiWriter(file, w.hash))
+	w.manifest.Files = append(w.manifest.Files, models.ManifestFileData{Name: name + ".jsonl"})
+	return nil
+}
+
+// Record writes the record as a line of the current section.
+func (w *Writer) Record(record interface{}) error {
+	if w.file == nil {
+		return fmt.Errorf("No section is started")
+	}
+	if err := w.encoder.Encode(record); err != nil {
+		return err
+	}
+	w.manifest.Files[len(w.manifest.Files)-1].Records++
+	return nil
+}
+
+func (w *Writer) closeFile() {
+	if w.file != nil {
+		w.manifest.Files[len(w.manifest.Files)-1].SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+		w.file = nil
+	}
+}
+
+// Close writes the manifest and finishes the archive, the manifest is returned.
+func (w *Writer) Close() (models.ManifestData, error) {
+	w.closeFile()
+	file, err := w.zip.CreateHeader(&zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: w.manifest.CreatedAt})
+	if err != nil {
+		return w.manifest, err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(w.manifest); err != nil {
+		return w.manifest, err
+	}
+	return w.manifest, w.zip.Close()
+}
+
+// Reader reads a library archive whose files were checked against the manifest.
+type Reader struct {
+	zip      *zip.Reader
+	Manifest models.ManifestData
+}
+
+// NewReader opens the archive and checks its format and version and the record counts and
+// checksums of its files, so that nothing is restored from a damaged archive.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid archive: %w", err)
+	}
+	reader := &Reader{zip: archive}
+	file, err := archive.Open(ManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid archive: the manifest is missing")
+	}
+	defer file.Close()
+	if err := json.NewDecoder(file).Decode(&reader.Manifest); err != nil {
+		return nil, fmt.Errorf("Invalid manifest: %w", err)
+	}
+	if reader.Manifest.Format != Format {
+		return nil, fmt.Errorf("Invalid manifest: the format is %q, expected %q", reader.Manifest.Format, Format)
+	}
+	if reader.Manifest.Version < 1 || reader.Manifest.Version > Version {
+		return nil, fmt.Errorf("Unsupported archive version %d, expected at most %d", reader.Manifest.Version, Version)
+	}
+	for _, file := range reader.Manifest.Files {
+		if err := reader.verify(file); err != nil {
+			return nil, err
+		}
+	}
+	return reader, nil
+}
+
+func (r *Reader) verify(expected models.ManifestFileData) error {
+	file, err := r.zip.Open(expected.Name)
+	if err != nil {
+		return fmt.Errorf("Invalid archive: %s is missing", expected.Name)
+	}
+	defer file.Close()
+	hash := sha256.New()
+	records, err := scan(io.TeeReader(file, hash), func(line []byte) error { return nil })
+	if err != nil {
+		return fmt.Errorf("Invalid archive: %s: %w", expected.Name, err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != expected.SHA256 {
+		return fmt.Errorf("Invalid archive: the checksum of %s does not match the manifest", expected.Name)
+	}
+	if records != expected.Records {
+		return fmt.Errorf("Invalid archive: %s has %d records, the manifest lists %d", expected.Name, records, expected.Records)
+	}
+	return nil
+}
+
+// ReadSection passes every record of the section to each, a section missing from the manifest
+// has no records.
+func (r *Reader) ReadSection(name string, each func(decode func(record interface{}) error) error) error {
+	found := false
+	for _, file := range r.Manifest.Files {
+		found = found || file.Name == name+".jsonl"
+	}
+	if !found {
+		return nil
+	}
+	file, err := r.zip.Open(name + ".jsonl")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	number := 0
+	_, err = scan(file, func(line []byte) error {
+		number++
+		err := each(func(record interface{}) error {
+			return json.Unmarshal(line, record)
+		})
+		if err != nil {
+			return fmt.Errorf("record %d: %w", number, err)
+		}
+		return nil
+	})
+	return err
+}
+
+// scan passes every line of the file to each and returns the number of lines.
+func scan(r io.Reader, each func(line []byte) error) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxRecord)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+		if err := each(scanner.Bytes()); err != nil {
+			return lines, err
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"test/internal/models"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T) []byte {
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer, 1, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	assert.NoError(t, writer.Section("groups"))
+	assert.NoError(t, writer.Record(models.DumpGroupData{Name: "Muse"}))
+	assert.NoError(t, writer.Section("songs"))
+	assert.NoError(t, writer.Record(models.DumpSongData{Group: "Muse", Song: "Uprising", Date: "07.09.2009"}))
+	assert.NoError(t, writer.Record(models.DumpSongData{Group: "Muse", Song: "Hysteria"}))
+	assert.NoError(t, writer.Section("lyrics"))
+	manifest, err := writer.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, Format, manifest.Format)
+	assert.Equal(t, []int{1, 2, 0}, []int{manifest.Files[0].Records, manifest.Files[1].Records, manifest.Files[2].Records})
+	assert.Len(t, manifest.Files[1].SHA256, 64)
+	return buffer.Bytes()
+}
+
+func TestWriteRead(t *testing.T) {
+	data := write(t)
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reader.Manifest.SchemaVersion)
+	assert.Equal(t, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), reader.Manifest.CreatedAt)
+	var songs []models.DumpSongData
+	err = reader.ReadSection("songs", func(decode func(interface{}) error) error {
+		var song models.DumpSongData
+		if err := decode(&song); err != nil {
+			return err
+		}
+		songs = append(songs, song)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.DumpSongData{{Group: "Muse", Song: "Uprising", Date: "07.09.2009"}, {Group: "Muse", Song: "Hysteria"}}, songs)
+	err = reader.ReadSection("song_lines", func(decode func(interface{}) error) error {
+		t.Fatal("a missing section has no records")
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+// rewrite copies the archive replacing the content of the file.
+func rewrite(t *testing.T, data []byte, name string, content string) []byte {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for _, file := range reader.File {
+		w, err := writer.Create(file.Name)
+		assert.NoError(t, err)
+		if file.Name == name {
+			io.WriteString(w, content)
+			continue
+		}
+		r, err := file.Open()
+		assert.NoError(t, err)
+		io.Copy(w, r)
+		r.Close()
+	}
+	assert.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func TestNewReaderErrors(t *testing.T) {
+	data := write(t)
+	_, err := NewReader(bytes.NewReader([]byte("not a zip")), 9)
+	assert.ErrorContains(t, err, "Invalid archive")
+	tampered := rewrite(t, data, "songs.jsonl", `{"group":"Muse","song":"Uprising"}`+"\n")
+	_, err = NewReader(bytes.NewReader(tampered), int64(len(tampered)))
+	assert.EqualError(t, err, "Invalid archive: the checksum of songs.jsonl does not match the manifest")
+	newer := rewrite(t, data, ManifestName, `{"format": "song-library", "version": 2}`)
+	_, err = NewReader(bytes.NewReader(newer), int64(len(newer)))
+	assert.EqualError(t, err, "Unsupported archive version 2, expected at most 1")
+	other := rewrite(t, data, ManifestName, `{"format": "playlist", "version": 1}`)
+	_, err = NewReader(bytes.NewReader(other), int64(len(other)))
+	assert.EqualError(t, err, "Invalid manifest: the format is \"playlist\", expected \"song-library\"")
+}
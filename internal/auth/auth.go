@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"test/internal/models"
+)
+
+// KeyPrefix starts every API key so that leaked keys are easy to recognise.
+const KeyPrefix = "sl_"
+
+type principalKey struct{}
+
+// WithPrincipal returns the context of the request made by the principal.
+func WithPrincipal(ctx context.Context, principal models.PrincipalData) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal of the request, it is missing for the calls that were not
+// authenticated.
+func PrincipalFrom(ctx context.Context) (models.PrincipalData, bool) {
+	principal, ok := ctx.Value(principalKey{}).(models.PrincipalData)
+	return principal, ok
+}
+
+// GenerateKey returns a new API key with its lookup prefix and hash, only the prefix and hash are
+// stored. The key is KeyPrefix, the 8 characters of the prefix, an underscore and 43 characters
+// of URL-safe base64 secret.
+func GenerateKey() (key string, prefix string, hash string, err error) {
+	random := make([]byte, 36)
+	if _, err = rand.Read(random); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(random[:4])
+	key = KeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(random[4:])
+	return key, prefix, HashKey(key), nil
+}
+
+// HashKey returns the hex SHA-256 of the key. Keys are random so a fast hash is enough to keep
+// them from being read back from the database.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestKey returns the API key of the request, given as a bearer token or the X-API-Key header.
+func RequestKey(r *http.Request) string {
+	return PresentedKey(r.Header.Get("X-API-Key"), r.Header.Get("Authorization"))
+}
+
+// PresentedKey returns the API key of the values of the X-API-Key and Authorization headers, or
+// of the metadata of the same names, the first one wins.
+func PresentedKey(apiKey string, authorization string) string {
+	if key := strings.TrimSpace(apiKey); key != "" {
+		return key
+	}
+	scheme, token, found := strings.Cut(authorization, " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
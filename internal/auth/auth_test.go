@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"regexp"
+	"test/internal/models"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, prefix, hash, err := GenerateKey()
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^sl_[0-9a-f]{8}_[A-Za-z0-9_-]{43}$`), key)
+	assert.Equal(t, key[3:11], prefix)
+	assert.Equal(t, HashKey(key), hash)
+	assert.Len(t, hash, 64)
+	other, _, _, _ := GenerateKey()
+	assert.NotEqual(t, key, other)
+}
+
+func TestRequestKey(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/getdata", nil)
+	assert.Equal(t, "", RequestKey(req))
+	req.Header.Set("Authorization", "Basic YWxpY2U6c2VjcmV0")
+	assert.Equal(t, "", RequestKey(req))
+	req.Header.Set("Authorization", "bearer sl_1")
+	assert.Equal(t, "sl_1", RequestKey(req))
+	req.Header.Set("X-API-Key", "sl_2")
+	assert.Equal(t, "sl_2", RequestKey(req))
+}
+
+func TestPrincipal(t *testing.T) {
+	_, ok := PrincipalFrom(context.Background())
+	assert.False(t, ok)
+	principal := models.PrincipalData{UserID: 1, User: "alice", Role: models.RoleEditor, KeyID: 3}
+	result, ok := PrincipalFrom(WithPrincipal(context.Background(), principal))
+	assert.True(t, ok)
+	assert.Equal(t, principal, result)
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"test/internal/models"
+	"test/internal/tenant"
+)
+
+// KeyAuthenticator returns the principal of an API key.
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (result models.PrincipalData, err error, status int)
+}
+
+// TokenVerifier checks the signature and the claims of a JWT.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (Claims, error)
+}
+
+// AnonymousAuthenticator is implemented by the KeyAuthenticators that store the roles, it returns
+// Anonymous with the permissions of the stored viewer role.
+type AnonymousAuthenticator interface {
+	AuthenticateAnonymous(ctx context.Context) (result models.PrincipalData, err error, status int)
+}
+
+// Anonymous is the principal of the callers of the public routes, it has the permissions of a
+// viewer so that a public route never shows more than a viewer with a key reads. The
+// KeyAuthenticators that are AnonymousAuthenticators replace the default permissions with the
+// permissions of the viewer role they store.
+var Anonymous = models.PrincipalData{
+	User:        "anonymous",
+	Role:        models.RoleViewer,
+	Permissions: models.DefaultRoles[models.RoleViewer],
+}
+
+// ErrKeyRequired rejects the callers of the routes that are not public without a key.
+var ErrKeyRequired = errors.New("An API key is required")
+
+// ScopeError rejects a JWT without the scope of the route.
+type ScopeError struct {
+	Route string
+	Scope string
+}
+
+func (e *ScopeError) Error() string {
+	return "The token does not allow " + e.Route
+}
+
+// Credentials are the values a caller presents, read from the headers of a REST request or the
+// metadata of a gRPC call.
+type Credentials struct {
+	// Key is the API key or the JWT.
+	Key string
+	// Tenant is the tenant the caller names.
+	Tenant string
+	// Addr is the host and port of the caller.
+	Addr string
+}
+
+// Caller is a caller admitted to a route.
+type Caller struct {
+	Principal models.PrincipalData
+	Tenant    string
+	// Client identifies the caller for the rate limits and the daily quotas, see Client.
+	Client string
+}
+
+// Resolver admits the callers of the REST routes and of the gRPC methods alike, so that both
+// transports authenticate, scope and assign tenants the same way.
+type Resolver struct {
+	Keys KeyAuthenticator
+	// Tokens verifies the JWTs, they are taken for API keys when it is nil.
+	Tokens TokenVerifier
+	// Scopes are the scopes a JWT needs to call the routes, the routes without one can not be
+	// called with tokens.
+	Scopes map[string]string
+}
+
+// Resolve returns the caller of the route from its credentials, or the error rejecting it with
+// its HTTP status. A JWT becomes a principal with the service role and its scopes as
+// permissions, an API key the principal of its user, and the callers of a public route without
+// a key are Anonymous. The callers act on the tenant of their principal. The services whose JWT
+// has no tenant claim but the tenants:select scope act on the tenant they name, and the other
+// principals without a tenant on the default one. Naming another tenant is forbidden.
+func (r *Resolver) Resolve(ctx context.Context, route string, public bool, credentials Credentials) (result Caller, err error, status int) {
+	result.Principal, err, status = r.authenticate(ctx, route, public, credentials.Key)
+	if err != nil {
+		return result, err, status
+	}
+	if credentials.Tenant != "" {
+		if err = tenant.Validate(credentials.Tenant); err != nil {
+			return result, err, http.StatusBadRequest
+		}
+	}
+	result.Tenant = result.Principal.Tenant
+	if result.Tenant == "" && result.Principal.Role == models.RoleService && result.Principal.Allows(models.ScopeTenantsSelect) {
+		result.Tenant = credentials.Tenant
+	}
+	if result.Tenant == "" {
+		result.Tenant = tenant.Default
+	}
+	if err = tenant.Validate(result.Tenant); err != nil {
+		return result, err, http.StatusBadRequest
+	}
+	if credentials.Tenant != "" && credentials.Tenant != result.Tenant {
+		return result, fmt.Errorf("The tenant %s is not the tenant of the caller", credentials.Tenant), http.StatusForbidden
+	}
+	result.Client = Client(result.Principal, credentials.Addr)
+	return result, nil, http.StatusOK
+}
+
+func (r *Resolver) authenticate(ctx context.Context, route string, public bool, key string) (models.PrincipalData, error, int) {
+	if key == "" && public {
+		if keys, ok := r.Keys.(AnonymousAuthenticator); ok {
+			return keys.AuthenticateAnonymous(ctx)
+		}
+		return Anonymous, nil, http.StatusOK
+	}
+	if key == "" {
+		return models.PrincipalData{}, ErrKeyRequired, http.StatusUnauthorized
+	}
+	if r.Tokens == nil || !LooksLikeJWT(key) {
+		return r.Keys.Authenticate(ctx, key)
+	}
+	claims, err := r.Tokens.Verify(ctx, key)
+	if err == nil && claims.Subject == "" {
+		err = ErrInvalidToken
+	}
+	if err != nil {
+		slog.InfoContext(ctx, "Rejected token", "error", err)
+		return models.PrincipalData{}, err, http.StatusUnauthorized
+	}
+	scope, found := r.Scopes[route]
+	if !found || !slices.Contains(claims.Scopes, scope) {
+		return models.PrincipalData{}, &ScopeError{Route: route, Scope: scope}, http.StatusForbidden
+	}
+	return models.PrincipalData{User: claims.Subject, Role: models.RoleService, Permissions: claims.Scopes, Tenant: claims.Tenant}, nil, http.StatusOK
+}
+
+// Client identifies the caller for the limits: the API key, the subject of the JWT, or the IP
+// address of the anonymous callers.
+func Client(principal models.PrincipalData, addr string) string {
+	switch {
+	case principal.KeyID != 0:
+		return fmt.Sprintf("key:%d", principal.KeyID)
+	case principal.Role == models.RoleService:
+		return "token:" + principal.User
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"test/internal/models"
+	"testing"
+)
+
+// fixedKeys authenticates the keys of its map.
+type fixedKeys map[string]models.PrincipalData
+
+func (k fixedKeys) Authenticate(ctx context.Context, key string) (models.PrincipalData, error, int) {
+	if principal, found := k[key]; found {
+		return principal, nil, http.StatusOK
+	}
+	return models.PrincipalData{}, errors.New("Invalid API key"), http.StatusUnauthorized
+}
+
+// fixedTokens accepts the token "a.b.c" with its claims.
+type fixedTokens Claims
+
+func (c fixedTokens) Verify(ctx context.Context, token string) (Claims, error) {
+	if token != "a.b.c" {
+		return Claims{}, ErrInvalidToken
+	}
+	return Claims(c), nil
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	alice := models.PrincipalData{User: "alice", Role: models.RoleEditor, KeyID: 2}
+	bob := models.PrincipalData{User: "bob", Role: models.RoleEditor, KeyID: 3, Tenant: "acme"}
+	resolver := &Resolver{
+		Keys:   fixedKeys{"sl_alice": alice, "sl_bob": bob},
+		Tokens: fixedTokens{Subject: "billing", Scopes: []string{"songs:read"}},
+		Scopes: map[string]string{"/getdata": "songs:read", "/deletesong": "songs:delete"},
+	}
+	service := models.PrincipalData{User: "billing", Role: models.RoleService, Permissions: []string{"songs:read"}}
+	cases := []struct {
+		name        string
+		route       string
+		public      bool
+		credentials Credentials
+		status      int
+		caller      Caller
+	}{
+		{"key", "/deletesong", false, Credentials{Key: "sl_alice", Addr: "10.0.0.1:5000"}, http.StatusOK, Caller{alice, "default", "key:2"}},
+		{"wrong key", "/deletesong", false, Credentials{Key: "sl_eve"}, http.StatusUnauthorized, Caller{}},
+		{"no key", "/deletesong", false, Credentials{}, http.StatusUnauthorized, Caller{}},
+		{"anonymous", "/getdata", true, Credentials{Addr: "10.0.0.1:5000"}, http.StatusOK, Caller{Anonymous, "default", "ip:10.0.0.1"}},
+		{"anonymous tenant", "/getdata", true, Credentials{Tenant: "globex", Addr: "10.0.0.1:5000"}, http.StatusForbidden, Caller{}},
+		{"anonymous default tenant", "/getdata", true, Credentials{Tenant: "default", Addr: "10.0.0.1:5000"}, http.StatusOK, Caller{Anonymous, "default", "ip:10.0.0.1"}},
+		{"tenant of the key", "/getdata", false, Credentials{Key: "sl_bob"}, http.StatusOK, Caller{bob, "acme", "key:3"}},
+		{"other tenant", "/getdata", false, Credentials{Key: "sl_bob", Tenant: "globex"}, http.StatusForbidden, Caller{}},
+		{"invalid tenant", "/getdata", true, Credentials{Tenant: "Globex Corp"}, http.StatusBadRequest, Caller{}},
+		{"token", "/getdata", false, Credentials{Key: "a.b.c"}, http.StatusOK, Caller{service, "default", "token:billing"}},
+		{"token tenant", "/getdata", false, Credentials{Key: "a.b.c", Tenant: "globex"}, http.StatusForbidden, Caller{}},
+		{"forged token", "/getdata", false, Credentials{Key: "x.y.z"}, http.StatusUnauthorized, Caller{}},
+		{"token scope", "/deletesong", false, Credentials{Key: "a.b.c"}, http.StatusForbidden, Caller{}},
+	}
+	for _, c := range cases {
+		caller, err, status := resolver.Resolve(context.Background(), c.route, c.public, c.credentials)
+		assert.Equal(t, c.status, status, c.name)
+		if c.status != http.StatusOK {
+			assert.Error(t, err, c.name)
+			continue
+		}
+		assert.NoError(t, err, c.name)
+		assert.Equal(t, c.caller, caller, c.name)
+	}
+	_, err, _ := resolver.Resolve(context.Background(), "/deletesong", false, Credentials{Key: "a.b.c"})
+	var scope *ScopeError
+	if assert.ErrorAs(t, err, &scope) {
+		assert.Equal(t, "songs:delete", scope.Scope)
+	}
+	// a service with the tenants:select scope and no tenant claim names its tenant
+	resolver.Tokens = fixedTokens{Subject: "billing", Scopes: []string{"songs:read", models.ScopeTenantsSelect}}
+	caller, err, status := resolver.Resolve(context.Background(), "/getdata", false, Credentials{Key: "a.b.c", Tenant: "globex"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "globex", caller.Tenant)
+	caller, _, _ = resolver.Resolve(context.Background(), "/getdata", false, Credentials{Key: "a.b.c"})
+	assert.Equal(t, "default", caller.Tenant)
+	// the scope does not override the tenant claim
+	resolver.Tokens = fixedTokens{Subject: "billing", Scopes: []string{"songs:read", models.ScopeTenantsSelect}, Tenant: "acme"}
+	_, _, status = resolver.Resolve(context.Background(), "/getdata", false, Credentials{Key: "a.b.c", Tenant: "globex"})
+	assert.Equal(t, http.StatusForbidden, status)
+	// without a verifier the tokens are taken for keys
+	resolver.Tokens = nil
+	_, _, status = resolver.Resolve(context.Background(), "/getdata", false, Credentials{Key: "a.b.c"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestPresentedKey(t *testing.T) {
+	assert.Equal(t, "", PresentedKey("", ""))
+	assert.Equal(t, "sl_1", PresentedKey("", "Bearer sl_1"))
+	assert.Equal(t, "sl_2", PresentedKey(" sl_2 ", "Bearer sl_1"))
+	assert.Equal(t, "", PresentedKey("", "Basic YWxpY2U6c2VjcmV0"))
+}
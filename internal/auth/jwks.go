@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwk is a JSON Web Key of RFC 7517, only the public RSA and P-256 keys used to sign are read.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey is a key of the set with the algorithm it verifies.
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// KeySet is a JWKS loaded from a file or a URL. It is reloaded when it gets older than the
+// refresh interval and when a token names a key it does not have, so that rotated keys are
+// picked up without a restart. The requests needing a reload at the same time share one fetch,
+// and the others keep verifying with the loaded keys meanwhile.
+type KeySet struct {
+	source  string
+	client  *http.Client
+	refresh time.Duration
+	// retry is the least time between reloads for unknown keys
+	retry time.Duration
+	now   func() time.Time
+	loads singleflight.Group
+
+	mu       sync.Mutex
+	keys     map[string]publicKey
+	loadedAt time.Time
+}
+
+// NewKeySet returns the key set of the source, an http or https URL or a file path. It is loaded
+// on first use.
+func NewKeySet(source string, client *http.Client, refresh time.Duration) *KeySet {
+	return &KeySet{source: source, client: client, refresh: refresh, retry: 30 * time.Second, now: time.Now}
+}
+
+// Key returns the key with the id and its algorithm. A token without a key id can only be
+// verified by a set of one key.
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, string, error) {
+	s.mu.Lock()
+	stale := s.keys == nil || s.now().Sub(s.loadedAt) >= s.refresh
+	s.mu.Unlock()
+	if stale {
+		if err := s.load(ctx); err != nil && !s.loaded() {
+			return nil, "", err
+		}
+	}
+	s.mu.Lock()
+	key, found := s.lookup(kid)
+	retry := !found && !stale && s.now().Sub(s.loadedAt) >= s.retry
+	s.mu.Unlock()
+	if retry {
+		if err := s.load(ctx); err != nil {
+			return nil, "", err
+		}
+		s.mu.Lock()
+		key, found = s.lookup(kid)
+		s.mu.Unlock()
+	}
+	if !found {
+		return nil, "", fmt.Errorf("Unknown signing key %q", kid)
+	}
+	return key.key, key.alg, nil
+}
+
+// loaded reports whether the set has keys.
+func (s *KeySet) loaded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys != nil
+}
+
+// lookup returns the key with the id, s.mu is held.
+func (s *KeySet) lookup(kid string) (publicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, found := s.keys[kid]
+	return key, found
+}
+
+// load replaces the keys by the ones of the source, the old keys are kept when it fails. The
+// source is read without the lock and once for the concurrent loads, and the read is not
+// cancelled with the request that started it since the other requests wait for it too.
+func (s *KeySet) load(ctx context.Context) error {
+	_, err, _ := s.loads.Do("", func() (interface{}, error) {
+		data, err := s.read(context.WithoutCancel(ctx))
+		var keys map[string]publicKey
+		if err == nil {
+			keys, err = parseJWKS(data)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.loadedAt = s.now()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load JWKS from %s: %w", s.source, err)
+		}
+		s.keys = keys
+		return nil, nil
+	})
+	return err
+}
+
+func (s *KeySet) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		return os.ReadFile(s.source)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parseJWKS reads the signing keys of a JWKS by their ids. Encryption keys and keys of other
+// types are skipped.
+func parseJWKS(data []byte) (map[string]publicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	keys := map[string]publicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key publicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key.alg = "RS256"
+			key.key, err = rsaKey(k)
+		case "EC":
+			key.alg = "ES256"
+			key.key, err = ecKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", k.Kid, err)
+		}
+		if k.Alg != "" && k.Alg != key.alg {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RS256 or ES256 signing keys")
+	}
+	return keys, nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) < 256 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("RSA keys need a modulus of 2048 bits or more and a small exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func ecKey(k jwk) (*ecdsa.PublicKey, error) {
+	if k.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q, expected P-256", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("P-256 coordinates are 32 bytes")
+	}
+	// parsing the uncompressed point checks that it is on the curve
+	if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for every token that is not accepted, wrapped with the reason.
+var ErrInvalidToken = errors.New("Invalid token")
+
+// Claims are the registered claims of a token with its scopes and tenant.
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	Scopes    []string
+	Tenant    string
+}
+
+// KeySource returns the key with the id and the algorithm it verifies.
+type KeySource interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, string, error)
+}
+
+// Verifier checks RS256 and ES256 tokens against the keys of a JWKS. The issuer and the audience
+// are checked when they are set, the times are checked with the clock skew as tolerance.
+type Verifier struct {
+	Keys     KeySource
+	Issuer   string
+	Audience string
+	Skew     time.Duration
+	Now      func() time.Time
+}
+
+// LooksLikeJWT tells tokens apart from API keys, which have no dots.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, fmt.Sprintf(format, args...))
+}
+
+// Verify checks the signature and the claims of the compact serialized token.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	var claims Claims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, invalid("expected three parts")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodePart(parts[0], &header); err != nil {
+		return claims, invalid("header: %v", err)
+	}
+	// the algorithm is taken from the key, the header can only agree with it
+	if header.Alg != "RS256" && header.Alg != "ES256" {
+		return claims, invalid("unsupported algorithm %q, expected RS256 or ES256", header.Alg)
+	}
+	key, alg, err := v.Keys.Key(ctx, header.Kid)
+	if err != nil {
+		return claims, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if alg != header.Alg {
+		return claims, invalid("the key %q verifies %s, not %s", header.Kid, alg, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, invalid("signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verifySignature(key, digest[:], signature) {
+		return claims, invalid("bad signature")
+	}
+	var payload struct {
+		Iss    string          `json:"iss"`
+		Sub    string          `json:"sub"`
+		Aud    json.RawMessage `json:"aud"`
+		Exp    *json.Number    `json:"exp"`
+		Nbf    *json.Number    `json:"nbf"`
+		Iat    *json.Number    `json:"iat"`
+		Scope  string          `json:"scope"`
+		Scp    []string        `json:"scp"`
+		Tenant string          `json:"tenant"`
+	}
+	if err := decodePart(parts[1], &payload); err != nil {
+		return claims, invalid("claims: %v", err)
+	}
+	claims = Claims{Issuer: payload.Iss, Subject: payload.Sub, Scopes: append(strings.Fields(payload.Scope), payload.Scp...), Tenant: payload.Tenant}
+	if claims.Audience, err = audience(payload.Aud); err != nil {
+		return claims, invalid("aud: %v", err)
+	}
+	for _, field := range []struct {
+		name  string
+		value *json.Number
+		time  *time.Time
+	}{{"exp", payload.Exp, &claims.ExpiresAt}, {"nbf", payload.Nbf, &claims.NotBefore}, {"iat", payload.Iat, &claims.IssuedAt}} {
+		if field.value == nil {
+			continue
+		}
+		seconds, err := field.value.Float64()
+		if err != nil {
+			return claims, invalid("%s: %v", field.name, err)
+		}
+		*field.time = time.Unix(0, 0).Add(time.Duration(seconds * float64(time.Second)))
+	}
+	return claims, v.check(claims)
+}
+
+func (v *Verifier) check(claims Claims) error {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if claims.ExpiresAt.IsZero() {
+		return invalid("exp is required")
+	}
+	if !now.Before(claims.ExpiresAt.Add(v.Skew)) {
+		return invalid("expired at %s", claims.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	if !claims.NotBefore.IsZero() && now.Add(v.Skew).Before(claims.NotBefore) {
+		return invalid("not valid before %s", claims.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if !claims.IssuedAt.IsZero() && now.Add(v.Skew).Before(claims.IssuedAt) {
+		return invalid("issued in the future at %s", claims.IssuedAt.UTC().Format(time.RFC3339))
+	}
+	if v.Issuer != "" && claims.Issuer != v.Issuer {
+		return invalid("unexpected issuer %q", claims.Issuer)
+	}
+	if v.Audience != "" && !slices.Contains(claims.Audience, v.Audience) {
+		return invalid("the token is not meant for %q", v.Audience)
+	}
+	return nil
+}
+
+func decodePart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// audience reads the aud claim, a string or an array of strings.
+func audience(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		return []string{one}, nil
+	}
+	var many []string
+	err := json.Unmarshal(raw, &many)
+	return many, err
+}
+
+func verifySignature(key crypto.PublicKey, digest []byte, signature []byte) bool {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		// JWS signatures are r and s of 32 bytes each, not ASN.1
+		if len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
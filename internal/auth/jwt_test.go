@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	rsaPrivate, _ = rsa.GenerateKey(rand.Reader, 2048)
+	ecPrivate, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	now           = time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func jwksOf(t *testing.T, keys map[string]crypto.PrivateKey) []byte {
+	var set []map[string]string
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			set = append(set, map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())})
+		case *ecdsa.PrivateKey:
+			set = append(set, map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32)))})
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{"keys": set})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func sign(t *testing.T, alg string, kid string, key crypto.PrivateKey, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+	var signature []byte
+	var err error
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + b64(signature)
+}
+
+func claimsAt(offset time.Duration) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":    "https://auth.internal",
+		"sub":    "billing",
+		"aud":    []string{"song-library", "other"},
+		"iat":    now.Add(offset).Unix(),
+		"exp":    now.Add(offset + 5*time.Minute).Unix(),
+		"scope":  "songs:read playlists:read",
+		"tenant": "acme",
+	}
+}
+
+func writeJWKS(t *testing.T, path string, keys map[string]crypto.PrivateKey) {
+	if err := os.WriteFile(path, jwksOf(t, keys), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]crypto.PrivateKey{"rsa-1": rsaPrivate, "ec-1": ecPrivate})
+	verifier := &Verifier{
+		Keys:     NewKeySet(path, &http.Client{}, time.Hour),
+		Issuer:   "https://auth.internal",
+		Audience: "song-library",
+		Skew:     time.Minute,
+		Now:      func() time.Time { return now },
+	}
+	claims, err := verifier.Verify(context.Background(), sign(t, "RS256", "rsa-1", rsaPrivate, claimsAt(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "billing", claims.Subject)
+	assert.Equal(t, []string{"songs:read", "playlists:read"}, claims.Scopes)
+	assert.Equal(t, "acme", claims.Tenant)
+	_, err = verifier.Verify(context.Background(), sign(t, "ES256", "ec-1", ecPrivate, claimsAt(0)))
+	assert.NoError(t, err)
+	// within the clock skew
+	_, err = verifier.Verify(context.Background(), sign(t, "ES256", "ec-1", ecPrivate, claimsAt(-5*time.Minute-30*time.Second)))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(context.Background(), sign(t, "ES256", "ec-1", ecPrivate, claimsAt(30*time.Second)))
+	assert.NoError(t, err)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	wrongIssuer := claimsAt(0)
+	wrongIssuer["iss"] = "https://evil.example"
+	wrongAudience := claimsAt(0)
+	wrongAudience["aud"] = "other"
+	noExpiry := claimsAt(0)
+	delete(noExpiry, "exp")
+	rsaToken := sign(t, "RS256", "rsa-1", rsaPrivate, claimsAt(0))
+	cases := map[string]string{
+		sign(t, "ES256", "ec-1", ecPrivate, claimsAt(-7*time.Minute)): "expired at",
+		sign(t, "ES256", "ec-1", ecPrivate, claimsAt(2*time.Minute)):  "issued in the future",
+		sign(t, "ES256", "ec-1", other, claimsAt(0)):                  "bad signature",
+		sign(t, "ES256", "rsa-1", ecPrivate, claimsAt(0)):             "the key \"rsa-1\" verifies RS256, not ES256",
+		sign(t, "ES256", "ec-2", ecPrivate, claimsAt(0)):              "Unknown signing key \"ec-2\"",
+		sign(t, "ES256", "ec-1", ecPrivate, wrongIssuer):              "unexpected issuer",
+		sign(t, "ES256", "ec-1", ecPrivate, wrongAudience):            "not meant for \"song-library\"",
+		sign(t, "ES256", "ec-1", ecPrivate, noExpiry):                 "exp is required",
+		rsaToken + ".e30": "expected three parts",
+		b64([]byte(`{"alg":"none"}`)) + "." + b64([]byte(`{}`)) + ".":  "unsupported algorithm \"none\"",
+		b64([]byte(`{"alg":"HS256"}`)) + "." + b64([]byte(`{}`)) + ".": "unsupported algorithm \"HS256\"",
+	}
+	for token, message := range cases {
+		_, err = verifier.Verify(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken, message)
+		assert.ErrorContains(t, err, message)
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]crypto.PrivateKey{"ec-1": ecPrivate})
+	clock := now
+	keys := NewKeySet(path, &http.Client{}, time.Hour)
+	keys.now = func() time.Time { return clock }
+	key, alg, err := keys.Key(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "ES256", alg)
+	assert.Equal(t, &ecPrivate.PublicKey, key)
+	rotated, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeJWKS(t, path, map[string]crypto.PrivateKey{"ec-1": ecPrivate, "ec-2": rotated})
+	// unknown keys are looked up again once the retry interval passed
+	_, _, err = keys.Key(context.Background(), "ec-2")
+	assert.EqualError(t, err, "Unknown signing key \"ec-2\"")
+	clock = clock.Add(time.Minute)
+	key, _, err = keys.Key(context.Background(), "ec-2")
+	assert.NoError(t, err)
+	assert.Equal(t, &rotated.PublicKey, key)
+	// the old keys are kept while the source is broken
+	os.WriteFile(path, []byte("{"), 0o600)
+	clock = clock.Add(2 * time.Hour)
+	_, _, err = keys.Key(context.Background(), "ec-1")
+	assert.NoError(t, err)
+	// without a key id the set has to have a single key
+	_, _, err = keys.Key(context.Background(), "")
+	assert.Error(t, err)
+}
+
+func TestKeySet_SharedLoad(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Write(jwksOf(t, map[string]crypto.PrivateKey{"ec-1": ecPrivate}))
+	}))
+	defer server.Close()
+	keys := NewKeySet(server.URL, server.Client(), time.Hour)
+	// the fetch outlives the request that started it
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		ctx := context.Background()
+		if i == 0 {
+			ctx = cancelled
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := keys.Key(ctx, "ec-1")
+			errs <- err
+		}()
+	}
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestParseJWKS(t *testing.T) {
+	keys, err := parseJWKS([]byte(`{"keys": [{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}, {"kty": "EC", "kid": "enc", "use": "enc", "crv": "P-256"}]}`))
+	assert.EqualError(t, err, "no RS256 or ES256 signing keys")
+	assert.Nil(t, keys)
+	_, err = parseJWKS([]byte(`{"keys": [{"kty": "EC", "kid": "bad", "crv": "P-256", "x": "` + b64(make([]byte, 32)) + `", "y": "` + b64(make([]byte, 32)) + `"}]}`))
+	assert.ErrorContains(t, err, "invalid key \"bad\"")
+	_, err = parseJWKS([]byte(`{"keys": [{"kty": "RSA", "kid": "short", "n": "` + b64([]byte{1, 2, 3}) + `", "e": "AQAB"}]}`))
+	assert.ErrorContains(t, err, "2048 bits")
+}
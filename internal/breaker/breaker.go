@@ -0,0 +1,103 @@
+package breaker
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The states of the circuit.
+const (
+	Closed   = "closed"
+	Open     = "open"
+	HalfOpen = "half-open"
+)
+
+// ErrOpen is returned instead of calling the provider while the circuit is open.
+var ErrOpen = errors.New("The metadata provider is unavailable, the circuit is open")
+
+// Breaker opens the circuit after a number of consecutive failures and fails the calls without
+// making them until the cooldown is over. A single trial call is let through then, the circuit
+// closes when it succeeds and opens again when it fails.
+type Breaker struct {
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	failed    int
+	openUntil time.Time
+	trial     bool
+}
+
+// New returns a closed breaker opening after failures consecutive failures for the cooldown,
+// now is time.Now when nil.
+func New(failures int, cooldown time.Duration, now func() time.Time) *Breaker {
+	if now == nil {
+		now = time.Now
+	}
+	return &Breaker{failures: failures, cooldown: cooldown, now: now}
+}
+
+// State returns the state of the circuit and, when it is open, the time it stays open until.
+func (b *Breaker) State() (string, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.openUntil.IsZero():
+		return Closed, time.Time{}
+	case b.now().Before(b.openUntil):
+		return Open, b.openUntil
+	}
+	return HalfOpen, time.Time{}
+}
+
+// Allow tells whether a call can be made, every allowed call has to be followed by Done.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if b.now().Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// Done records the outcome of an allowed call.
+func (b *Breaker) Done(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if success {
+		b.failed = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failed++
+	if !b.openUntil.IsZero() || b.failed >= b.failures {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// Transport returns the transport of the provider client guarded by the breaker, the transport
+// errors and the 5xx responses are failures.
+func (b *Breaker) Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		if !b.Allow() {
+			return nil, ErrOpen
+		}
+		resp, err := next.RoundTrip(req)
+		b.Done(err == nil && resp.StatusCode < http.StatusInternalServerError)
+		return resp, err
+	})
+}
+
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
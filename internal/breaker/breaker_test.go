@@ -0,0 +1,74 @@
+package breaker
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b := New(2, 30*time.Second, func() time.Time { return now })
+	state, _ := b.State()
+	assert.Equal(t, Closed, state)
+	assert.True(t, b.Allow())
+	b.Done(false)
+	assert.True(t, b.Allow())
+	b.Done(true)
+	// the failures have to be consecutive
+	assert.True(t, b.Allow())
+	b.Done(false)
+	assert.True(t, b.Allow())
+	b.Done(false)
+	state, until := b.State()
+	assert.Equal(t, Open, state)
+	assert.Equal(t, now.Add(30*time.Second), until)
+	assert.False(t, b.Allow())
+	now = now.Add(30 * time.Second)
+	state, _ = b.State()
+	assert.Equal(t, HalfOpen, state)
+	// a single trial is let through
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+	b.Done(false)
+	state, until = b.State()
+	assert.Equal(t, Open, state)
+	assert.Equal(t, now.Add(30*time.Second), until)
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	b.Done(true)
+	state, _ = b.State()
+	assert.Equal(t, Closed, state)
+}
+
+func TestTransport(t *testing.T) {
+	b := New(2, time.Minute, nil)
+	statuses := []int{http.StatusBadGateway, http.StatusNotFound, http.StatusServiceUnavailable}
+	calls := 0
+	client := &http.Client{Transport: b.Transport(roundTripper(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if len(statuses) == 0 {
+			return nil, errors.New("connection refused")
+		}
+		status := statuses[0]
+		statuses = statuses[1:]
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}))}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("http://api.test/info")
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	// 502 and 503 are failures, 404 is not, so the circuit is still closed
+	state, _ := b.State()
+	assert.Equal(t, Closed, state)
+	_, err := client.Get("http://api.test/info")
+	assert.Error(t, err)
+	_, err = client.Get("http://api.test/info")
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, 4, calls)
+}
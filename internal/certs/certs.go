@@ -0,0 +1,98 @@
+package certs
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// CheckInterval is how often the files are checked for a new certificate.
+const CheckInterval = 10 * time.Second
+
+// Reloader serves the certificate of the files to the TLS handshakes, it is loaded again when
+// the files change, e.g. when the certificate is renewed. The certificate loaded before is kept
+// while the new files are incomplete or invalid.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	now      func() time.Time
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// NewReloader loads the certificate and its key from the PEM files, now is time.Now when nil.
+func NewReloader(certFile string, keyFile string, now func() time.Time) (*Reloader, error) {
+	if now == nil {
+		now = time.Now
+	}
+	r := &Reloader{certFile: certFile, keyFile: keyFile, now: now}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the certificate from the files.
+func (r *Reloader) Reload() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert, r.modTime, r.checked = &cert, modTime, r.now()
+	return nil
+}
+
+// GetCertificate returns the certificate, it is the GetCertificate of the tls.Config. The files
+// are checked at most once every CheckInterval.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	due := r.now().Sub(r.checked) >= CheckInterval
+	if due {
+		r.checked = r.now()
+	}
+	r.mu.Unlock()
+	if due {
+		if modTime, err := r.lastModified(); err == nil && !modTime.Equal(r.loadedAt()) {
+			if err := r.Reload(); err != nil {
+				slog.Error("Failed to reload the TLS certificate, the previous one is kept", "error", err)
+			} else {
+				slog.Info("Reloaded the TLS certificate", "file", r.certFile)
+			}
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cert, nil
+}
+
+func (r *Reloader) loadedAt() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.modTime
+}
+
+// lastModified returns the latest modification time of the files.
+func (r *Reloader) lastModified() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
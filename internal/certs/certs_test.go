@@ -0,0 +1,85 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate of the name and its key, modified at the time.
+func writeCertificate(t *testing.T, certFile string, keyFile string, name string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	assert.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	assert.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+// commonName returns the name of the certificate served by the reloader.
+func commonName(t *testing.T, reloader *Reloader) string {
+	cert, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	writeCertificate(t, certFile, keyFile, "old.example.com", modTime)
+	now := modTime
+	reloader, err := NewReloader(certFile, keyFile, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "old.example.com", commonName(t, reloader))
+
+	// the files are only checked once in a while
+	writeCertificate(t, certFile, keyFile, "new.example.com", modTime.Add(time.Minute))
+	now = now.Add(time.Second)
+	assert.Equal(t, "old.example.com", commonName(t, reloader))
+	now = now.Add(CheckInterval)
+	assert.Equal(t, "new.example.com", commonName(t, reloader))
+
+	// a broken renewal keeps the certificate
+	assert.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+	assert.NoError(t, os.Chtimes(certFile, modTime.Add(2*time.Minute), modTime.Add(2*time.Minute)))
+	now = now.Add(CheckInterval)
+	assert.Equal(t, "new.example.com", commonName(t, reloader))
+
+	_, err = NewReloader(filepath.Join(dir, "missing.crt"), keyFile, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package chordpro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Chord is a chord placed above the lyric line, Position is the index of the rune it is
+// sung on.
+type Chord struct {
+	Position int
+	Name     string
+}
+
+type Line struct {
+	Text   string
+	Chords []Chord
+}
+
+var sharps = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+var flats = []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+// Parse reads a song in the ChordPro format into lyric lines with the chords written inline
+// in square brackets. Directives in curly braces and # comments are skipped, so are the lines
+// with chords but no lyrics as the chords are kept with the lyric lines. Empty lines separate
+// verses, runs of them are collapsed into one.
+func Parse(text string) ([]Line, error) {
+	var lines []Line
+	for number, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		raw = strings.TrimRight(raw, " \t")
+		trimmed := strings.TrimSpace(raw)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "{") {
+			if !strings.HasSuffix(trimmed, "}") {
+				return nil, fmt.Errorf("line %d: unclosed directive", number+1)
+			}
+			continue
+		}
+		if trimmed == "" {
+			if len(lines) > 0 && lines[len(lines)-1].Text != "" {
+				lines = append(lines, Line{})
+			}
+			continue
+		}
+		line, err := parseLine(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number+1, err)
+		}
+		if strings.TrimSpace(line.Text) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 && lines[len(lines)-1].Text == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("There are no lyric lines")
+	}
+	return lines, nil
+}
+
+func parseLine(raw string) (Line, error) {
+	var line Line
+	var text []rune
+	for raw != "" {
+		start := strings.Index(raw, "[")
+		if start < 0 {
+			text = append(text, []rune(raw)...)
+			break
+		}
+		text = append(text, []rune(raw[:start])...)
+		end := strings.Index(raw[start:], "]")
+		if end < 0 {
+			return line, fmt.Errorf("unclosed chord")
+		}
+		name := strings.TrimSpace(raw[start+1 : start+end])
+		if name == "" {
+			return line, fmt.Errorf("empty chord")
+		}
+		line.Chords = append(line.Chords, Chord{Position: len(text), Name: name})
+		raw = raw[start+end+1:]
+	}
+	line.Text = strings.TrimRight(string(text), " \t")
+	return line, nil
+}
+
+// Lyrics returns the plain text of the lines without chords.
+func Lyrics(lines []Line) string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Render writes every line with chords under the line of its chords aligned to the syllables
+// they are played on. A chord that would run into the previous one is moved to the right.
+func Render(lines []Line) string {
+	var builder strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		if len(line.Chords) > 0 {
+			var chords []rune
+			for _, chord := range line.Chords {
+				position := chord.Position
+				if len(chords) > 0 && position <= len(chords) {
+					position = len(chords) + 1
+				}
+				for len(chords) < position {
+					chords = append(chords, ' ')
+				}
+				chords = append(chords, []rune(chord.Name)...)
+			}
+			builder.WriteString(string(chords))
+			builder.WriteString("\n")
+		}
+		builder.WriteString(line.Text)
+	}
+	return builder.String()
+}
+
+// Format writes the lines back in the ChordPro format with the chords inline.
+func Format(lines []Line) string {
+	var builder strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		text := []rune(line.Text)
+		written := 0
+		for _, chord := range line.Chords {
+			position := min(chord.Position, len(text))
+			builder.WriteString(string(text[written:position]))
+			fmt.Fprintf(&builder, "[%s]", chord.Name)
+			written = position
+		}
+		builder.WriteString(string(text[written:]))
+	}
+	return builder.String()
+}
+
+// Transpose shifts all the chords of the lines by the number of semitones.
+func Transpose(lines []Line, semitones int) []Line {
+	result := make([]Line, len(lines))
+	for i, line := range lines {
+		result[i] = Line{Text: line.Text}
+		for _, chord := range line.Chords {
+			result[i].Chords = append(result[i].Chords, Chord{Position: chord.Position, Name: TransposeChord(chord.Name, semitones)})
+		}
+	}
+	return result
+}
+
+// TransposeChord shifts the root and the bass note of a slash chord by the number of
+// semitones keeping the quality of the chord. Flats stay flats and sharps stay sharps, the
+// names that are not chords, like N.C., are returned unchanged.
+func TransposeChord(name string, semitones int) string {
+	root, rest, ok := transposeNote(name, semitones)
+	if !ok {
+		return name
+	}
+	if quality, bass, found := strings.Cut(rest, "/"); found {
+		if transposed, tail, ok := transposeNote(bass, semitones); ok && tail == "" {
+			return root + quality + "/" + transposed
+		}
+	}
+	return root + rest
+}
+
+// transposeNote transposes the note at the start of the name and returns it with the rest
+// of the name.
+func transposeNote(name string, semitones int) (string, string, bool) {
+	if name == "" || name[0] < 'A' || name[0] > 'G' {
+		return "", name, false
+	}
+	names := sharps
+	note := indexOf(sharps, name[:1])
+	rest := name[1:]
+	if strings.HasPrefix(rest, "#") {
+		note++
+		rest = rest[1:]
+	} else if strings.HasPrefix(rest, "b") {
+		names = flats
+		note--
+		rest = rest[1:]
+	}
+	note = ((note+semitones)%12 + 12) % 12
+	return names[note], rest, true
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
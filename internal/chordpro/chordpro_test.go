@@ -0,0 +1,87 @@
+package chordpro
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const song = `{title: Supermassive Black Hole}
+{key: Em}
+# riff
+[Em] [G] [Em]
+[Em]Ooh baby, don't you [G]know I suffer?
+Ooh [Em]baby, can you hear me [A7]moan?
+
+
+{start_of_chorus}
+[C]Ooh
+You set my [Bb/D]soul alight
+{end_of_chorus}
+`
+
+func TestParse(t *testing.T) {
+	lines, err := Parse(song)
+	assert.NoError(t, err)
+	assert.Equal(t, []Line{
+		{Text: "Ooh baby, don't you know I suffer?", Chords: []Chord{{0, "Em"}, {20, "G"}}},
+		{Text: "Ooh baby, can you hear me moan?", Chords: []Chord{{4, "Em"}, {26, "A7"}}},
+		{},
+		{Text: "Ooh", Chords: []Chord{{0, "C"}}},
+		{Text: "You set my soul alight", Chords: []Chord{{11, "Bb/D"}}},
+	}, lines)
+	assert.Equal(t, "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\n\nOoh\nYou set my soul alight", Lyrics(lines))
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse("Ooh [Em baby")
+	assert.EqualError(t, err, "line 1: unclosed chord")
+	_, err = Parse("Ooh\n[]baby")
+	assert.EqualError(t, err, "line 2: empty chord")
+	_, err = Parse("{title: Supermassive")
+	assert.EqualError(t, err, "line 1: unclosed directive")
+	_, err = Parse("{title: Supermassive Black Hole}\n[Em]")
+	assert.EqualError(t, err, "There are no lyric lines")
+}
+
+func TestRender(t *testing.T) {
+	lines := []Line{
+		{Text: "Ooh baby, can you hear me moan?", Chords: []Chord{{4, "Em"}, {26, "A7"}}},
+		{},
+		{Text: "Ooh", Chords: []Chord{{0, "Cmaj7"}, {1, "G"}}},
+	}
+	assert.Equal(t, "    Em                    A7\nOoh baby, can you hear me moan?\n\nCmaj7 G\nOoh", Render(lines))
+}
+
+func TestFormat(t *testing.T) {
+	lines, err := Parse(song)
+	assert.NoError(t, err)
+	assert.Equal(t, "[Em]Ooh baby, don't you [G]know I suffer?\nOoh [Em]baby, can you hear me [A7]moan?\n\n[C]Ooh\nYou set my [Bb/D]soul alight", Format(lines))
+}
+
+func TestTransposeChord(t *testing.T) {
+	cases := []struct {
+		name      string
+		semitones int
+		expected  string
+	}{
+		{"Em", 2, "F#m"},
+		{"A7", 2, "B7"},
+		{"Bb/D", 2, "C/E"},
+		{"Bb", 1, "B"},
+		{"Eb", -3, "C"},
+		{"B", 1, "C"},
+		{"C#m7", -2, "Bm7"},
+		{"G/B", 12, "G/B"},
+		{"N.C.", 3, "N.C."},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, TransposeChord(c.name, c.semitones), c.name)
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	lines := []Line{{Text: "Ooh", Chords: []Chord{{0, "C"}}}}
+	transposed := Transpose(lines, -1)
+	assert.Equal(t, []Line{{Text: "Ooh", Chords: []Chord{{0, "B"}}}}, transposed)
+	assert.Equal(t, "C", lines[0].Chords[0].Name)
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"test/internal/logging"
+	"test/internal/ratelimit"
+	"test/internal/tracing"
+	"time"
+)
+
+// Config is the configuration of the server. Every setting has a default, can be set in the YAML
+// file, by the environment variable of its env tag and by the flag named after it, e.g.
+// -database-url for DATABASE_URL, in that order of precedence.
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Database Database `yaml:"database"`
+	API      API      `yaml:"api"`
+	Auth     Auth     `yaml:"auth"`
+	Limits   Limits   `yaml:"limits"`
+	Log      Log      `yaml:"log"`
+	Tracing  Tracing  `yaml:"tracing"`
+}
+
+// Server configures the HTTP server, the zero timeouts are unlimited.
+type Server struct {
+	IP   string `yaml:"ip" env:"SERVER_IP" usage:"address to listen on, every address when empty"`
+	Port string `yaml:"port" env:"PORT" usage:"port to listen on"`
+	// GRPCPort serves the gRPC API on its own port, it is not served when empty.
+	GRPCPort string `yaml:"grpc_port" env:"GRPC_PORT" usage:"port to serve the gRPC API on, none when empty"`
+	// PublicRoutes are served without an API key, they have to be read-only routes.
+	PublicRoutes      []string      `yaml:"public_routes" env:"PUBLIC_ROUTES" usage:"comma-separated read-only routes served without an API key"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" usage:"time to read a request with its body"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" usage:"time to read the headers of a request"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" usage:"time to write a response"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" usage:"time an idle keep-alive connection is kept"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env:"HTTP_MAX_HEADER_BYTES" usage:"largest size of the request headers"`
+	// ShutdownTimeout bounds the draining of the requests in flight on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"time the requests in flight are given on shutdown"`
+	ReadyTimeout    time.Duration `yaml:"ready_timeout" env:"READY_TIMEOUT" usage:"time every readiness check may take"`
+	// TLSCertFile and TLSKeyFile serve HTTPS when both are set.
+	TLSCertFile string `yaml:"tls_cert_file" env:"TLS_CERT_FILE" usage:"PEM certificate to serve HTTPS with"`
+	TLSKeyFile  string `yaml:"tls_key_file" env:"TLS_KEY_FILE" usage:"PEM key of the certificate"`
+}
+
+// Database configures the pool of the PostgreSQL connections.
+type Database struct {
+	URL               string        `yaml:"url" env:"DATABASE_URL" secret:"true" usage:"PostgreSQL URL"`
+	TenantRLS         bool          `yaml:"tenant_rls" env:"TENANT_RLS" usage:"enforce the tenants with row level security"`
+	MaxConns          int           `yaml:"max_conns" env:"DATABASE_MAX_CONNS" usage:"largest number of connections"`
+	MinConns          int           `yaml:"min_conns" env:"DATABASE_MIN_CONNS" usage:"number of connections kept open"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" env:"DATABASE_MAX_CONN_LIFETIME" usage:"time after which a connection is closed"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" env:"DATABASE_MAX_CONN_IDLE_TIME" usage:"time after which an idle connection is closed"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" env:"DATABASE_HEALTH_CHECK_PERIOD" usage:"interval of the checks of the idle connections"`
+	ConnectTimeout    time.Duration `yaml:"connect_timeout" env:"DATABASE_CONNECT_TIMEOUT" usage:"time to open a connection"`
+}
+
+// API configures the client of the metadata provider.
+type API struct {
+	URL             string        `yaml:"url" env:"API_URL" secret:"true" usage:"URL of the metadata provider serving /info"`
+	BreakerFailures int           `yaml:"breaker_failures" env:"INFO_BREAKER_FAILURES" usage:"consecutive failures of /info that open the circuit"`
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown" env:"INFO_BREAKER_COOLDOWN" usage:"time the circuit stays open"`
+}
+
+// Auth configures the JWTs, they are rejected without a JWKS.
+type Auth struct {
+	JWKS      string        `yaml:"jwks" env:"JWKS" usage:"file path or URL of the JSON Web Key Set of the JWTs"`
+	Issuer    string        `yaml:"issuer" env:"JWT_ISSUER" usage:"required iss claim of the JWTs"`
+	Audience  string        `yaml:"audience" env:"JWT_AUDIENCE" usage:"value the aud claim of the JWTs has to contain"`
+	ClockSkew time.Duration `yaml:"clock_skew" env:"JWT_CLOCK_SKEW" usage:"tolerance of the exp, nbf and iat checks"`
+}
+
+// Limits are the requests a minute and a day per client of the request classes, 0 for no limit.
+type Limits struct {
+	ReadPerMinute   int `yaml:"read_per_minute" env:"RATE_LIMIT_READ" usage:"reads a minute per client"`
+	WritePerMinute  int `yaml:"write_per_minute" env:"RATE_LIMIT_WRITE" usage:"writes a minute per client"`
+	EnrichPerMinute int `yaml:"enrich_per_minute" env:"RATE_LIMIT_ENRICH" usage:"/addsong requests a minute per client"`
+	ReadDaily       int `yaml:"read_daily" env:"QUOTA_READ" usage:"reads a day per client"`
+	WriteDaily      int `yaml:"write_daily" env:"QUOTA_WRITE" usage:"writes a day per client"`
+	EnrichDaily     int `yaml:"enrich_daily" env:"QUOTA_ENRICH" usage:"/addsong requests a day per client"`
+}
+
+// Log configures the logs.
+type Log struct {
+	Level string `yaml:"level" env:"LOG_LEVEL" usage:"debug, info, warn or error"`
+}
+
+// Tracing configures the export of the spans.
+type Tracing struct {
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" usage:"otlp, file or none"`
+	File     string `yaml:"file" env:"OTEL_TRACES_FILE" usage:"file of the file exporter"`
+}
+
+// Default returns the configuration used unless it is overridden.
+func Default() Config {
+	limits := ratelimit.DefaultLimits
+	return Config{
+		Server: Server{
+			Port:              "8080",
+			GRPCPort:          "9090",
+			ReadTimeout:       time.Minute,
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+			ShutdownTimeout:   30 * time.Second,
+			ReadyTimeout:      2 * time.Second,
+		},
+		Database: Database{
+			MaxConns:          4,
+			MaxConnLifetime:   time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			HealthCheckPeriod: time.Minute,
+			ConnectTimeout:    5 * time.Second,
+		},
+		API:  API{BreakerFailures: 5, BreakerCooldown: 30 * time.Second},
+		Auth: Auth{ClockSkew: time.Minute},
+		Limits: Limits{
+			ReadPerMinute:   limits[ratelimit.Read].PerMinute,
+			WritePerMinute:  limits[ratelimit.Write].PerMinute,
+			EnrichPerMinute: limits[ratelimit.Enrich].PerMinute,
+			ReadDaily:       limits[ratelimit.Read].Daily,
+			WriteDaily:      limits[ratelimit.Write].Daily,
+			EnrichDaily:     limits[ratelimit.Enrich].Daily,
+		},
+		Log:     Log{Level: "info"},
+		Tracing: Tracing{Exporter: tracing.None},
+	}
+}
+
+// Map returns the limits of the request classes.
+func (l Limits) Map() map[string]ratelimit.Limit {
+	return map[string]ratelimit.Limit{
+		ratelimit.Read:   {PerMinute: l.ReadPerMinute, Daily: l.ReadDaily},
+		ratelimit.Write:  {PerMinute: l.WritePerMinute, Daily: l.WriteDaily},
+		ratelimit.Enrich: {PerMinute: l.EnrichPerMinute, Daily: l.EnrichDaily},
+	}
+}
+
+// Validate returns every invalid setting of the configuration.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port < 65536, "Invalid port %q, expected a number from 1 to 65535", c.Server.Port)
+	if c.Server.GRPCPort != "" {
+		grpcPort, err := strconv.Atoi(c.Server.GRPCPort)
+		check(err == nil && grpcPort > 0 && grpcPort < 65536, "Invalid gRPC port %q, expected a number from 1 to 65535", c.Server.GRPCPort)
+		check(c.Server.GRPCPort != c.Server.Port, "The gRPC port has to differ from the port %s", c.Server.Port)
+	}
+	check(c.Database.URL != "", "The database URL is required")
+	check((c.Server.TLSCertFile == "") == (c.Server.TLSKeyFile == ""), "The TLS certificate and key files have to be set together")
+	check(c.Server.MaxHeaderBytes > 0, "Invalid max header bytes %d, expected a positive number", c.Server.MaxHeaderBytes)
+	check(c.Server.ReadyTimeout > 0, "Invalid ready timeout %s, expected a positive duration", c.Server.ReadyTimeout)
+	check(c.Database.MaxConns > 0, "Invalid max conns %d, expected a positive number", c.Database.MaxConns)
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns, "Invalid min conns %d, expected a number from 0 to the max conns %d", c.Database.MinConns, c.Database.MaxConns)
+	check(c.API.BreakerFailures > 0, "Invalid breaker failures %d, expected a positive number", c.API.BreakerFailures)
+	for name, value := range map[string]time.Duration{
+		"read timeout": c.Server.ReadTimeout, "read header timeout": c.Server.ReadHeaderTimeout,
+		"write timeout": c.Server.WriteTimeout, "idle timeout": c.Server.IdleTimeout,
+		"shutdown timeout": c.Server.ShutdownTimeout, "max conn lifetime": c.Database.MaxConnLifetime,
+		"max conn idle time": c.Database.MaxConnIdleTime, "health check period": c.Database.HealthCheckPeriod,
+		"connect timeout": c.Database.ConnectTimeout, "breaker cooldown": c.API.BreakerCooldown,
+		"clock skew": c.Auth.ClockSkew,
+	} {
+		check(value >= 0, "Invalid %s %s, expected a duration that is not negative", name, value)
+	}
+	for class, limit := range c.Limits.Map() {
+		check(limit.PerMinute >= 0 && limit.Daily >= 0, "Invalid %s limits, expected numbers of requests that are not negative", class)
+	}
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, err)
+	}
+	check(slices.Contains([]string{"", tracing.None, tracing.OTLP, tracing.File}, c.Tracing.Exporter), "Invalid exporter %q, expected otlp, file or none", c.Tracing.Exporter)
+	check(c.Tracing.Exporter != tracing.File || c.Tracing.File != "", "The file exporter needs a file")
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileEnv names the YAML file of the configuration unless the -config flag does.
+const FileEnv = "CONFIG_FILE"
+
+// Masked replaces the secrets when the configuration is printed, like the passwords of
+// url.URL.Redacted.
+const Masked = "xxxxx"
+
+// Load returns the default configuration overridden by the YAML file, then by the environment
+// variables read with getenv, then by the flags of args, and checks it. The file is named by the
+// -config flag or by CONFIG_FILE. The unknown keys of the file and the unknown flags are errors.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	config := Default()
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	file := flags.String("config", getenv(FileEnv), "YAML file of the configuration")
+	var set []func() error
+	each(&config, func(field reflect.StructField, value reflect.Value) {
+		env := field.Tag.Get("env")
+		name := flagName(env)
+		record := func(raw string) error {
+			set = append(set, func() error {
+				if err := parse(value, raw); err != nil {
+					return fmt.Errorf("Invalid -%s: %w", name, err)
+				}
+				return nil
+			})
+			return nil
+		}
+		usage := field.Tag.Get("usage") + " (" + env + ")"
+		if value.Kind() == reflect.Bool {
+			flags.BoolFunc(name, usage, record)
+		} else {
+			flags.Func(name, usage, record)
+		}
+	})
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			flags.SetOutput(os.Stderr)
+			flags.PrintDefaults()
+		}
+		return config, err
+	}
+	if flags.NArg() > 0 {
+		return config, fmt.Errorf("Unexpected arguments %q", flags.Args())
+	}
+	if *file != "" {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return config, err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil && err != io.EOF {
+			return config, fmt.Errorf("Invalid config file %s: %w", *file, err)
+		}
+	}
+	var err error
+	each(&config, func(field reflect.StructField, value reflect.Value) {
+		env := field.Tag.Get("env")
+		if raw := getenv(env); raw != "" && err == nil {
+			if parseErr := parse(value, raw); parseErr != nil {
+				err = fmt.Errorf("Invalid %s: %w", env, parseErr)
+			}
+		}
+	})
+	if err != nil {
+		return config, err
+	}
+	for _, apply := range set {
+		if err := apply(); err != nil {
+			return config, err
+		}
+	}
+	return config, config.Validate()
+}
+
+// Print writes the configuration as YAML with the secrets masked, the passwords of the URLs
+// are masked and the rest of the URLs is kept.
+func Print(w io.Writer, config Config) error {
+	each(&config, func(field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("secret") == "true" && value.String() != "" {
+			value.SetString(mask(value.String()))
+		}
+	})
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// mask hides the password and the keys, tokens and passwords of the query of the URL, or the
+// whole value when it is not a URL with a host.
+func mask(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return Masked
+	}
+	query := u.Query()
+	for name := range query {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "password") || strings.Contains(lower, "key") || strings.Contains(lower, "token") {
+			query.Set(name, Masked)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.Redacted()
+}
+
+// each visits the settings of the configuration, the fields with an env tag.
+func each(config *Config, visit func(field reflect.StructField, value reflect.Value)) {
+	var walk func(value reflect.Value)
+	walk = func(value reflect.Value) {
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			switch {
+			case field.Type.Kind() == reflect.Struct:
+				walk(value.Field(i))
+			case field.Tag.Get("env") != "":
+				visit(field, value.Field(i))
+			}
+		}
+	}
+	walk(reflect.ValueOf(config).Elem())
+}
+
+// flagName returns the flag of the environment variable, -database-url for DATABASE_URL.
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// parse sets the setting to the raw value of an environment variable or a flag.
+func parse(value reflect.Value, raw string) error {
+	switch {
+	case value.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+	case value.Kind() == reflect.String:
+		value.SetString(raw)
+	case value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("expected a number: %q", raw)
+		}
+		value.SetInt(int64(n))
+	case value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("expected true or false: %q", raw)
+		}
+		value.SetBool(b)
+	case value.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting of type %s", value.Type())
+	}
+	return nil
+}
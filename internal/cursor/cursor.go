@@ -0,0 +1,40 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"test/internal/models"
+)
+
+// Encode returns the cursor as an opaque token safe to put in URLs.
+func Encode(cursor models.CursorData) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func Decode(token string) (models.CursorData, error) {
+	var cursor models.CursorData
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, fmt.Errorf("Invalid cursor")
+	}
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("Invalid cursor")
+	}
+	return cursor, nil
+}
+
+// SortKey is the signature of the sort order a cursor is valid for, in the form of the sort
+// parameter.
+func SortKey(sort []models.SortData) string {
+	keys := make([]string, len(sort))
+	for i, key := range sort {
+		keys[i] = key.Field
+		if key.Desc {
+			keys[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(keys, ",")
+}
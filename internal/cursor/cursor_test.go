@@ -0,0 +1,28 @@
+package cursor
+
+import (
+	"github.com/stretchr/testify/assert"
+	"test/internal/models"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	cursor := models.CursorData{Sort: "-releaseDate,song", Values: []string{"2006-07-16 00:00:00", "Supermassive Black Hole"}, ID: 7, Backward: true}
+	token := Encode(cursor)
+	assert.NotContains(t, token, "=")
+	decoded, err := Decode(token)
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	_, err := Decode("not a cursor")
+	assert.EqualError(t, err, "Invalid cursor")
+	_, err = Decode("bm90IGpzb24")
+	assert.EqualError(t, err, "Invalid cursor")
+}
+
+func TestSortKey(t *testing.T) {
+	assert.Equal(t, "-releaseDate,song", SortKey([]models.SortData{{Field: "releaseDate", Desc: true}, {Field: "song"}}))
+	assert.Equal(t, "", SortKey(nil))
+}
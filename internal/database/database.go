@@ -2,27 +2,70 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"log"
+	"log/slog"
+	"slices"
 	"strings"
+	"test/internal/cursor"
+	"test/internal/lyrics"
 	"test/internal/models"
+	"test/internal/tenant"
+	"time"
 )
 
 type Database interface {
 	InsertQuery(ctx context.Context, group_name string, song_name string, releaseDate string, text string, link string) error
+	ImportQuery(ctx context.Context, rows []models.ImportRowData, dryRun bool) error
+	DumpQuery(ctx context.Context, dump DumpWriter) error
+	RestoreQuery(ctx context.Context, dump DumpReader, replace bool) error
+	InsertPlaylistQuery(ctx context.Context, owner string, title string, description string) (int, error)
+	UpdatePlaylistQuery(ctx context.Context, id int, title string, description string) error
+	DeletePlaylistQuery(ctx context.Context, id int) error
+	SelectPlaylistQuery(ctx context.Context, id int) (models.PlaylistData, error)
+	SelectPlaylistOwnerQuery(ctx context.Context, id int) (string, error)
+	SelectPlaylistsQuery(ctx context.Context, owner string) ([]models.PlaylistData, error)
+	InsertPlaylistSongQuery(ctx context.Context, id int, group string, song string, position int) (int, error)
+	DeletePlaylistSongQuery(ctx context.Context, id int, position int) error
+	MovePlaylistSongQuery(ctx context.Context, id int, from int, to int) error
+	ImportPlaylistQuery(ctx context.Context, playlist models.PlaylistData) (models.PlaylistData, []models.PlaylistSongData, error)
+	InsertUserQuery(ctx context.Context, name string, role string) (int, error)
+	SelectRolesQuery(ctx context.Context) ([]models.RoleData, error)
+	UpsertRoleQuery(ctx context.Context, name string, permissions []string) error
+	AssignRoleQuery(ctx context.Context, user string, role string) error
+	DeleteGroupQuery(ctx context.Context, group string) error
+	RenameGroupQuery(ctx context.Context, group string, name string) error
+	SelectGroupsQuery(ctx context.Context) ([]models.GroupData, error)
+	InsertKeyQuery(ctx context.Context, user string, prefix string, hash string) (models.APIKeyData, error)
+	RotateKeyQuery(ctx context.Context, id int, user string, prefix string, hash string) (models.APIKeyData, error)
+	RevokeKeyQuery(ctx context.Context, id int, user string) error
+	SelectKeysQuery(ctx context.Context, user string) ([]models.APIKeyData, error)
+	SelectPrincipalQuery(ctx context.Context, hash string) (models.PrincipalData, error)
 	CreateTableQuery(ctx context.Context) error
 	DeleteQuery(ctx context.Context, group_name string, song_name string) error
-	SelectDataQuery(ctx context.Context, page int64, items int64, group string, song string, releaseDate string, text string, link string) (models.AnswerData, error)
+	SelectDataQuery(ctx context.Context, songs models.SongsQuery) (models.AnswerData, error)
+	StreamDataQuery(ctx context.Context, songs models.SongsQuery, each func(models.RowDbData) error) error
 	SelectCoupletQuery(ctx context.Context, group string, song string, couplet int64) (models.AnswerCoupletData, error)
+	SelectTextQuery(ctx context.Context, group string, song string) (string, error)
 	EditQuery(ctx context.Context, group_name string, song_name string, releaseDate string, text string, link string) error
+	UpdateLanguageQuery(ctx context.Context, group_name string, song_name string, lang string, confidence float64) error
+	InsertLinesQuery(ctx context.Context, group string, song string, lines []models.TimedLineData) error
+	SelectLinesQuery(ctx context.Context, group string, song string) ([]models.TimedLineData, error)
+	InsertChordsQuery(ctx context.Context, group string, song string, text string, chords []models.ChordLineData) error
+	SelectChordsQuery(ctx context.Context, group string, song string) ([]models.ChordLineData, error)
+	UpsertLyricsQuery(ctx context.Context, group string, song string, lang string, kind string, text string) error
+	DeleteLyricsQuery(ctx context.Context, group string, song string, lang string, kind string) error
+	SelectLyricsQuery(ctx context.Context, group string, song string) ([]models.LyricsData, error)
 }
 
 type DBPool interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, arguments ...interface{}) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, arguments ...interface{}) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
 type PGXDatabase struct {
@@ -39,22 +82,242 @@ func (db *PGXDatabase) CreateTableQuery(ctx context.Context) error {
 		return err
 	}
 	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS songs (id SERIAL PRIMARY KEY, song_name TEXT, releaseDate TIMESTAMP, text TEXT, link TEXT, group_id INTEGER, FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE, CONSTRAINT unique_group_song UNIQUE(group_id, song_name));")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "ALTER TABLE songs ADD COLUMN IF NOT EXISTS lang TEXT, ADD COLUMN IF NOT EXISTS lang_confidence REAL;")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS song_lines (id SERIAL PRIMARY KEY, song_id INTEGER, position INTEGER, start_ms BIGINT, text TEXT, words JSONB, FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE, CONSTRAINT unique_song_line UNIQUE(song_id, position));")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS song_chords (id SERIAL PRIMARY KEY, song_id INTEGER, line INTEGER, chords JSONB, FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE, CONSTRAINT unique_song_chords UNIQUE(song_id, line));")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS lyrics (id SERIAL PRIMARY KEY, song_id INTEGER, lang TEXT, kind TEXT, text TEXT, FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE, CONSTRAINT unique_song_lyrics UNIQUE(song_id, lang, kind));")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS playlists (id SERIAL PRIMARY KEY, owner TEXT NOT NULL, title TEXT NOT NULL, description TEXT);")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS playlist_songs (id SERIAL PRIMARY KEY, playlist_id INTEGER, song_id INTEGER, position INTEGER, FOREIGN KEY (playlist_id) REFERENCES playlists (id) ON DELETE CASCADE, FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE);")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS playlist_songs_position ON playlist_songs (playlist_id, position);")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS users (id SERIAL PRIMARY KEY, name TEXT NOT NULL, role TEXT NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT now(), CONSTRAINT unique_user UNIQUE(name));")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS api_keys (id SERIAL PRIMARY KEY, user_id INTEGER NOT NULL, prefix TEXT NOT NULL, key_hash TEXT NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT now(), revoked_at TIMESTAMPTZ, FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE, CONSTRAINT unique_key_hash UNIQUE(key_hash));")
+	if err != nil {
+		return err
+	}
+	if err = db.migrateTenants(ctx); err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS roles (name TEXT PRIMARY KEY);")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS role_permissions (role TEXT, permission TEXT, PRIMARY KEY (role, permission), FOREIGN KEY (role) REFERENCES roles (name) ON DELETE CASCADE);")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS quotas (client TEXT, class TEXT, day DATE, used INTEGER NOT NULL, PRIMARY KEY (client, class, day));")
+	if err != nil {
+		return err
+	}
+	err = db.seedRoles(ctx)
+	if err != nil {
+		return err
+	}
+	return db.recordSchemaVersion(ctx)
+}
+
+// migrateTenants adds the tenant to the libraries, playlists and users, the rows stored before
+// belong to the default tenant. The names of the groups and songs are unique per tenant.
+func (db *PGXDatabase) migrateTenants(ctx context.Context) error {
+	for _, table := range []string{"groups", "songs", "playlists", "users"} {
+		_, err := db.pool.Exec(ctx, "ALTER TABLE "+table+" ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT '"+tenant.Default+"';")
+		if err != nil {
+			return err
+		}
+	}
+	_, err := db.pool.Exec(ctx, "ALTER TABLE groups DROP CONSTRAINT IF EXISTS unique_group;")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE UNIQUE INDEX IF NOT EXISTS unique_tenant_group ON groups (tenant, group_name);")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "ALTER TABLE songs DROP CONSTRAINT IF EXISTS unique_group_song;")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "CREATE UNIQUE INDEX IF NOT EXISTS unique_tenant_group_song ON songs (tenant, group_id, song_name);")
+	return err
+}
+
+// seedRoles adds the default roles to a database without roles, the roles changed by the admins
+// are left alone. The users of the single user role that came before the roles become editors.
+func (db *PGXDatabase) seedRoles(ctx context.Context) error {
+	var roles, permissionRoles, permissions []string
+	for role, granted := range models.DefaultRoles {
+		roles = append(roles, role)
+		for _, permission := range granted {
+			permissionRoles = append(permissionRoles, role)
+			permissions = append(permissions, permission)
+		}
+	}
+	_, err := db.pool.Exec(ctx, "WITH seeded AS (INSERT INTO roles(name) SELECT UNNEST($1::text[]) WHERE NOT EXISTS (SELECT 1 FROM roles) RETURNING name) INSERT INTO role_permissions(role, permission) SELECT d.role, d.permission FROM UNNEST($2::text[], $3::text[]) AS d(role, permission) WHERE d.role IN (SELECT name FROM seeded)", roles, permissionRoles, permissions)
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "UPDATE users SET role = $1 WHERE role = 'user'", models.RoleEditor)
 	return err
 }
 
 func (db *PGXDatabase) InsertQuery(ctx context.Context, group_name string, song_name string, releaseDate string, text string, link string) error {
-	var groupID int
-	err := db.pool.QueryRow(ctx, "SELECT id FROM groups WHERE group_name = $1", group_name).Scan(&groupID)
+	tenantName := tenant.From(ctx)
+	groupID, err := db.SelectGroupIdQuery(ctx, group_name)
 	if err != nil {
-		err = db.pool.QueryRow(ctx, "INSERT INTO groups(group_name) values($1) RETURNING id", group_name).Scan(&groupID)
+		err = db.pool.QueryRow(ctx, "INSERT INTO groups(group_name, tenant) values($1, $2) RETURNING id", group_name, tenantName).Scan(&groupID)
 		if err != nil {
 			return err
 		}
 	}
-	_, err = db.pool.Exec(ctx, "INSERT INTO songs(song_name, releaseDate, text, link, group_id) values($1, TO_TIMESTAMP($2, 'DD.MM.YYYY'), $3, $4, $5)", song_name, releaseDate, text, link, groupID)
+	_, err = db.pool.Exec(ctx, "INSERT INTO songs(song_name, releaseDate, text, link, group_id, tenant) values($1, TO_TIMESTAMP($2, 'DD.MM.YYYY'), $3, $4, $5, $6)", song_name, releaseDate, text, link, groupID, tenantName)
 	return err
 }
 
+// songKey identifies a song by its group and name.
+type songKey struct {
+	group string
+	song  string
+}
+
+// ImportQuery sets the status of the rows and copies the inserted ones into the songs in a
+// single transaction, creating their groups. A row is skipped when the song is already stored
+// or given earlier with the same data and conflicting when the data differs. The transaction is
+// rolled back on a dry run, so the rows are still checked against the constraints of the table.
+func (db *PGXDatabase) ImportQuery(ctx context.Context, rows []models.ImportRowData, dryRun bool) error {
+	tenantName := tenant.From(ctx)
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	groupNames := make([]string, len(rows))
+	songNames := make([]string, len(rows))
+	for i, row := range rows {
+		groupNames[i], songNames[i] = row.Group, row.Song
+	}
+	stored, err := tx.Query(ctx, "SELECT g.group_name, s.song_name, COALESCE(TO_CHAR(s.releaseDate, 'DD.MM.YYYY'), ''), COALESCE(s.text, ''), COALESCE(s.link, '') FROM songs s JOIN groups g ON s.group_id = g.id WHERE g.tenant = $3 AND (g.group_name, s.song_name) IN (SELECT * FROM UNNEST($1::text[], $2::text[]))", groupNames, songNames, tenantName)
+	if err != nil {
+		return err
+	}
+	existing := map[songKey]models.ImportRowData{}
+	for stored.Next() {
+		var row models.ImportRowData
+		if err := stored.Scan(&row.Group, &row.Song, &row.Date, &row.Text, &row.Link); err != nil {
+			stored.Close()
+			return err
+		}
+		existing[songKey{row.Group, row.Song}] = row
+	}
+	stored.Close()
+	if err := stored.Err(); err != nil {
+		return err
+	}
+	given := map[songKey]int{}
+	var inserted []int
+	var groups []string
+	for i := range rows {
+		row := &rows[i]
+		key := songKey{row.Group, row.Song}
+		if song, found := existing[key]; found {
+			row.Status = models.ImportSkipped
+			if song.Date != row.Date || song.Text != row.Text || song.Link != row.Link {
+				row.Status, row.Error = models.ImportConflicting, "The song is stored with other data"
+			}
+			continue
+		}
+		if earlier, found := given[key]; found {
+			song := rows[earlier]
+			row.Status, row.Error = models.ImportSkipped, fmt.Sprintf("The song is given in row %d", song.Row)
+			if song.Date != row.Date || song.Text != row.Text || song.Link != row.Link {
+				row.Status, row.Error = models.ImportConflicting, fmt.Sprintf("The song is given in row %d with other data", song.Row)
+			}
+			continue
+		}
+		row.Status = models.ImportInserted
+		given[key] = i
+		inserted = append(inserted, i)
+		if !slices.Contains(groups, row.Group) {
+			groups = append(groups, row.Group)
+		}
+	}
+	if len(inserted) == 0 {
+		return nil
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO groups(group_name, tenant) SELECT UNNEST($1::text[]), $2 ON CONFLICT DO NOTHING", groups, tenantName)
+	if err != nil {
+		return err
+	}
+	ids, err := tx.Query(ctx, "SELECT id, group_name FROM groups WHERE tenant = $2 AND group_name = ANY($1)", groups, tenantName)
+	if err != nil {
+		return err
+	}
+	groupIDs := map[string]int{}
+	for ids.Next() {
+		var id int
+		var name string
+		if err := ids.Scan(&id, &name); err != nil {
+			ids.Close()
+			return err
+		}
+		groupIDs[name] = id
+	}
+	ids.Close()
+	if err := ids.Err(); err != nil {
+		return err
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"songs"}, []string{"song_name", "releasedate", "text", "link", "group_id", "lang", "lang_confidence", "tenant"},
+		pgx.CopyFromSlice(len(inserted), func(i int) ([]interface{}, error) {
+			row := rows[inserted[i]]
+			var date interface{}
+			if row.Date != "" {
+				parsed, err := time.Parse("02.01.2006", row.Date)
+				if err != nil {
+					return nil, err
+				}
+				date = parsed
+			}
+			var lang interface{}
+			if row.Lang != "" {
+				lang = row.Lang
+			}
+			return []interface{}{row.Song, date, row.Text, row.Link, groupIDs[row.Group], lang, row.LangConfidence, tenantName}, nil
+		}))
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	return tx.Commit(ctx)
+}
+
 func (db *PGXDatabase) DeleteQuery(ctx context.Context, group_name string, song_name string) error {
 	groupID, err := db.SelectGroupIdQuery(ctx, group_name)
 	if err != nil {
@@ -66,94 +329,423 @@ func (db *PGXDatabase) DeleteQuery(ctx context.Context, group_name string, song_
 
 func (db *PGXDatabase) SelectGroupIdQuery(ctx context.Context, group_name string) (int, error) {
 	var groupID int
-	err := db.pool.QueryRow(ctx, "SELECT id FROM groups WHERE group_name = $1", group_name).Scan(&groupID)
+	err := db.pool.QueryRow(ctx, "SELECT id FROM groups WHERE group_name = $1 AND tenant = $2", group_name, tenant.From(ctx)).Scan(&groupID)
 	if err != nil {
 		return 0, err
 	}
 	return groupID, nil
 }
 
-func (db *PGXDatabase) SelectDataQuery(ctx context.Context, page int64, items int64, group string, song string, releaseDate string, text string, link string) (models.AnswerData, error) {
-	query := "SELECT g.group_name, s.song_name, TO_CHAR(s.releaseDate, 'DD.MM.YYYY'), s.text, s.link FROM songs s JOIN groups g ON s.group_id = g.id "
-	var answer models.AnswerData
-	paramindex := 1
-	setClauses := []string{}
-	params := []interface{}{}
-	if group != "" || song != "" || releaseDate != "" || text != "" || link != "" {
-		if group != "" {
-			var groupID int
-			groupID, err := db.SelectGroupIdQuery(ctx, group)
-			if err != nil {
-				return answer, err
-			}
-			setClauses = append(setClauses, fmt.Sprintf("group_id = $%d", paramindex))
-			params = append(params, groupID)
-			paramindex++
-		}
-		if song != "" {
-			setClauses = append(setClauses, fmt.Sprintf("song_name = $%d", paramindex))
-			params = append(params, song)
-			paramindex++
+// songColumns are the columns of the fields songs are filtered by.
+var songColumns = map[string]string{
+	"group":       "g.group_name",
+	"song":        "s.song_name",
+	"releaseDate": "s.releaseDate",
+	"text":        "s.text",
+	"link":        "s.link",
+	"lang":        "s.lang",
+}
+
+type fieldColumn struct {
+	expression string
+	dest       func(row *models.RowDbData) interface{}
+}
+
+// fieldColumns are the expressions the fields of the songs are selected with.
+var fieldColumns = map[string]fieldColumn{
+	"group":          {"g.group_name", func(row *models.RowDbData) interface{} { return &row.Group }},
+	"song":           {"s.song_name", func(row *models.RowDbData) interface{} { return &row.Song }},
+	"releaseDate":    {"TO_CHAR(s.releaseDate, 'DD.MM.YYYY')", func(row *models.RowDbData) interface{} { return &row.Date }},
+	"text":           {"s.text", func(row *models.RowDbData) interface{} { return &row.Text }},
+	"link":           {"s.link", func(row *models.RowDbData) interface{} { return &row.Link }},
+	"lang":           {"COALESCE(s.lang, '')", func(row *models.RowDbData) interface{} { return &row.Lang }},
+	"langConfidence": {"COALESCE(s.lang_confidence, 0)", func(row *models.RowDbData) interface{} { return &row.LangConfidence }},
+}
+
+type sortColumn struct {
+	asc  string
+	desc string
+	cast string
+}
+
+// sortColumns are the expressions of the fields songs are sorted by, songs without a value come
+// last in both directions. The cast converts the cursor values back from text.
+var sortColumns = map[string]sortColumn{
+	"group":       {"COALESCE(g.group_name, '')", "COALESCE(g.group_name, '')", ""},
+	"song":        {"COALESCE(s.song_name, '')", "COALESCE(s.song_name, '')", ""},
+	"releaseDate": {"COALESCE(s.releaseDate, 'infinity')", "COALESCE(s.releaseDate, '-infinity')", "::timestamp"},
+}
+
+var comparisons = map[string]string{
+	models.OperatorEq:  "=",
+	models.OperatorGt:  ">",
+	models.OperatorGte: ">=",
+	models.OperatorLt:  "<",
+	models.OperatorLte: "<=",
+}
+
+type sortKey struct {
+	expression string
+	cast       string
+	desc       bool
+}
+
+// songsPlan is the validated songs query: its filters with their parameters, the sort keys
+// ending with the id and the selected fields.
+type songsPlan struct {
+	songs        models.SongsQuery
+	setClauses   []string
+	params       []interface{}
+	keys         []sortKey
+	fields       []string
+	includeGroup bool
+}
+
+func newSongsPlan(songs models.SongsQuery, tenant string) (songsPlan, error) {
+	plan := songsPlan{songs: songs, fields: songs.Fields}
+	var err error
+	plan.setClauses, plan.params, err = songsFilter(songs, tenant)
+	if err != nil {
+		return plan, err
+	}
+	for _, key := range songs.Sort {
+		column, found := sortColumns[key.Field]
+		if !found {
+			return plan, fmt.Errorf("Invalid sort field %q", key.Field)
 		}
-		if releaseDate != "" {
-			setClauses = append(setClauses, fmt.Sprintf("releaseDate = $%d", paramindex))
-			params = append(params, releaseDate)
-			paramindex++
+		expression := column.asc
+		if key.Desc {
+			expression = column.desc
 		}
-		if text != "" {
-			setClauses = append(setClauses, fmt.Sprintf("text = $%d", paramindex))
-			params = append(params, text)
-			paramindex++
+		plan.keys = append(plan.keys, sortKey{expression: expression, cast: column.cast, desc: key.Desc})
+	}
+	// the id keeps the order stable when the sort keys are equal
+	plan.keys = append(plan.keys, sortKey{expression: "s.id"})
+	if songs.Cursor != nil && len(songs.Cursor.Values) != len(plan.keys)-1 {
+		return plan, fmt.Errorf("Invalid cursor")
+	}
+	if len(plan.fields) == 0 {
+		plan.fields = models.SongFields
+	}
+	for _, field := range plan.fields {
+		if _, found := fieldColumns[field]; !found {
+			return plan, fmt.Errorf("Invalid field %q", field)
 		}
-		if link != "" {
-			setClauses = append(setClauses, fmt.Sprintf("link = $%d", paramindex))
-			params = append(params, link)
-			paramindex++
+	}
+	for _, include := range songs.Include {
+		if include != "group" {
+			return plan, fmt.Errorf("Invalid include %q", include)
 		}
+		plan.includeGroup = true
 	}
+	return plan, nil
+}
+
+// query builds the select of the songs after or before the cursor, or of the page without it.
+// The limit is not applied when it is zero.
+func (plan songsPlan) query(limit int64) (string, []interface{}) {
+	setClauses := plan.setClauses
+	params := plan.params
+	if plan.songs.Cursor != nil {
+		clause, cursorParams := keysetClause(plan.keys, plan.songs.Cursor, len(params)+1)
+		setClauses = append(setClauses[:len(setClauses):len(setClauses)], clause)
+		params = append(params[:len(params):len(params)], cursorParams...)
+	}
+	selectClauses := []string{}
+	for _, field := range plan.fields {
+		selectClauses = append(selectClauses, fieldColumns[field].expression)
+	}
+	if plan.includeGroup {
+		selectClauses = append(selectClauses, "g.id, g.group_name, (SELECT COUNT(*) FROM songs gs WHERE gs.group_id = g.id)")
+	}
+	// the sort keys and the id are selected for the cursors
+	for _, key := range plan.keys[:len(plan.keys)-1] {
+		selectClauses = append(selectClauses, fmt.Sprintf("(%s)::text", key.expression))
+	}
+	selectClauses = append(selectClauses, "s.id")
+	query := "SELECT " + strings.Join(selectClauses, ", ") + " FROM songs s JOIN groups g ON s.group_id = g.id "
 	if len(setClauses) > 0 {
 		query += "WHERE " + strings.Join(setClauses, " AND ") + " "
 	}
-	query += fmt.Sprintf("LIMIT $%d OFFSET $%d", paramindex, paramindex+1)
-	params = append(params, items)
-	params = append(params, (page-1)*items)
-	log.Printf("INFO: query for the database=%s\n", query)
-	log.Printf("INFO: query params for the database=%s\n", params)
+	backward := plan.songs.Cursor != nil && plan.songs.Cursor.Backward
+	orderClauses := []string{}
+	for _, key := range plan.keys {
+		// the songs before a backward cursor are read in the reverse order
+		if key.desc != backward {
+			orderClauses = append(orderClauses, key.expression+" DESC")
+		} else {
+			orderClauses = append(orderClauses, key.expression)
+		}
+	}
+	query += "ORDER BY " + strings.Join(orderClauses, ", ")
+	if limit == 0 {
+		return query, params
+	}
+	query += fmt.Sprintf(" LIMIT $%d", len(params)+1)
+	params = append(params, limit)
+	if plan.songs.Cursor == nil {
+		query += fmt.Sprintf(" OFFSET $%d", len(params)+1)
+		params = append(params, (plan.songs.Page-1)*plan.songs.Items)
+	}
+	return query, params
+}
+
+// scan reads the song of the current row with its position for the cursors.
+func (plan songsPlan) scan(rows pgx.Rows) (models.RowDbData, models.CursorData, error) {
+	var result models.RowDbData
+	position := models.CursorData{Sort: cursor.SortKey(plan.songs.Sort), Values: make([]string, len(plan.keys)-1)}
+	if len(plan.songs.Fields) > 0 {
+		result.Fields = plan.songs.Fields
+	}
+	dest := []interface{}{}
+	for _, field := range plan.fields {
+		dest = append(dest, fieldColumns[field].dest(&result))
+	}
+	if plan.includeGroup {
+		result.GroupInfo = &models.GroupData{}
+		dest = append(dest, &result.GroupInfo.ID, &result.GroupInfo.Name, &result.GroupInfo.Songs)
+	}
+	for i := range position.Values {
+		dest = append(dest, &position.Values[i])
+	}
+	dest = append(dest, &position.ID)
+	err := rows.Scan(dest...)
+	return result, position, err
+}
+
+// SelectDataQuery selects the page of the songs query. One extra song is read to learn whether
+// there is a page after it, the cursors of the pages around it are returned in the pagination.
+func (db *PGXDatabase) SelectDataQuery(ctx context.Context, songs models.SongsQuery) (models.AnswerData, error) {
+	answer := models.AnswerData{Pagination: &models.PaginationData{}}
+	plan, err := newSongsPlan(songs, tenant.From(ctx))
+	if err != nil {
+		return answer, err
+	}
+	if songs.Total != "" {
+		total, err := db.countSongs(ctx, songs.Total, plan.setClauses, plan.params)
+		if err != nil {
+			return answer, err
+		}
+		answer.Pagination.Total = &total
+		answer.Pagination.TotalEstimated = songs.Total == models.TotalEstimate
+	}
+	query, params := plan.query(songs.Items + 1)
+	slog.DebugContext(ctx, "Query for the database", "query", query, "params", len(params))
 	rows, err := db.pool.Query(ctx, query, params...)
 	if err != nil {
 		return answer, err
 	}
 	defer rows.Close()
+	var positions []models.CursorData
 	for rows.Next() {
-		var result models.RowDbData
-		if err := rows.Scan(&result.Group, &result.Song, &result.Date, &result.Text, &result.Link); err != nil {
+		result, position, err := plan.scan(rows)
+		if err != nil {
 			return answer, err
 		}
 		answer.Items = append(answer.Items, result)
+		positions = append(positions, position)
+	}
+	if err := rows.Err(); err != nil {
+		return answer, err
+	}
+	backward := songs.Cursor != nil && songs.Cursor.Backward
+	more := int64(len(answer.Items)) > songs.Items
+	if more {
+		answer.Items = answer.Items[:songs.Items]
+		positions = positions[:songs.Items]
+	}
+	if backward {
+		slices.Reverse(answer.Items)
+		slices.Reverse(positions)
+	}
+	if len(positions) == 0 {
+		return answer, nil
+	}
+	// there are songs on the side of the cursor it was made from, and before any page but the first
+	hasNext := more || backward
+	hasPrev := (more && backward) || (songs.Cursor != nil && !backward) || (songs.Cursor == nil && songs.Page > 1)
+	if hasNext {
+		answer.Pagination.Next = cursor.Encode(positions[len(positions)-1])
+	}
+	if hasPrev {
+		first := positions[0]
+		first.Backward = true
+		answer.Pagination.Prev = cursor.Encode(first)
 	}
 	return answer, nil
 }
 
-func (db *PGXDatabase) SelectCoupletQuery(ctx context.Context, group string, song string, couplet int64) (models.AnswerCoupletData, error) {
-	var text string
-	var answer models.AnswerCoupletData
-	var groupID int
-	groupID, err := db.SelectGroupIdQuery(ctx, group)
+// StreamDataQuery passes the songs of the query to each as they are read from the database,
+// all of them when Items is zero. The songs before a backward cursor would have to be buffered
+// to be passed in the sort order, so it is not supported.
+func (db *PGXDatabase) StreamDataQuery(ctx context.Context, songs models.SongsQuery, each func(models.RowDbData) error) error {
+	if songs.Cursor != nil && songs.Cursor.Backward {
+		return fmt.Errorf("Backward cursors are not supported by streaming")
+	}
+	plan, err := newSongsPlan(songs, tenant.From(ctx))
 	if err != nil {
-		return answer, err
+		return err
 	}
-	err = db.pool.QueryRow(ctx, "SELECT text FROM songs WHERE group_id = $1 AND song_name = $2", groupID, song).Scan(&text)
+	query, params := plan.query(songs.Items)
+	slog.DebugContext(ctx, "Query for the database", "query", query, "params", len(params))
+	rows, err := db.pool.Query(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		result, _, err := plan.scan(rows)
+		if err != nil {
+			return err
+		}
+		if err = each(result); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// songsFilter returns the conditions of the filters of the songs query with their parameters,
+// starting with the tenant of the songs.
+func songsFilter(songs models.SongsQuery, tenant string) ([]string, []interface{}, error) {
+	setClauses := []string{"s.tenant = $1"}
+	params := []interface{}{tenant}
+	for _, filter := range songs.Filters {
+		paramindex := len(params) + 1
+		column, found := songColumns[filter.Field]
+		if !found || len(filter.Values) == 0 {
+			return nil, nil, fmt.Errorf("Invalid filter %s[%s]", filter.Field, filter.Operator)
+		}
+		placeholder := fmt.Sprintf("$%d", paramindex)
+		if filter.Field == "releaseDate" {
+			placeholder = fmt.Sprintf("TO_TIMESTAMP($%d, 'DD.MM.YYYY')", paramindex)
+		}
+		switch filter.Operator {
+		case models.OperatorEq, models.OperatorGt, models.OperatorGte, models.OperatorLt, models.OperatorLte:
+			setClauses = append(setClauses, fmt.Sprintf("%s %s %s", column, comparisons[filter.Operator], placeholder))
+			params = append(params, filter.Values[0])
+		case models.OperatorPrefix:
+			setClauses = append(setClauses, fmt.Sprintf("%s ILIKE $%d", column, paramindex))
+			params = append(params, escapeLike(filter.Values[0])+"%")
+		case models.OperatorContains:
+			setClauses = append(setClauses, fmt.Sprintf("%s ILIKE $%d", column, paramindex))
+			params = append(params, "%"+escapeLike(filter.Values[0])+"%")
+		case models.OperatorIn:
+			if filter.Field == "releaseDate" {
+				return nil, nil, fmt.Errorf("Invalid filter %s[%s]", filter.Field, filter.Operator)
+			}
+			setClauses = append(setClauses, fmt.Sprintf("%s = ANY($%d)", column, paramindex))
+			params = append(params, filter.Values)
+		default:
+			return nil, nil, fmt.Errorf("Invalid filter %s[%s]", filter.Field, filter.Operator)
+		}
+	}
+	if songs.HasLink != nil {
+		setClauses = append(setClauses, presence("s.link", *songs.HasLink))
+	}
+	if songs.HasLyrics != nil {
+		setClauses = append(setClauses, presence("s.text", *songs.HasLyrics))
+	}
+	return setClauses, params, nil
+}
+
+// keysetClause matches the songs after the cursor in the sort order, or before it when the
+// cursor is backward: the ones with a greater first key, or an equal first key and a greater
+// second one and so on, where greater is smaller for the descending keys.
+func keysetClause(keys []sortKey, position *models.CursorData, paramindex int) (string, []interface{}) {
+	params := []interface{}{}
+	for _, value := range position.Values {
+		params = append(params, value)
+	}
+	params = append(params, position.ID)
+	orClauses := []string{}
+	for i, key := range keys {
+		andClauses := []string{}
+		for j := 0; j < i; j++ {
+			andClauses = append(andClauses, fmt.Sprintf("%s = $%d%s", keys[j].expression, paramindex+j, keys[j].cast))
+		}
+		operator := ">"
+		if key.desc != position.Backward {
+			operator = "<"
+		}
+		andClauses = append(andClauses, fmt.Sprintf("%s %s $%d%s", key.expression, operator, paramindex+i, key.cast))
+		orClauses = append(orClauses, "("+strings.Join(andClauses, " AND ")+")")
+	}
+	return "(" + strings.Join(orClauses, " OR ") + ")", params
+}
+
+// countSongs counts the songs matching the filters exactly, or estimates the number from the
+// query plan, which does not read the table.
+func (db *PGXDatabase) countSongs(ctx context.Context, mode string, setClauses []string, params []interface{}) (int64, error) {
+	from := "FROM songs s JOIN groups g ON s.group_id = g.id"
+	if len(setClauses) > 0 {
+		from += " WHERE " + strings.Join(setClauses, " AND ")
+	}
+	var total int64
+	switch mode {
+	case models.TotalExact:
+		err := db.pool.QueryRow(ctx, "SELECT COUNT(*) "+from, params...).Scan(&total)
+		return total, err
+	case models.TotalEstimate:
+		var plan string
+		err := db.pool.QueryRow(ctx, "EXPLAIN (FORMAT JSON) SELECT 1 "+from, params...).Scan(&plan)
+		if err != nil {
+			return total, err
+		}
+		var plans []struct {
+			Plan struct {
+				Rows float64 `json:"Plan Rows"`
+			}
+		}
+		if err = json.Unmarshal([]byte(plan), &plans); err != nil || len(plans) == 0 {
+			return total, fmt.Errorf("Failed to read the query plan: %v", err)
+		}
+		return int64(plans[0].Plan.Rows), nil
+	}
+	return total, fmt.Errorf("Invalid total %q", mode)
+}
+
+// escapeLike escapes the wildcards of the LIKE patterns in the value.
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+// presence matches the rows where the text column is set, or is not when present is false.
+func presence(column string, present bool) string {
+	if present {
+		return fmt.Sprintf("COALESCE(%s, '') <> ''", column)
+	}
+	return fmt.Sprintf("COALESCE(%s, '') = ''", column)
+}
+
+// ErrCoupletNotFound is returned for the couplets past the last verse of the song.
+var ErrCoupletNotFound = errors.New("There is no such couplet")
+
+func (db *PGXDatabase) SelectCoupletQuery(ctx context.Context, group string, song string, couplet int64) (models.AnswerCoupletData, error) {
+	var answer models.AnswerCoupletData
+	text, err := db.SelectTextQuery(ctx, group, song)
 	if err != nil {
 		return answer, err
 	}
-	result := strings.Split(text, "\n\n")
-	if couplet > int64(len(result)) {
-		return answer, fmt.Errorf("There is no such couplet")
+	result := lyrics.SplitVerses(text)
+	if couplet < 1 || couplet > int64(len(result)) {
+		return answer, ErrCoupletNotFound
 	}
 	answer.Text = result[couplet-1]
 	return answer, nil
 }
 
+func (db *PGXDatabase) SelectTextQuery(ctx context.Context, group string, song string) (string, error) {
+	var text string
+	var groupID int
+	groupID, err := db.SelectGroupIdQuery(ctx, group)
+	if err != nil {
+		return text, err
+	}
+	err = db.pool.QueryRow(ctx, "SELECT text FROM songs WHERE group_id = $1 AND song_name = $2", groupID, song).Scan(&text)
+	return text, err
+}
+
 func (db *PGXDatabase) EditQuery(ctx context.Context, group_name string, song_name string, releaseDate string, text string, link string) error {
 	query := "UPDATE songs SET "
 	paramindex := 3
@@ -184,8 +776,184 @@ func (db *PGXDatabase) EditQuery(ctx context.Context, group_name string, song_na
 	}
 	query += strings.Join(setClauses, ", ")
 	query += fmt.Sprintf(" WHERE group_id = $1 AND song_name = $2")
-	log.Printf("INFO: query for the database=%s\n", query)
-	log.Printf("INFO: query params for the database=%s\n", params)
-	_, err = db.pool.Exec(ctx, query, params...)
+	slog.DebugContext(ctx, "Query for the database", "query", query, "params", len(params))
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if text != "" {
+		// the chords and the LRC lines are kept by line number, they no longer match a changed text
+		for _, table := range []string{"song_chords", "song_lines"} {
+			_, err = tx.Exec(ctx, "DELETE FROM "+table+" WHERE song_id = (SELECT id FROM songs WHERE group_id = $1 AND song_name = $2 AND text IS DISTINCT FROM $3)", groupID, song_name, text)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err = tx.Exec(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (db *PGXDatabase) UpdateLanguageQuery(ctx context.Context, group_name string, song_name string, lang string, confidence float64) error {
+	groupID, err := db.SelectGroupIdQuery(ctx, group_name)
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "UPDATE songs SET lang = NULLIF($1, ''), lang_confidence = $2 WHERE group_id = $3 AND song_name = $4", lang, confidence, groupID, song_name)
+	return err
+}
+
+func (db *PGXDatabase) SelectSongIdQuery(ctx context.Context, group_name string, song_name string) (int, error) {
+	var songID int
+	groupID, err := db.SelectGroupIdQuery(ctx, group_name)
+	if err != nil {
+		return 0, err
+	}
+	err = db.pool.QueryRow(ctx, "SELECT id FROM songs WHERE group_id = $1 AND song_name = $2", groupID, song_name).Scan(&songID)
+	if err != nil {
+		return 0, err
+	}
+	return songID, nil
+}
+
+func (db *PGXDatabase) InsertLinesQuery(ctx context.Context, group string, song string, lines []models.TimedLineData) error {
+	songID, err := db.SelectSongIdQuery(ctx, group, song)
+	if err != nil {
+		return err
+	}
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx, "DELETE FROM song_lines WHERE song_id = $1", songID)
+	if err != nil {
+		return err
+	}
+	for i, line := range lines {
+		_, err = tx.Exec(ctx, "INSERT INTO song_lines(song_id, position, start_ms, text, words) values($1, $2, $3, $4, $5)", songID, i+1, line.Time, line.Text, line.Words)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (db *PGXDatabase) SelectLinesQuery(ctx context.Context, group string, song string) ([]models.TimedLineData, error) {
+	var lines []models.TimedLineData
+	songID, err := db.SelectSongIdQuery(ctx, group, song)
+	if err != nil {
+		return lines, err
+	}
+	rows, err := db.pool.Query(ctx, "SELECT start_ms, text, words FROM song_lines WHERE song_id = $1 ORDER BY position", songID)
+	if err != nil {
+		return lines, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line models.TimedLineData
+		if err := rows.Scan(&line.Time, &line.Text, &line.Words); err != nil {
+			return lines, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// InsertChordsQuery replaces the song text with the lyrics of the chord sheet and its chords,
+// which are keyed by the number of the text line they are played over.
+func (db *PGXDatabase) InsertChordsQuery(ctx context.Context, group string, song string, text string, chords []models.ChordLineData) error {
+	songID, err := db.SelectSongIdQuery(ctx, group, song)
+	if err != nil {
+		return err
+	}
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	// the LRC lines are kept by line number like the chords, they no longer match a changed text
+	_, err = tx.Exec(ctx, "DELETE FROM song_lines WHERE song_id = (SELECT id FROM songs WHERE id = $1 AND text IS DISTINCT FROM $2)", songID, text)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "UPDATE songs SET text = $1 WHERE id = $2", text, songID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "DELETE FROM song_chords WHERE song_id = $1", songID)
+	if err != nil {
+		return err
+	}
+	for _, line := range chords {
+		_, err = tx.Exec(ctx, "INSERT INTO song_chords(song_id, line, chords) values($1, $2, $3)", songID, line.Line, line.Chords)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (db *PGXDatabase) SelectChordsQuery(ctx context.Context, group string, song string) ([]models.ChordLineData, error) {
+	var chords []models.ChordLineData
+	songID, err := db.SelectSongIdQuery(ctx, group, song)
+	if err != nil {
+		return chords, err
+	}
+	rows, err := db.pool.Query(ctx, "SELECT line, chords FROM song_chords WHERE song_id = $1 ORDER BY line", songID)
+	if err != nil {
+		return chords, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line models.ChordLineData
+		if err := rows.Scan(&line.Line, &line.Chords); err != nil {
+			return chords, err
+		}
+		chords = append(chords, line)
+	}
+	return chords, rows.Err()
+}
+
+func (db *PGXDatabase) UpsertLyricsQuery(ctx context.Context, group string, song string, lang string, kind string, text string) error {
+	songID, err := db.SelectSongIdQuery(ctx, group, song)
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "INSERT INTO lyrics(song_id, lang, kind, text) values($1, $2, $3, $4) ON CONFLICT (song_id, lang, kind) DO UPDATE SET text = EXCLUDED.text", songID, lang, kind, text)
+	return err
+}
+
+func (db *PGXDatabase) DeleteLyricsQuery(ctx context.Context, group string, song string, lang string, kind string) error {
+	songID, err := db.SelectSongIdQuery(ctx, group, song)
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "DELETE FROM lyrics WHERE song_id = $1 AND lang = $2 AND kind = $3", songID, lang, kind)
 	return err
 }
+
+func (db *PGXDatabase) SelectLyricsQuery(ctx context.Context, group string, song string) ([]models.LyricsData, error) {
+	var versions []models.LyricsData
+	songID, err := db.SelectSongIdQuery(ctx, group, song)
+	if err != nil {
+		return versions, err
+	}
+	rows, err := db.pool.Query(ctx, "SELECT lang, kind, text FROM lyrics WHERE song_id = $1 ORDER BY lang, kind", songID)
+	if err != nil {
+		return versions, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version models.LyricsData
+		if err := rows.Scan(&version.Lang, &version.Kind, &version.Text); err != nil {
+			return versions, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
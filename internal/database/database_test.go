@@ -2,8 +2,12 @@ package database
 
 import (
 	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
+	"test/internal/cursor"
+	"test/internal/models"
 	"testing"
 )
 
@@ -16,6 +20,35 @@ func TestCreateTableQuery(t *testing.T) {
 	defer mockk.Close()
 	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS groups").WillReturnResult(pgxmock.NewResult("CREATE", 1))
 	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS songs").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("ALTER TABLE songs ADD COLUMN IF NOT EXISTS lang").WillReturnResult(pgxmock.NewResult("ALTER", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS song_lines").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS song_chords").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS lyrics").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS playlists").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS playlist_songs").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE INDEX IF NOT EXISTS playlist_songs_position").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS users").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS api_keys").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	for _, table := range []string{"groups", "songs", "playlists", "users"} {
+		mockk.ExpectExec("ALTER TABLE " + table + " ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default'").WillReturnResult(pgxmock.NewResult("ALTER", 1))
+	}
+	mockk.ExpectExec("ALTER TABLE groups DROP CONSTRAINT IF EXISTS unique_group;").WillReturnResult(pgxmock.NewResult("ALTER", 1))
+	mockk.ExpectExec("CREATE UNIQUE INDEX IF NOT EXISTS unique_tenant_group ON groups \\(tenant, group_name\\)").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("ALTER TABLE songs DROP CONSTRAINT IF EXISTS unique_group_song;").WillReturnResult(pgxmock.NewResult("ALTER", 1))
+	mockk.ExpectExec("CREATE UNIQUE INDEX IF NOT EXISTS unique_tenant_group_song ON songs \\(tenant, group_id, song_name\\)").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS roles").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS role_permissions").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS quotas").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("WITH seeded AS \\(INSERT INTO roles\\(name\\) .* WHERE NOT EXISTS \\(SELECT 1 FROM roles\\)").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 16))
+	mockk.ExpectExec("UPDATE users SET role = \\$1 WHERE role = 'user'").
+		WithArgs("editor").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockk.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(pgxmock.NewResult("CREATE", 1))
+	mockk.ExpectExec("INSERT INTO schema_version\\(id, version\\) values\\(true, \\$1\\) ON CONFLICT \\(id\\) DO UPDATE SET version = GREATEST\\(schema_version.version, EXCLUDED.version\\)").
+		WithArgs(SchemaVersion).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 	err = database.CreateTableQuery(context.Background())
 	assert.NoError(t, err)
 	if err := mockk.ExpectationsWereMet(); err != nil {
@@ -35,11 +68,14 @@ func TestInsertQuery(t *testing.T) {
 	date := "16.07.2006"
 	text := "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
 	link := "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+	mockk.ExpectQuery("SELECT id FROM groups WHERE group_name = \\$1 AND tenant = \\$2").
+		WithArgs(group, "default").
+		WillReturnError(pgx.ErrNoRows)
 	mockk.ExpectQuery("INSERT INTO groups").
-		WithArgs(group).
+		WithArgs(group, "default").
 		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
 	mockk.ExpectExec("INSERT INTO songs").
-		WithArgs(song, date, text, link, 1).
+		WithArgs(song, date, text, link, 1, "default").
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 	err = database.InsertQuery(context.Background(), group, song, date, text, link)
 	assert.NoError(t, err)
@@ -58,7 +94,7 @@ func TestDeleteQuery(t *testing.T) {
 	group := "Muse"
 	song := "Supermassive Black Hole"
 	mockk.ExpectQuery("SELECT id FROM groups").
-		WithArgs(group).
+		WithArgs(group, "default").
 		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
 	mockk.ExpectExec("DELETE FROM songs").
 		WithArgs(1, song).
@@ -84,14 +120,341 @@ func TestSelectDataQuery(t *testing.T) {
 	date := "16.07.2006"
 	text := "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
 	link := "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+	lang := "en"
+	mockk.ExpectQuery("SELECT g.group_name, s.song_name, TO_CHAR\\(s.releaseDate, \\'DD.MM.YYYY\\'\\), s.text, s.link, (.+) FROM songs s JOIN groups g ON s.group_id = g.id WHERE s.tenant = \\$1 AND g.group_name = \\$2 AND s.song_name = \\$3 AND s.releaseDate = TO_TIMESTAMP\\(\\$4, 'DD.MM.YYYY'\\) AND s.text = \\$5 AND s.link = \\$6 AND s.lang = \\$7 ORDER BY s.id LIMIT \\$8 OFFSET \\$9").
+		WithArgs("default", group, song, date, text, link, lang, items+1, (page-1)*items).
+		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "releaseDate", "text", "link", "lang", "lang_confidence", "id"}).
+			AddRow(group, song, date, text, link, lang, 0.98, 1))
+	answer, err := database.SelectDataQuery(context.Background(), models.SongsQuery{
+		Page:  page,
+		Items: items,
+		Filters: []models.FilterData{
+			{Field: "group", Operator: models.OperatorEq, Values: []string{group}},
+			{Field: "song", Operator: models.OperatorEq, Values: []string{song}},
+			{Field: "releaseDate", Operator: models.OperatorEq, Values: []string{date}},
+			{Field: "text", Operator: models.OperatorEq, Values: []string{text}},
+			{Field: "link", Operator: models.OperatorEq, Values: []string{link}},
+			{Field: "lang", Operator: models.OperatorEq, Values: []string{lang}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RowDbData{{Group: group, Song: song, Date: date, Text: text, Link: link, Lang: lang, LangConfidence: 0.98}}, answer.Items)
+	assert.Equal(t, &models.PaginationData{}, answer.Pagination)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectDataQuery_OperatorsAndSort(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	hasLink := true
+	hasLyrics := false
+	mockk.ExpectQuery("WHERE s.tenant = \\$1 AND g.group_name ILIKE \\$2 AND s.song_name ILIKE \\$3 AND g.group_name = ANY\\(\\$4\\) AND s.releaseDate >= TO_TIMESTAMP\\(\\$5, 'DD.MM.YYYY'\\) AND s.releaseDate < TO_TIMESTAMP\\(\\$6, 'DD.MM.YYYY'\\) AND COALESCE\\(s.link, ''\\) <> '' AND COALESCE\\(s.text, ''\\) = '' ORDER BY COALESCE\\(s.releaseDate, '-infinity'\\) DESC, COALESCE\\(g.group_name, ''\\), s.id LIMIT \\$7 OFFSET \\$8").
+		WithArgs("default", "50\\%\\_M%", "%black%", []string{"Muse", "Queen"}, "01.01.2006", "01.01.2010", int64(6), int64(10)).
+		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "releaseDate", "text", "link", "lang", "lang_confidence", "release_key", "group_key", "id"}))
+	answer, err := database.SelectDataQuery(context.Background(), models.SongsQuery{
+		Page:  3,
+		Items: 5,
+		Filters: []models.FilterData{
+			{Field: "group", Operator: models.OperatorPrefix, Values: []string{"50%_M"}},
+			{Field: "song", Operator: models.OperatorContains, Values: []string{"black"}},
+			{Field: "group", Operator: models.OperatorIn, Values: []string{"Muse", "Queen"}},
+			{Field: "releaseDate", Operator: models.OperatorGte, Values: []string{"01.01.2006"}},
+			{Field: "releaseDate", Operator: models.OperatorLt, Values: []string{"01.01.2010"}},
+		},
+		Sort:      []models.SortData{{Field: "releaseDate", Desc: true}, {Field: "group"}},
+		HasLink:   &hasLink,
+		HasLyrics: &hasLyrics,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, answer.Items)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectDataQuery_Cursor(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	columns := []string{"group_name", "song_name", "releaseDate", "text", "link", "lang", "lang_confidence", "release_key", "id"}
+	mockk.ExpectQuery("SELECT COUNT\\(\\*\\) FROM songs s JOIN groups g ON s.group_id = g.id WHERE s.tenant = \\$1 AND g.group_name = \\$2$").
+		WithArgs("default", "Muse").
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(7)))
+	mockk.ExpectQuery("WHERE s.tenant = \\$1 AND g.group_name = \\$2 AND \\(\\(COALESCE\\(s.releaseDate, '-infinity'\\) < \\$3::timestamp\\) OR \\(COALESCE\\(s.releaseDate, '-infinity'\\) = \\$3::timestamp AND s.id > \\$4\\)\\) ORDER BY COALESCE\\(s.releaseDate, '-infinity'\\) DESC, s.id LIMIT \\$5$").
+		WithArgs("default", "Muse", "2009-09-07 00:00:00", 4, int64(3)).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow("Muse", "Uprising", "07.09.2009", "", "", "", 0.0, "2009-09-07 00:00:00", 5).
+			AddRow("Muse", "Starlight", "03.09.2006", "", "", "", 0.0, "2006-09-03 00:00:00", 2).
+			AddRow("Muse", "Supermassive Black Hole", "16.07.2006", "", "", "", 0.0, "2006-07-16 00:00:00", 1))
+	sort := []models.SortData{{Field: "releaseDate", Desc: true}}
+	answer, err := database.SelectDataQuery(context.Background(), models.SongsQuery{
+		Items:   2,
+		Cursor:  &models.CursorData{Sort: "-releaseDate", Values: []string{"2009-09-07 00:00:00"}, ID: 4},
+		Filters: []models.FilterData{{Field: "group", Operator: models.OperatorEq, Values: []string{"Muse"}}},
+		Sort:    sort,
+		Total:   models.TotalExact,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, answer.Items, 2)
+	assert.Equal(t, "Uprising", answer.Items[0].Song)
+	assert.Equal(t, int64(7), *answer.Pagination.Total)
+	assert.False(t, answer.Pagination.TotalEstimated)
+	next, err := cursor.Decode(answer.Pagination.Next)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CursorData{Sort: "-releaseDate", Values: []string{"2006-09-03 00:00:00"}, ID: 2}, next)
+	prev, err := cursor.Decode(answer.Pagination.Prev)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CursorData{Sort: "-releaseDate", Values: []string{"2009-09-07 00:00:00"}, ID: 5, Backward: true}, prev)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectDataQuery_BackwardCursor(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	columns := []string{"group_name", "song_name", "releaseDate", "text", "link", "lang", "lang_confidence", "id"}
+	mockk.ExpectQuery("EXPLAIN \\(FORMAT JSON\\) SELECT 1 FROM songs s JOIN groups g ON s.group_id = g.id WHERE s.tenant = \\$1$").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"plan"}).AddRow(`[{"Plan": {"Node Type": "Hash Join", "Plan Rows": 1200}}]`))
+	mockk.ExpectQuery("WHERE s.tenant = \\$1 AND \\(\\(s.id < \\$2\\)\\) ORDER BY s.id DESC LIMIT \\$3$").
+		WithArgs("default", 3, int64(3)).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow("Muse", "Starlight", "03.09.2006", "", "", "", 0.0, 2).
+			AddRow("Muse", "Supermassive Black Hole", "16.07.2006", "", "", "", 0.0, 1))
+	answer, err := database.SelectDataQuery(context.Background(), models.SongsQuery{
+		Items:  2,
+		Cursor: &models.CursorData{Values: []string{}, ID: 3, Backward: true},
+		Total:  models.TotalEstimate,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Supermassive Black Hole", answer.Items[0].Song)
+	assert.Equal(t, "Starlight", answer.Items[1].Song)
+	assert.Equal(t, int64(1200), *answer.Pagination.Total)
+	assert.True(t, answer.Pagination.TotalEstimated)
+	assert.Empty(t, answer.Pagination.Prev)
+	next, err := cursor.Decode(answer.Pagination.Next)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CursorData{Values: []string{}, ID: 2}, next)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectDataQuery_Fields(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT s.song_name, TO_CHAR\\(s.releaseDate, 'DD.MM.YYYY'\\), g.id, g.group_name, \\(SELECT COUNT\\(\\*\\) FROM songs gs WHERE gs.group_id = g.id\\), \\(COALESCE\\(s.song_name, ''\\)\\)::text, s.id FROM songs s JOIN groups g ON s.group_id = g.id WHERE s.tenant = \\$1 ORDER BY").
+		WithArgs("default", int64(2), int64(0)).
+		WillReturnRows(pgxmock.NewRows([]string{"song_name", "releaseDate", "id", "group_name", "count", "song_key", "id"}).
+			AddRow("Uprising", "07.09.2009", 1, "Muse", int64(12), "Uprising", 5))
+	fields := []string{"song", "releaseDate"}
+	answer, err := database.SelectDataQuery(context.Background(), models.SongsQuery{
+		Page:    1,
+		Items:   1,
+		Sort:    []models.SortData{{Field: "song"}},
+		Fields:  fields,
+		Include: []string{"group"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RowDbData{{
+		Song:      "Uprising",
+		Date:      "07.09.2009",
+		GroupInfo: &models.GroupData{ID: 1, Name: "Muse", Songs: 12},
+		Fields:    fields,
+	}}, answer.Items)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	rows := []models.ImportRowData{
+		{Row: 1, Group: "Muse", Song: "Uprising", Date: "07.09.2009", Lang: "en", LangConfidence: 0.9},
+		{Row: 2, Group: "Muse", Song: "Hysteria", Date: "01.12.2003"},
+		{Row: 3, Group: "Muse", Song: "Starlight"},
+		{Row: 4, Group: "Muse", Song: "Uprising", Date: "07.09.2009", Lang: "en", LangConfidence: 0.9},
+		{Row: 5, Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"},
+		{Row: 6, Group: "Queen", Song: "Bohemian Rhapsody"},
+	}
+	mockk.ExpectBegin()
+	mockk.ExpectQuery("SELECT g.group_name, s.song_name, .* FROM songs s JOIN groups g ON s.group_id = g.id WHERE g.tenant = \\$3 AND \\(g.group_name, s.song_name\\) IN \\(SELECT \\* FROM UNNEST").
+		WithArgs([]string{"Muse", "Muse", "Muse", "Muse", "Muse", "Queen"}, []string{"Uprising", "Hysteria", "Starlight", "Uprising", "Uprising", "Bohemian Rhapsody"}, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "releaseDate", "text", "link"}).
+			AddRow("Muse", "Hysteria", "01.12.2003", "", "").
+			AddRow("Muse", "Starlight", "04.09.2006", "", ""))
+	mockk.ExpectExec("INSERT INTO groups\\(group_name, tenant\\) SELECT UNNEST").
+		WithArgs([]string{"Muse", "Queen"}, "default").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectQuery("SELECT id, group_name FROM groups WHERE tenant = \\$2 AND group_name = ANY").
+		WithArgs([]string{"Muse", "Queen"}, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "group_name"}).AddRow(1, "Muse").AddRow(2, "Queen"))
+	mockk.ExpectCopyFrom(pgx.Identifier{"songs"}, []string{"song_name", "releasedate", "text", "link", "group_id", "lang", "lang_confidence", "tenant"}).
+		WillReturnResult(2)
+	mockk.ExpectCommit()
+	err = database.ImportQuery(context.Background(), rows, false)
+	assert.NoError(t, err)
+	statuses := []string{}
+	for _, row := range rows {
+		statuses = append(statuses, row.Status)
+	}
+	assert.Equal(t, []string{models.ImportInserted, models.ImportSkipped, models.ImportConflicting, models.ImportSkipped, models.ImportConflicting, models.ImportInserted}, statuses)
+	assert.Equal(t, "The song is stored with other data", rows[2].Error)
+	assert.Equal(t, "The song is given in row 1", rows[3].Error)
+	assert.Equal(t, "The song is given in row 1 with other data", rows[4].Error)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportQuery_DryRun(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	rows := []models.ImportRowData{{Row: 1, Group: "Muse", Song: "Uprising"}}
+	mockk.ExpectBegin()
+	mockk.ExpectQuery("SELECT g.group_name, s.song_name").
+		WithArgs([]string{"Muse"}, []string{"Uprising"}, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "releaseDate", "text", "link"}))
+	mockk.ExpectExec("INSERT INTO groups").
+		WithArgs([]string{"Muse"}, "default").
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mockk.ExpectQuery("SELECT id, group_name FROM groups").
+		WithArgs([]string{"Muse"}, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "group_name"}).AddRow(1, "Muse"))
+	mockk.ExpectCopyFrom(pgx.Identifier{"songs"}, []string{"song_name", "releasedate", "text", "link", "group_id", "lang", "lang_confidence", "tenant"}).
+		WillReturnResult(1)
+	mockk.ExpectRollback()
+	err = database.ImportQuery(context.Background(), rows, true)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportInserted, rows[0].Status)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestStreamDataQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT g.group_name, s.song_name, s.id FROM songs s JOIN groups g ON s.group_id = g.id WHERE s.tenant = \\$1 AND g.group_name = \\$2 ORDER BY s.id$").
+		WithArgs("default", "Muse").
+		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "id"}).
+			AddRow("Muse", "Supermassive Black Hole", 1).
+			AddRow("Muse", "Uprising", 5))
+	fields := []string{"group", "song"}
+	var rows []models.RowDbData
+	err = database.StreamDataQuery(context.Background(), models.SongsQuery{
+		Filters: []models.FilterData{{Field: "group", Operator: models.OperatorEq, Values: []string{"Muse"}}},
+		Fields:  fields,
+	}, func(row models.RowDbData) error {
+		rows = append(rows, row)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RowDbData{
+		{Group: "Muse", Song: "Supermassive Black Hole", Fields: fields},
+		{Group: "Muse", Song: "Uprising", Fields: fields},
+	}, rows)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestStreamDataQuery_Page(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT s.song_name, s.id FROM songs s JOIN groups g ON s.group_id = g.id WHERE s.tenant = \\$1 ORDER BY s.id LIMIT \\$2 OFFSET \\$3").
+		WithArgs("default", int64(10), int64(10)).
+		WillReturnRows(pgxmock.NewRows([]string{"song_name", "id"}).
+			AddRow("Uprising", 5).
+			RowError(0, errors.New("connection reset")))
+	err = database.StreamDataQuery(context.Background(), models.SongsQuery{Page: 2, Items: 10, Fields: []string{"song"}}, func(row models.RowDbData) error {
+		return nil
+	})
+	assert.EqualError(t, err, "connection reset")
+	err = database.StreamDataQuery(context.Background(), models.SongsQuery{Items: 10, Cursor: &models.CursorData{ID: 5, Backward: true}}, func(row models.RowDbData) error {
+		return nil
+	})
+	assert.EqualError(t, err, "Backward cursors are not supported by streaming")
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectDataQuery_InvalidQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	_, err = database.SelectDataQuery(context.Background(), models.SongsQuery{Page: 1, Items: 1, Filters: []models.FilterData{{Field: "id; DROP TABLE songs", Operator: models.OperatorEq, Values: []string{"1"}}}})
+	assert.EqualError(t, err, "Invalid filter id; DROP TABLE songs[eq]")
+	_, err = database.SelectDataQuery(context.Background(), models.SongsQuery{Page: 1, Items: 1, Filters: []models.FilterData{{Field: "releaseDate", Operator: models.OperatorIn, Values: []string{"16.07.2006"}}}})
+	assert.EqualError(t, err, "Invalid filter releaseDate[in]")
+	_, err = database.SelectDataQuery(context.Background(), models.SongsQuery{Page: 1, Items: 1, Sort: []models.SortData{{Field: "text"}}})
+	assert.EqualError(t, err, "Invalid sort field \"text\"")
+	_, err = database.SelectDataQuery(context.Background(), models.SongsQuery{Items: 1, Cursor: &models.CursorData{Values: []string{"Muse"}, ID: 1}})
+	assert.EqualError(t, err, "Invalid cursor")
+	_, err = database.SelectDataQuery(context.Background(), models.SongsQuery{Page: 1, Items: 1, Fields: []string{"s.id"}})
+	assert.EqualError(t, err, "Invalid field \"s.id\"")
+	_, err = database.SelectDataQuery(context.Background(), models.SongsQuery{Page: 1, Items: 1, Include: []string{"album"}})
+	assert.EqualError(t, err, "Invalid include \"album\"")
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateLanguageQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
 	mockk.ExpectQuery("SELECT id FROM groups").
-		WithArgs(group).
+		WithArgs(group, "default").
 		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
-	mockk.ExpectQuery("SELECT g.group_name, s.song_name, TO_CHAR\\(s.releaseDate, \\'DD.MM.YYYY\\'\\), s.text, s.link FROM songs s JOIN groups g ON s.group_id = g.id").
-		WithArgs(1, song, date, text, link, items, (page-1)*items).
-		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "releaseDate", "text", "link"}).
-			AddRow(group, song, date, text, link))
-	_, err = database.SelectDataQuery(context.Background(), page, items, group, song, date, text, link)
+	mockk.ExpectExec("UPDATE songs SET lang").
+		WithArgs("en", 0.98, 1, song).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	err = database.UpdateLanguageQuery(context.Background(), group, song, "en", 0.98)
 	assert.NoError(t, err)
 	if err := mockk.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
@@ -110,7 +473,7 @@ func TestSelectCoupletQuery(t *testing.T) {
 	song := "Supermassive Black Hole"
 	text := "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
 	mockk.ExpectQuery("SELECT id FROM groups").
-		WithArgs(group).
+		WithArgs(group, "default").
 		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
 	mockk.ExpectQuery("SELECT text FROM songs").
 		WithArgs(1, song).
@@ -123,6 +486,32 @@ func TestSelectCoupletQuery(t *testing.T) {
 	}
 }
 
+func TestSelectCoupletQuery_NoSuchCouplet(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "Ooh baby, don't you know I suffer?\n\nOoh\nYou set my soul alight"
+	for _, couplet := range []int64{0, 3} {
+		mockk.ExpectQuery("SELECT id FROM groups").
+			WithArgs(group, "default").
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+		mockk.ExpectQuery("SELECT text FROM songs").
+			WithArgs(1, song).
+			WillReturnRows(pgxmock.NewRows([]string{"text"}).
+				AddRow(text))
+		_, err = database.SelectCoupletQuery(context.Background(), group, song, couplet)
+		assert.EqualError(t, err, "There is no such couplet")
+	}
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestEditQuery(t *testing.T) {
 	mockk, err := pgxmock.NewPool()
 	if err != nil {
@@ -136,13 +525,343 @@ func TestEditQuery(t *testing.T) {
 	text := "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
 	link := "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
 	mockk.ExpectQuery("SELECT id FROM groups").
-		WithArgs(group).
+		WithArgs(group, "default").
 		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DELETE FROM song_chords WHERE song_id = \\(SELECT id FROM songs WHERE group_id = \\$1 AND song_name = \\$2 AND text IS DISTINCT FROM \\$3\\)").
+		WithArgs(1, song, text).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mockk.ExpectExec("DELETE FROM song_lines").
+		WithArgs(1, song, text).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
 	mockk.ExpectExec("UPDATE songs SET").
 		WithArgs(1, song, date, text, link).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectCommit()
 	err = database.EditQuery(context.Background(), group, song, date, text, link)
 	assert.NoError(t, err)
+	// the chords and lines stay without a new text
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectBegin()
+	mockk.ExpectExec("UPDATE songs SET").
+		WithArgs(1, song, link).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectCommit()
+	err = database.EditQuery(context.Background(), group, song, "", "", link)
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertLinesQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	lines := []models.TimedLineData{
+		{Time: 12000, Text: "Ooh baby", Words: []models.TimedWordData{{Time: 12000, Text: "Ooh "}, {Time: 12500, Text: "baby"}}},
+		{Time: 16000, Text: "Ooh baby, can you hear me moan?"},
+	}
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DELETE FROM song_lines").
+		WithArgs(2).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	mockk.ExpectExec("INSERT INTO song_lines").
+		WithArgs(2, 1, lines[0].Time, lines[0].Text, lines[0].Words).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectExec("INSERT INTO song_lines").
+		WithArgs(2, 2, lines[1].Time, lines[1].Text, lines[1].Words).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectCommit()
+	err = database.InsertLinesQuery(context.Background(), group, song, lines)
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertLinesQuery_InsertError(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	lines := []models.TimedLineData{{Time: 16000, Text: "Ooh baby, can you hear me moan?"}}
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DELETE FROM song_lines").
+		WithArgs(2).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mockk.ExpectExec("INSERT INTO song_lines").
+		WithArgs(2, 1, lines[0].Time, lines[0].Text, lines[0].Words).
+		WillReturnError(errors.New("insert failed"))
+	mockk.ExpectRollback()
+	err = database.InsertLinesQuery(context.Background(), group, song, lines)
+	assert.EqualError(t, err, "insert failed")
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectLinesQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	words := []models.TimedWordData{{Time: 12000, Text: "Ooh "}, {Time: 12500, Text: "baby"}}
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectQuery("SELECT start_ms, text, words FROM song_lines").
+		WithArgs(2).
+		WillReturnRows(pgxmock.NewRows([]string{"start_ms", "text", "words"}).
+			AddRow(int64(12000), "Ooh baby", words).
+			AddRow(int64(16000), "Ooh baby, can you hear me moan?", []models.TimedWordData(nil)))
+	lines, err := database.SelectLinesQuery(context.Background(), group, song)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TimedLineData{
+		{Time: 12000, Text: "Ooh baby", Words: words},
+		{Time: 16000, Text: "Ooh baby, can you hear me moan?"},
+	}, lines)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertChordsQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "Ooh baby, don't you know I suffer?\n\nOoh"
+	chords := []models.ChordLineData{
+		{Line: 1, Chords: []models.ChordData{{Position: 0, Name: "Em"}, {Position: 20, Name: "G"}}},
+		{Line: 3, Chords: []models.ChordData{{Position: 0, Name: "C"}}},
+	}
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DELETE FROM song_lines WHERE song_id = \\(SELECT id FROM songs WHERE id = \\$1 AND text IS DISTINCT FROM \\$2\\)").
+		WithArgs(2, text).
+		WillReturnResult(pgxmock.NewResult("DELETE", 4))
+	mockk.ExpectExec("UPDATE songs SET text").
+		WithArgs(text, 2).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectExec("DELETE FROM song_chords").
+		WithArgs(2).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockk.ExpectExec("INSERT INTO song_chords").
+		WithArgs(2, 1, chords[0].Chords).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectExec("INSERT INTO song_chords").
+		WithArgs(2, 3, chords[1].Chords).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectCommit()
+	err = database.InsertChordsQuery(context.Background(), group, song, text, chords)
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertChordsQuery_UpdateError(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DELETE FROM song_lines").
+		WithArgs(2, "Ooh").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mockk.ExpectExec("UPDATE songs SET text").
+		WithArgs("Ooh", 2).
+		WillReturnError(errors.New("Error updating text"))
+	mockk.ExpectRollback()
+	err = database.InsertChordsQuery(context.Background(), group, song, "Ooh", nil)
+	assert.EqualError(t, err, "Error updating text")
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertChordsQuery_DeleteLinesError(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DELETE FROM song_lines").
+		WithArgs(2, "Ooh").
+		WillReturnError(errors.New("Error deleting lines"))
+	mockk.ExpectRollback()
+	err = database.InsertChordsQuery(context.Background(), group, song, "Ooh", nil)
+	assert.EqualError(t, err, "Error deleting lines")
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectChordsQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	chords := []models.ChordData{{Position: 0, Name: "Em"}, {Position: 20, Name: "G"}}
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectQuery("SELECT line, chords FROM song_chords").
+		WithArgs(2).
+		WillReturnRows(pgxmock.NewRows([]string{"line", "chords"}).
+			AddRow(1, chords))
+	lines, err := database.SelectChordsQuery(context.Background(), group, song)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ChordLineData{{Line: 1, Chords: chords}}, lines)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpsertLyricsQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "О, детка, разве ты не знаешь, что я страдаю?"
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectExec("INSERT INTO lyrics(.+)ON CONFLICT").
+		WithArgs(2, "ru", "translation", text).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	err = database.UpsertLyricsQuery(context.Background(), group, song, "ru", "translation", text)
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteLyricsQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectExec("DELETE FROM lyrics").
+		WithArgs(2, "ru", "translation").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	err = database.DeleteLyricsQuery(context.Background(), group, song, "ru", "translation")
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectLyricsQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	mockk.ExpectQuery("SELECT id FROM groups").
+		WithArgs(group, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT id FROM songs").
+		WithArgs(1, song).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+	mockk.ExpectQuery("SELECT lang, kind, text FROM lyrics").
+		WithArgs(2).
+		WillReturnRows(pgxmock.NewRows([]string{"lang", "kind", "text"}).
+			AddRow("en", "original", "Ooh baby").
+			AddRow("ru", "translation", "О, детка"))
+	versions, err := database.SelectLyricsQuery(context.Background(), group, song)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LyricsData{
+		{Lang: "en", Kind: "original", Text: "Ooh baby"},
+		{Lang: "ru", Kind: "translation", Text: "О, детка"},
+	}, versions)
 	if err := mockk.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
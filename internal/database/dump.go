@@ -0,0 +1,294 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"test/internal/models"
+	"test/internal/tenant"
+)
+
+// SchemaVersion is the version of the tables, dumps record it to be restored into the same
+// tables. It is increased whenever a table or column is added or changed.
+const SchemaVersion = 4
+
+// DumpSections are the sections of a dump in the order they are written and restored, every
+// section holds the records of a table.
+var DumpSections = []string{"groups", "songs", "song_lines", "song_chords", "lyrics", "playlists", "playlist_songs"}
+
+// DumpWriter receives the records of a dump, every section is started before its records.
+type DumpWriter interface {
+	Section(name string) error
+	Record(record interface{}) error
+}
+
+// DumpReader passes every record of the section to each, which decodes it into its type.
+type DumpReader interface {
+	ReadSection(name string, each func(decode func(record interface{}) error) error) error
+}
+
+// dumpTable selects the records of a section of the tenant given as $1.
+type dumpTable struct {
+	section string
+	query   string
+	scan    func(rows pgx.Rows) (interface{}, error)
+}
+
+var dumpTables = []dumpTable{
+	{"groups", "SELECT group_name FROM groups WHERE tenant = $1 ORDER BY id", func(rows pgx.Rows) (interface{}, error) {
+		var group models.DumpGroupData
+		err := rows.Scan(&group.Name)
+		return group, err
+	}},
+	{"songs", "SELECT g.group_name, s.song_name, COALESCE(TO_CHAR(s.releaseDate, 'DD.MM.YYYY'), ''), COALESCE(s.text, ''), COALESCE(s.link, ''), COALESCE(s.lang, ''), COALESCE(s.lang_confidence, 0) FROM songs s JOIN groups g ON s.group_id = g.id WHERE g.tenant = $1 ORDER BY s.id", func(rows pgx.Rows) (interface{}, error) {
+		var song models.DumpSongData
+		err := rows.Scan(&song.Group, &song.Song, &song.Date, &song.Text, &song.Link, &song.Lang, &song.LangConfidence)
+		return song, err
+	}},
+	{"song_lines", "SELECT g.group_name, s.song_name, l.position, l.start_ms, COALESCE(l.text, ''), l.words FROM song_lines l JOIN songs s ON l.song_id = s.id JOIN groups g ON s.group_id = g.id WHERE g.tenant = $1 ORDER BY l.song_id, l.position", func(rows pgx.Rows) (interface{}, error) {
+		var line models.DumpLineData
+		err := rows.Scan(&line.Group, &line.Song, &line.Position, &line.Time, &line.Text, &line.Words)
+		return line, err
+	}},
+	{"song_chords", "SELECT g.group_name, s.song_name, c.line, c.chords FROM song_chords c JOIN songs s ON c.song_id = s.id JOIN groups g ON s.group_id = g.id WHERE g.tenant = $1 ORDER BY c.song_id, c.line", func(rows pgx.Rows) (interface{}, error) {
+		var chords models.DumpChordsData
+		err := rows.Scan(&chords.Group, &chords.Song, &chords.Line, &chords.Chords)
+		return chords, err
+	}},
+	{"lyrics", "SELECT g.group_name, s.song_name, y.lang, y.kind, COALESCE(y.text, '') FROM lyrics y JOIN songs s ON y.song_id = s.id JOIN groups g ON s.group_id = g.id WHERE g.tenant = $1 ORDER BY y.id", func(rows pgx.Rows) (interface{}, error) {
+		var lyrics models.DumpLyricsData
+		err := rows.Scan(&lyrics.Group, &lyrics.Song, &lyrics.Lang, &lyrics.Kind, &lyrics.Text)
+		return lyrics, err
+	}},
+	{"playlists", "SELECT id, owner, title, COALESCE(description, '') FROM playlists WHERE tenant = $1 ORDER BY id", func(rows pgx.Rows) (interface{}, error) {
+		var playlist models.DumpPlaylistData
+		err := rows.Scan(&playlist.ID, &playlist.Owner, &playlist.Title, &playlist.Description)
+		return playlist, err
+	}},
+	{"playlist_songs", "SELECT p.playlist_id, p.position, g.group_name, s.song_name FROM playlist_songs p JOIN playlists l ON p.playlist_id = l.id JOIN songs s ON p.song_id = s.id JOIN groups g ON s.group_id = g.id WHERE l.tenant = $1 ORDER BY p.playlist_id, p.position", func(rows pgx.Rows) (interface{}, error) {
+		var entry models.DumpPlaylistSongData
+		err := rows.Scan(&entry.Playlist, &entry.Position, &entry.Group, &entry.Song)
+		return entry, err
+	}},
+}
+
+// DumpQuery writes the library of the tenant to the dump from a single snapshot of the database,
+// the records are written as they are read.
+func (db *PGXDatabase) DumpQuery(ctx context.Context, dump DumpWriter) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY")
+	if err != nil {
+		return err
+	}
+	for _, table := range dumpTables {
+		if err := dump.Section(table.section); err != nil {
+			return err
+		}
+		if err := dumpRecords(ctx, tx, table, dump); err != nil {
+			return fmt.Errorf("Failed to dump %s: %w", table.section, err)
+		}
+	}
+	return nil
+}
+
+func dumpRecords(ctx context.Context, tx pgx.Tx, table dumpTable, dump DumpWriter) error {
+	rows, err := tx.Query(ctx, table.query, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		record, err := table.scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := dump.Record(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// RestoreQuery loads the dump into the library of the tenant in a single transaction. With
+// replace the library and the playlists are emptied first, otherwise the dump is merged into
+// it: the songs of the dump replace the stored ones with their lines, chords and lyrics, the
+// playlists of the dump replace the stored ones of the same owner and title with their songs,
+// the other songs and playlists are kept.
+func (db *PGXDatabase) RestoreQuery(ctx context.Context, dump DumpReader, replace bool) error {
+	tenantName := tenant.From(ctx)
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if replace {
+		// the songs and their details are deleted with their groups, the entries of the
+		// playlists with the playlists
+		_, err = tx.Exec(ctx, "DELETE FROM playlists WHERE tenant = $1", tenantName)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM groups WHERE tenant = $1", tenantName)
+		if err != nil {
+			return err
+		}
+	}
+	err = dump.ReadSection("groups", func(decode func(interface{}) error) error {
+		var group models.DumpGroupData
+		if err := decodeRecord(decode, &group); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "INSERT INTO groups(group_name, tenant) values($1, $2) ON CONFLICT (tenant, group_name) DO NOTHING", group.Name, tenantName)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to restore groups: %w", err)
+	}
+	err = dump.ReadSection("songs", func(decode func(interface{}) error) error {
+		var song models.DumpSongData
+		if err := decodeRecord(decode, &song); err != nil {
+			return err
+		}
+		var songID int
+		err := tx.QueryRow(ctx, "INSERT INTO songs(song_name, releaseDate, text, link, group_id, lang, lang_confidence, tenant) SELECT $1, TO_TIMESTAMP(NULLIF($2, ''), 'DD.MM.YYYY'), $3, $4, id, NULLIF($5, ''), $6, tenant FROM groups WHERE group_name = $7 AND tenant = $8 ON CONFLICT (tenant, group_id, song_name) DO UPDATE SET releaseDate = EXCLUDED.releaseDate, text = EXCLUDED.text, link = EXCLUDED.link, lang = EXCLUDED.lang, lang_confidence = EXCLUDED.lang_confidence RETURNING id",
+			song.Song, song.Date, song.Text, song.Link, song.Lang, song.LangConfidence, song.Group, tenantName).Scan(&songID)
+		if err == pgx.ErrNoRows {
+			return &DumpError{Err: fmt.Errorf("The group %q of the song %q is missing", song.Group, song.Song)}
+		}
+		if err != nil || replace {
+			return err
+		}
+		// the details of the merged song are replaced by the ones of the dump
+		for _, table := range []string{"song_lines", "song_chords", "lyrics"} {
+			if _, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE song_id = $1", songID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to restore songs: %w", err)
+	}
+	err = dump.ReadSection("song_lines", func(decode func(interface{}) error) error {
+		var line models.DumpLineData
+		if err := decodeRecord(decode, &line); err != nil {
+			return err
+		}
+		return restoreRecord(ctx, tx, "INSERT INTO song_lines(song_id, position, start_ms, text, words) SELECT s.id, $3, $4, $5, $6 FROM songs s JOIN groups g ON s.group_id = g.id WHERE g.group_name = $1 AND s.song_name = $2 AND g.tenant = $7",
+			line.Group, line.Song, line.Position, line.Time, line.Text, line.Words, tenantName)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to restore song lines: %w", err)
+	}
+	err = dump.ReadSection("song_chords", func(decode func(interface{}) error) error {
+		var chords models.DumpChordsData
+		if err := decodeRecord(decode, &chords); err != nil {
+			return err
+		}
+		return restoreRecord(ctx, tx, "INSERT INTO song_chords(song_id, line, chords) SELECT s.id, $3, $4 FROM songs s JOIN groups g ON s.group_id = g.id WHERE g.group_name = $1 AND s.song_name = $2 AND g.tenant = $5",
+			chords.Group, chords.Song, chords.Line, chords.Chords, tenantName)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to restore song chords: %w", err)
+	}
+	err = dump.ReadSection("lyrics", func(decode func(interface{}) error) error {
+		var lyrics models.DumpLyricsData
+		if err := decodeRecord(decode, &lyrics); err != nil {
+			return err
+		}
+		return restoreRecord(ctx, tx, "INSERT INTO lyrics(song_id, lang, kind, text) SELECT s.id, $3, $4, $5 FROM songs s JOIN groups g ON s.group_id = g.id WHERE g.group_name = $1 AND s.song_name = $2 AND g.tenant = $6",
+			lyrics.Group, lyrics.Song, lyrics.Lang, lyrics.Kind, lyrics.Text, tenantName)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to restore lyrics: %w", err)
+	}
+	// the ids of the playlists of the dump are the ids of the restored ones
+	playlists := map[int]int{}
+	err = dump.ReadSection("playlists", func(decode func(interface{}) error) error {
+		var playlist models.DumpPlaylistData
+		if err := decodeRecord(decode, &playlist); err != nil {
+			return err
+		}
+		id, err := restorePlaylist(ctx, tx, playlist, tenantName, replace)
+		playlists[playlist.ID] = id
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to restore playlists: %w", err)
+	}
+	err = dump.ReadSection("playlist_songs", func(decode func(interface{}) error) error {
+		var entry models.DumpPlaylistSongData
+		if err := decodeRecord(decode, &entry); err != nil {
+			return err
+		}
+		id, found := playlists[entry.Playlist]
+		if !found {
+			return &DumpError{Err: fmt.Errorf("The playlist %d of the song %q is missing", entry.Playlist, entry.Song)}
+		}
+		return restoreRecord(ctx, tx, "INSERT INTO playlist_songs(playlist_id, song_id, position) SELECT $3, s.id, $4 FROM songs s JOIN groups g ON s.group_id = g.id WHERE g.group_name = $1 AND s.song_name = $2 AND g.tenant = $5",
+			entry.Group, entry.Song, id, entry.Position, tenantName)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to restore playlist songs: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// restorePlaylist returns the id of the restored playlist. Merged into the library, the stored
+// playlist of the same owner and title is emptied and takes the description of the dump.
+func restorePlaylist(ctx context.Context, tx pgx.Tx, playlist models.DumpPlaylistData, tenantName string, replace bool) (int, error) {
+	var id int
+	if !replace {
+		err := tx.QueryRow(ctx, "UPDATE playlists SET description = $4 WHERE id = (SELECT id FROM playlists WHERE tenant = $1 AND owner = $2 AND title = $3 ORDER BY id LIMIT 1) RETURNING id",
+			tenantName, playlist.Owner, playlist.Title, playlist.Description).Scan(&id)
+		if err == nil {
+			_, err = tx.Exec(ctx, "DELETE FROM playlist_songs WHERE playlist_id = $1", id)
+			return id, err
+		}
+		if err != pgx.ErrNoRows {
+			return id, err
+		}
+	}
+	err := tx.QueryRow(ctx, "INSERT INTO playlists(owner, title, description, tenant) values($1, $2, $3, $4) RETURNING id",
+		playlist.Owner, playlist.Title, playlist.Description, tenantName).Scan(&id)
+	return id, err
+}
+
+// DumpError is a record of the dump that can not be decoded or refers to a record missing from
+// the dump, the dump is invalid rather than the database failing.
+type DumpError struct {
+	Err error
+}
+
+func (e *DumpError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DumpError) Unwrap() error {
+	return e.Err
+}
+
+// decodeRecord decodes a record of the dump, the records that can not be decoded are DumpErrors.
+func decodeRecord(decode func(interface{}) error, record interface{}) error {
+	if err := decode(record); err != nil {
+		return &DumpError{Err: err}
+	}
+	return nil
+}
+
+// restoreRecord inserts a record of the song given by the first two arguments, its group and
+// name, failing when the song is missing.
+func restoreRecord(ctx context.Context, tx pgx.Tx, query string, arguments ...interface{}) error {
+	tag, err := tx.Exec(ctx, query, arguments...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &DumpError{Err: fmt.Errorf("The song %q of %q is missing", arguments[1], arguments[0])}
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"test/internal/models"
+	"testing"
+)
+
+// recordedDump keeps the records of a dump by section and reads them back as a DumpReader.
+type recordedDump struct {
+	sections []string
+	records  map[string][]string
+}
+
+func (d *recordedDump) Section(name string) error {
+	d.sections = append(d.sections, name)
+	return nil
+}
+
+func (d *recordedDump) Record(record interface{}) error {
+	data, err := json.Marshal(record)
+	section := d.sections[len(d.sections)-1]
+	d.records[section] = append(d.records[section], string(data))
+	return err
+}
+
+func (d *recordedDump) ReadSection(name string, each func(decode func(record interface{}) error) error) error {
+	for _, record := range d.records[name] {
+		err := each(func(v interface{}) error {
+			return json.Unmarshal([]byte(record), v)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDumpQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectBegin()
+	mockk.ExpectExec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY").
+		WillReturnResult(pgxmock.NewResult("SET", 0))
+	mockk.ExpectQuery("SELECT group_name FROM groups WHERE tenant = \\$1 ORDER BY id").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"group_name"}).AddRow("Muse"))
+	mockk.ExpectQuery("SELECT g.group_name, s.song_name, .* FROM songs s").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "releaseDate", "text", "link", "lang", "lang_confidence"}).
+			AddRow("Muse", "Uprising", "07.09.2009", "Paranoia is in bloom", "", "en", 0.9))
+	mockk.ExpectQuery("SELECT g.group_name, s.song_name, l.position, .* FROM song_lines l").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "position", "start_ms", "text", "words"}).
+			AddRow("Muse", "Uprising", 1, int64(12000), "Paranoia is in bloom", []models.TimedWordData(nil)))
+	mockk.ExpectQuery("SELECT g.group_name, s.song_name, c.line, c.chords FROM song_chords c").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "line", "chords"}).
+			AddRow("Muse", "Uprising", 1, []models.ChordData{{Position: 0, Name: "Dm"}}))
+	mockk.ExpectQuery("SELECT g.group_name, s.song_name, y.lang, y.kind, .* FROM lyrics y").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"group_name", "song_name", "lang", "kind", "text"}))
+	mockk.ExpectQuery("SELECT id, owner, title, .* FROM playlists WHERE tenant = \\$1").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "owner", "title", "description"}).AddRow(7, "alice", "Friday setlist", ""))
+	mockk.ExpectQuery("SELECT p.playlist_id, p.position, g.group_name, s.song_name FROM playlist_songs p").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"playlist_id", "position", "group_name", "song_name"}).AddRow(7, 1, "Muse", "Uprising"))
+	mockk.ExpectRollback()
+	dump := &recordedDump{records: map[string][]string{}}
+	err = database.DumpQuery(context.Background(), dump)
+	assert.NoError(t, err)
+	assert.Equal(t, DumpSections, dump.sections)
+	assert.Equal(t, map[string][]string{
+		"groups":         {`{"name":"Muse"}`},
+		"songs":          {`{"group":"Muse","song":"Uprising","releaseDate":"07.09.2009","text":"Paranoia is in bloom","lang":"en","langConfidence":0.9}`},
+		"song_lines":     {`{"group":"Muse","song":"Uprising","position":1,"time":12000,"text":"Paranoia is in bloom"}`},
+		"song_chords":    {`{"group":"Muse","song":"Uprising","line":1,"chords":[{"position":0,"name":"Dm"}]}`},
+		"playlists":      {`{"id":7,"owner":"alice","title":"Friday setlist"}`},
+		"playlist_songs": {`{"playlist":7,"position":1,"group":"Muse","song":"Uprising"}`},
+	}, dump.records)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRestoreQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	dump := &recordedDump{records: map[string][]string{
+		"groups":     {`{"name":"Muse"}`},
+		"songs":      {`{"group":"Muse","song":"Uprising","releaseDate":"07.09.2009","lang":"en","langConfidence":0.9}`},
+		"song_lines": {`{"group":"Muse","song":"Uprising","position":1,"time":12000,"text":"Paranoia is in bloom"}`},
+		"lyrics":     {`{"group":"Muse","song":"Uprising","lang":"ru","kind":"translation","text":"Паранойя расцветает"}`},
+		"playlists": {
+			`{"id":7,"owner":"alice","title":"Friday setlist","description":"Encore included"}`,
+			`{"id":8,"owner":"bob","title":"Warm-up"}`,
+		},
+		"playlist_songs": {`{"playlist":7,"position":1,"group":"Muse","song":"Uprising"}`, `{"playlist":8,"position":1,"group":"Muse","song":"Uprising"}`},
+	}}
+	mockk.ExpectBegin()
+	mockk.ExpectExec("INSERT INTO groups\\(group_name, tenant\\) values\\(\\$1, \\$2\\) ON CONFLICT \\(tenant, group_name\\)").
+		WithArgs("Muse", "default").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectQuery("INSERT INTO songs.* ON CONFLICT \\(tenant, group_id, song_name\\) DO UPDATE").
+		WithArgs("Uprising", "07.09.2009", "", "", "en", 0.9, "Muse", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(5))
+	for _, table := range []string{"song_lines", "song_chords", "lyrics"} {
+		mockk.ExpectExec("DELETE FROM " + table + " WHERE song_id = \\$1").
+			WithArgs(5).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	}
+	mockk.ExpectExec("INSERT INTO song_lines").
+		WithArgs("Muse", "Uprising", 1, int64(12000), "Paranoia is in bloom", []models.TimedWordData(nil), "default").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectExec("INSERT INTO lyrics").
+		WithArgs("Muse", "Uprising", "ru", "translation", "Паранойя расцветает", "default").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	// the stored playlist of the same owner and title is emptied, the other one is added
+	mockk.ExpectQuery("UPDATE playlists SET description = \\$4 WHERE id = \\(SELECT id FROM playlists WHERE tenant = \\$1 AND owner = \\$2 AND title = \\$3").
+		WithArgs("default", "alice", "Friday setlist", "Encore included").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(3))
+	mockk.ExpectExec("DELETE FROM playlist_songs WHERE playlist_id = \\$1").
+		WithArgs(3).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mockk.ExpectQuery("UPDATE playlists SET description").
+		WithArgs("default", "bob", "Warm-up", "").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	mockk.ExpectQuery("INSERT INTO playlists\\(owner, title, description, tenant\\)").
+		WithArgs("bob", "Warm-up", "", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(4))
+	mockk.ExpectExec("INSERT INTO playlist_songs\\(playlist_id, song_id, position\\)").
+		WithArgs("Muse", "Uprising", 3, 1, "default").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectExec("INSERT INTO playlist_songs").
+		WithArgs("Muse", "Uprising", 4, 1, "default").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectCommit()
+	err = database.RestoreQuery(context.Background(), dump, false)
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRestoreQuery_Replace(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	dump := &recordedDump{records: map[string][]string{
+		"songs":       {`{"group":"Muse","song":"Uprising"}`},
+		"song_chords": {`{"group":"Muse","song":"Hysteria","line":1,"chords":[]}`},
+	}}
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DELETE FROM playlists WHERE tenant = \\$1").
+		WithArgs("default").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mockk.ExpectExec("DELETE FROM groups WHERE tenant = \\$1").
+		WithArgs("default").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mockk.ExpectQuery("INSERT INTO songs").
+		WithArgs("Uprising", "", "", "", "", 0.0, "Muse", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectExec("INSERT INTO song_chords").
+		WithArgs("Muse", "Hysteria", 1, []models.ChordData{}, "default").
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mockk.ExpectRollback()
+	err = database.RestoreQuery(context.Background(), dump, true)
+	assert.EqualError(t, err, "Failed to restore song chords: The song \"Hysteria\" of \"Muse\" is missing")
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRestoreQuery_Playlists(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	dump := &recordedDump{records: map[string][]string{
+		"playlists":      {`{"id":7,"owner":"alice","title":"Friday setlist"}`},
+		"playlist_songs": {`{"playlist":9,"position":1,"group":"Muse","song":"Uprising"}`},
+	}}
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DELETE FROM playlists WHERE tenant = \\$1").
+		WithArgs("default").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockk.ExpectExec("DELETE FROM groups WHERE tenant = \\$1").
+		WithArgs("default").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockk.ExpectQuery("INSERT INTO playlists").
+		WithArgs("alice", "Friday setlist", "", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectRollback()
+	err = database.RestoreQuery(context.Background(), dump, true)
+	assert.EqualError(t, err, "Failed to restore playlist songs: The playlist 9 of the song \"Uprising\" is missing")
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRestoreQuery_MissingGroup(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	dump := &recordedDump{records: map[string][]string{"songs": {`{"group":"Muse","song":"Uprising"}`}}}
+	mockk.ExpectBegin()
+	mockk.ExpectQuery("INSERT INTO songs").
+		WithArgs("Uprising", "", "", "", "", 0.0, "Muse", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	mockk.ExpectRollback()
+	err = database.RestoreQuery(context.Background(), dump, false)
+	assert.EqualError(t, err, "Failed to restore songs: The group \"Muse\" of the song \"Uprising\" is missing")
+	var invalid *DumpError
+	assert.ErrorAs(t, err, &invalid)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRestoreQuery_InvalidRecord(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	dump := &recordedDump{records: map[string][]string{"groups": {`{"name":1}`}}}
+	mockk.ExpectBegin()
+	mockk.ExpectRollback()
+	err = database.RestoreQuery(context.Background(), dump, false)
+	var invalid *DumpError
+	assert.ErrorAs(t, err, &invalid)
+	assert.ErrorContains(t, err, "Failed to restore groups")
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+)
+
+// recordSchemaVersion stores the version of the tables once they are created or migrated. The
+// version is never lowered, so an older instance starting against migrated tables leaves it.
+func (db *PGXDatabase) recordSchemaVersion(ctx context.Context) error {
+	_, err := db.pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS schema_version (id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id), version INTEGER NOT NULL);")
+	if err != nil {
+		return err
+	}
+	_, err = db.pool.Exec(ctx, "INSERT INTO schema_version(id, version) values(true, $1) ON CONFLICT (id) DO UPDATE SET version = GREATEST(schema_version.version, EXCLUDED.version);", SchemaVersion)
+	return err
+}
+
+// SchemaVersionQuery returns the version of the tables recorded by CreateTableQuery, it also
+// tells that the database is reachable.
+func (db *PGXDatabase) SchemaVersionQuery(ctx context.Context) (int, error) {
+	var version int
+	err := db.pool.QueryRow(ctx, "SELECT version FROM schema_version;").Scan(&version)
+	return version, err
+}
+
+// PingQuery tells whether the database answers.
+func (db *PGXDatabase) PingQuery(ctx context.Context) error {
+	_, err := db.pool.Exec(ctx, "SELECT 1;")
+	return err
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHealthQueries(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectExec("SELECT 1").WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mockk.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(3))
+	assert.NoError(t, database.PingQuery(context.Background()))
+	version, err := database.SchemaVersionQuery(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, version)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"test/internal/tenant"
+	"time"
+)
+
+// ErrTenantsInUse refuses to roll back the tenants while rows belong to other tenants than the
+// default one, the older schema would merge them into one library.
+var ErrTenantsInUse = errors.New("Rows belong to other tenants than the default one, move or delete them before rolling back")
+
+// downgrades are the statements undoing the schema changes of a version, by the version. The
+// fourth version only added the playlists to the archives and changed no table.
+var downgrades = map[int][]string{
+	4: nil,
+	3: {"DROP TABLE IF EXISTS quotas;"},
+	2: {
+		"DROP POLICY IF EXISTS tenant_isolation ON groups;",
+		"DROP POLICY IF EXISTS tenant_isolation ON songs;",
+		"DROP POLICY IF EXISTS tenant_isolation ON playlists;",
+		"ALTER TABLE groups NO FORCE ROW LEVEL SECURITY, DISABLE ROW LEVEL SECURITY;",
+		"ALTER TABLE songs NO FORCE ROW LEVEL SECURITY, DISABLE ROW LEVEL SECURITY;",
+		"ALTER TABLE playlists NO FORCE ROW LEVEL SECURITY, DISABLE ROW LEVEL SECURITY;",
+		"DROP INDEX IF EXISTS unique_tenant_group;",
+		"DROP INDEX IF EXISTS unique_tenant_group_song;",
+		"ALTER TABLE groups ADD CONSTRAINT unique_group UNIQUE(group_name);",
+		"ALTER TABLE songs ADD CONSTRAINT unique_group_song UNIQUE(group_id, song_name);",
+		"ALTER TABLE groups DROP COLUMN IF EXISTS tenant;",
+		"ALTER TABLE songs DROP COLUMN IF EXISTS tenant;",
+		"ALTER TABLE playlists DROP COLUMN IF EXISTS tenant;",
+		"ALTER TABLE users DROP COLUMN IF EXISTS tenant;",
+	},
+}
+
+// MigrateDownQuery rolls the tables back to the schema version before the recorded one in one
+// transaction and returns the version they have then. The tenants are only rolled back while
+// every row belongs to the default tenant, and the first version can not be rolled back.
+func (db *PGXDatabase) MigrateDownQuery(ctx context.Context) (int, error) {
+	version, err := db.SchemaVersionQuery(ctx)
+	if err != nil {
+		return version, err
+	}
+	statements, found := downgrades[version]
+	if !found {
+		return version, fmt.Errorf("The schema version %d can not be rolled back", version)
+	}
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return version, err
+	}
+	defer tx.Rollback(ctx)
+	if version == 2 {
+		var others bool
+		err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM groups WHERE tenant <> $1 UNION ALL SELECT 1 FROM playlists WHERE tenant <> $1 UNION ALL SELECT 1 FROM users WHERE tenant <> $1)", tenant.Default).Scan(&others)
+		if err != nil {
+			return version, err
+		}
+		if others {
+			return version, ErrTenantsInUse
+		}
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(ctx, statement); err != nil {
+			return version, err
+		}
+	}
+	if _, err = tx.Exec(ctx, "UPDATE schema_version SET version = $1", version-1); err != nil {
+		return version, err
+	}
+	return version - 1, tx.Commit(ctx)
+}
+
+// searchTables are the tables the songs are searched in by /getdata.
+var searchTables = []string{"groups", "songs", "lyrics"}
+
+// ReindexQuery rebuilds the indexes the songs are searched with and refreshes the statistics
+// the planner chooses them by.
+func (db *PGXDatabase) ReindexQuery(ctx context.Context) error {
+	for _, table := range searchTables {
+		if _, err := db.pool.Exec(ctx, "REINDEX TABLE "+table+";"); err != nil {
+			return err
+		}
+		if _, err := db.pool.Exec(ctx, "ANALYZE "+table+";"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeQuery deletes what is left behind in the library of the tenant: the groups without
+// songs and the API keys of its users revoked before the time. It returns the number of the
+// groups and of the keys deleted.
+func (db *PGXDatabase) PurgeQuery(ctx context.Context, before time.Time) (groups int64, keys int64, err error) {
+	tenantName := tenant.From(ctx)
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+	result, err := tx.Exec(ctx, "DELETE FROM groups g WHERE g.tenant = $1 AND NOT EXISTS (SELECT 1 FROM songs s WHERE s.group_id = g.id)", tenantName)
+	if err != nil {
+		return 0, 0, err
+	}
+	groups = result.RowsAffected()
+	result, err = tx.Exec(ctx, "DELETE FROM api_keys k USING users u WHERE k.user_id = u.id AND u.tenant = $1 AND k.revoked_at < $2", tenantName, before)
+	if err != nil {
+		return 0, 0, err
+	}
+	keys = result.RowsAffected()
+	return groups, keys, tx.Commit(ctx)
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMigrateDownQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(3))
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DROP TABLE IF EXISTS quotas").
+		WillReturnResult(pgxmock.NewResult("DROP", 0))
+	mockk.ExpectExec("UPDATE schema_version SET version = \\$1").
+		WithArgs(2).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectCommit()
+	version, err := database.MigrateDownQuery(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigrateDownQuery_Tenants(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(2))
+	mockk.ExpectBegin()
+	mockk.ExpectQuery("SELECT EXISTS").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mockk.ExpectRollback()
+	version, err := database.MigrateDownQuery(context.Background())
+	assert.Equal(t, ErrTenantsInUse, err)
+	assert.Equal(t, 2, version)
+	mockk.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(2))
+	mockk.ExpectBegin()
+	mockk.ExpectQuery("SELECT EXISTS").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	for range downgrades[2] {
+		mockk.ExpectExec("").
+			WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	}
+	mockk.ExpectExec("UPDATE schema_version SET version = \\$1").
+		WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectCommit()
+	version, err = database.MigrateDownQuery(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version)
+	mockk.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(1))
+	_, err = database.MigrateDownQuery(context.Background())
+	assert.EqualError(t, err, "The schema version 1 can not be rolled back")
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPurgeQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	before := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mockk.ExpectBegin()
+	mockk.ExpectExec("DELETE FROM groups g WHERE g.tenant = \\$1 AND NOT EXISTS").
+		WithArgs("default").
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mockk.ExpectExec("DELETE FROM api_keys k USING users u").
+		WithArgs("default", before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	mockk.ExpectCommit()
+	groups, keys, err := database.PurgeQuery(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), groups)
+	assert.Equal(t, int64(3), keys)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,279 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"test/internal/models"
+	"test/internal/tenant"
+)
+
+var (
+	ErrPlaylistNotFound = errors.New("The playlist is not found")
+	ErrSongNotFound     = errors.New("The song is not found")
+	ErrInvalidPosition  = errors.New("Invalid position")
+)
+
+func (db *PGXDatabase) InsertPlaylistQuery(ctx context.Context, owner string, title string, description string) (int, error) {
+	var id int
+	err := db.pool.QueryRow(ctx, "INSERT INTO playlists(owner, title, description, tenant) values($1, $2, $3, $4) RETURNING id", owner, title, description, tenant.From(ctx)).Scan(&id)
+	return id, err
+}
+
+func (db *PGXDatabase) UpdatePlaylistQuery(ctx context.Context, id int, title string, description string) error {
+	tag, err := db.pool.Exec(ctx, "UPDATE playlists SET title = $1, description = $2 WHERE id = $3 AND tenant = $4", title, description, id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPlaylistNotFound
+	}
+	return nil
+}
+
+func (db *PGXDatabase) DeletePlaylistQuery(ctx context.Context, id int) error {
+	tag, err := db.pool.Exec(ctx, "DELETE FROM playlists WHERE id = $1 AND tenant = $2", id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPlaylistNotFound
+	}
+	return nil
+}
+
+// SelectPlaylistOwnerQuery selects the owner of the playlist.
+func (db *PGXDatabase) SelectPlaylistOwnerQuery(ctx context.Context, id int) (string, error) {
+	var owner string
+	err := db.pool.QueryRow(ctx, "SELECT owner FROM playlists WHERE id = $1 AND tenant = $2", id, tenant.From(ctx)).Scan(&owner)
+	if err == pgx.ErrNoRows {
+		return owner, ErrPlaylistNotFound
+	}
+	return owner, err
+}
+
+// SelectPlaylistQuery selects the playlist with its songs numbered in their order.
+func (db *PGXDatabase) SelectPlaylistQuery(ctx context.Context, id int) (models.PlaylistData, error) {
+	playlist := models.PlaylistData{ID: id}
+	err := db.pool.QueryRow(ctx, "SELECT owner, title, COALESCE(description, '') FROM playlists WHERE id = $1 AND tenant = $2", id, tenant.From(ctx)).Scan(&playlist.Owner, &playlist.Title, &playlist.Description)
+	if err == pgx.ErrNoRows {
+		return playlist, ErrPlaylistNotFound
+	}
+	if err != nil {
+		return playlist, err
+	}
+	rows, err := db.pool.Query(ctx, "SELECT ROW_NUMBER() OVER (ORDER BY p.position), g.group_name, s.song_name, COALESCE(s.link, '') FROM playlist_songs p JOIN songs s ON p.song_id = s.id JOIN groups g ON s.group_id = g.id WHERE p.playlist_id = $1 ORDER BY p.position", id)
+	if err != nil {
+		return playlist, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var song models.PlaylistSongData
+		if err := rows.Scan(&song.Position, &song.Group, &song.Song, &song.Link); err != nil {
+			return playlist, err
+		}
+		playlist.Songs = append(playlist.Songs, song)
+	}
+	return playlist, rows.Err()
+}
+
+// SelectPlaylistsQuery selects the playlists of the owner without their songs, the playlists of
+// every owner of the tenant without one.
+func (db *PGXDatabase) SelectPlaylistsQuery(ctx context.Context, owner string) ([]models.PlaylistData, error) {
+	var playlists []models.PlaylistData
+	rows, err := db.pool.Query(ctx, "SELECT id, owner, title, COALESCE(description, '') FROM playlists WHERE ($1 = '' OR owner = $1) AND tenant = $2 ORDER BY id", owner, tenant.From(ctx))
+	if err != nil {
+		return playlists, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var playlist models.PlaylistData
+		if err := rows.Scan(&playlist.ID, &playlist.Owner, &playlist.Title, &playlist.Description); err != nil {
+			return playlists, err
+		}
+		playlists = append(playlists, playlist)
+	}
+	return playlists, rows.Err()
+}
+
+// lockPlaylist locks the playlist for the changes of its positions and returns its length. The
+// songs are renumbered first, as deleting a song from the library leaves a gap in the positions.
+func lockPlaylist(ctx context.Context, tx pgx.Tx, id int) (int, error) {
+	var locked int
+	err := tx.QueryRow(ctx, "SELECT id FROM playlists WHERE id = $1 AND tenant = $2 FOR UPDATE", id, tenant.From(ctx)).Scan(&locked)
+	if err == pgx.ErrNoRows {
+		return 0, ErrPlaylistNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(ctx, "UPDATE playlist_songs p SET position = r.n FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY position) AS n FROM playlist_songs WHERE playlist_id = $1) r WHERE p.id = r.id AND p.position <> r.n", id)
+	if err != nil {
+		return 0, err
+	}
+	var length int
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM playlist_songs WHERE playlist_id = $1", id).Scan(&length)
+	return length, err
+}
+
+// InsertPlaylistSongQuery inserts the song at the position moving the following songs down, the
+// song is appended without a position. The position of the song is returned.
+func (db *PGXDatabase) InsertPlaylistSongQuery(ctx context.Context, id int, group string, song string, position int) (int, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	length, err := lockPlaylist(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if position == 0 {
+		position = length + 1
+	}
+	if position < 1 || position > length+1 {
+		return 0, fmt.Errorf("%w %d, the playlist has %d songs", ErrInvalidPosition, position, length)
+	}
+	var songID int
+	err = tx.QueryRow(ctx, "SELECT s.id FROM songs s JOIN groups g ON s.group_id = g.id WHERE g.group_name = $1 AND s.song_name = $2 AND g.tenant = $3", group, song, tenant.From(ctx)).Scan(&songID)
+	if err == pgx.ErrNoRows {
+		return 0, ErrSongNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(ctx, "UPDATE playlist_songs SET position = position + 1 WHERE playlist_id = $1 AND position >= $2", id, position)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO playlist_songs(playlist_id, song_id, position) values($1, $2, $3)", id, songID, position)
+	if err != nil {
+		return 0, err
+	}
+	return position, tx.Commit(ctx)
+}
+
+// DeletePlaylistSongQuery removes the song at the position moving the following songs up.
+func (db *PGXDatabase) DeletePlaylistSongQuery(ctx context.Context, id int, position int) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	length, err := lockPlaylist(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if position < 1 || position > length {
+		return fmt.Errorf("%w %d, the playlist has %d songs", ErrInvalidPosition, position, length)
+	}
+	_, err = tx.Exec(ctx, "DELETE FROM playlist_songs WHERE playlist_id = $1 AND position = $2", id, position)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "UPDATE playlist_songs SET position = position - 1 WHERE playlist_id = $1 AND position > $2", id, position)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// MovePlaylistSongQuery moves the song from a position to another, the songs between them move
+// by one position towards the place the song was taken from.
+func (db *PGXDatabase) MovePlaylistSongQuery(ctx context.Context, id int, from int, to int) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	length, err := lockPlaylist(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	for _, position := range []int{from, to} {
+		if position < 1 || position > length {
+			return fmt.Errorf("%w %d, the playlist has %d songs", ErrInvalidPosition, position, length)
+		}
+	}
+	if from == to {
+		return nil
+	}
+	// the moved song is put aside while the others move
+	_, err = tx.Exec(ctx, "UPDATE playlist_songs SET position = 0 WHERE playlist_id = $1 AND position = $2", id, from)
+	if err != nil {
+		return err
+	}
+	if from < to {
+		_, err = tx.Exec(ctx, "UPDATE playlist_songs SET position = position - 1 WHERE playlist_id = $1 AND position > $2 AND position <= $3", id, from, to)
+	} else {
+		_, err = tx.Exec(ctx, "UPDATE playlist_songs SET position = position + 1 WHERE playlist_id = $1 AND position >= $2 AND position < $3", id, to, from)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "UPDATE playlist_songs SET position = $1 WHERE playlist_id = $2 AND position = 0", to, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ImportPlaylistQuery creates the playlist with its songs, which are matched by their group and
+// name ignoring the case. The songs that are not found are returned, the found ones are numbered
+// in their order.
+func (db *PGXDatabase) ImportPlaylistQuery(ctx context.Context, playlist models.PlaylistData) (models.PlaylistData, []models.PlaylistSongData, error) {
+	var unresolved []models.PlaylistSongData
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return playlist, unresolved, err
+	}
+	defer tx.Rollback(ctx)
+	err = tx.QueryRow(ctx, "INSERT INTO playlists(owner, title, description, tenant) values($1, $2, $3, $4) RETURNING id", playlist.Owner, playlist.Title, playlist.Description, tenant.From(ctx)).Scan(&playlist.ID)
+	if err != nil {
+		return playlist, unresolved, err
+	}
+	groupNames := make([]string, len(playlist.Songs))
+	songNames := make([]string, len(playlist.Songs))
+	for i, song := range playlist.Songs {
+		groupNames[i], songNames[i] = song.Group, song.Song
+	}
+	rows, err := tx.Query(ctx, "SELECT s.id, g.group_name, s.song_name, COALESCE(s.link, '') FROM songs s JOIN groups g ON s.group_id = g.id WHERE g.tenant = $3 AND (lower(g.group_name), lower(s.song_name)) IN (SELECT lower(g), lower(s) FROM UNNEST($1::text[], $2::text[]) AS entries(g, s))", groupNames, songNames, tenant.From(ctx))
+	if err != nil {
+		return playlist, unresolved, err
+	}
+	type found struct {
+		id   int
+		song models.PlaylistSongData
+	}
+	songs := map[songKey]found{}
+	for rows.Next() {
+		var song found
+		if err := rows.Scan(&song.id, &song.song.Group, &song.song.Song, &song.song.Link); err != nil {
+			rows.Close()
+			return playlist, unresolved, err
+		}
+		songs[songKey{strings.ToLower(song.song.Group), strings.ToLower(song.song.Song)}] = song
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return playlist, unresolved, err
+	}
+	entries := playlist.Songs
+	playlist.Songs = nil
+	for _, entry := range entries {
+		song, ok := songs[songKey{strings.ToLower(entry.Group), strings.ToLower(entry.Song)}]
+		if !ok {
+			unresolved = append(unresolved, entry)
+			continue
+		}
+		song.song.Position = len(playlist.Songs) + 1
+		_, err = tx.Exec(ctx, "INSERT INTO playlist_songs(playlist_id, song_id, position) values($1, $2, $3)", playlist.ID, song.id, song.song.Position)
+		if err != nil {
+			return playlist, unresolved, err
+		}
+		playlist.Songs = append(playlist.Songs, song.song)
+	}
+	return playlist, unresolved, tx.Commit(ctx)
+}
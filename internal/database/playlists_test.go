@@ -0,0 +1,221 @@
+package database
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"test/internal/models"
+	"testing"
+)
+
+// expectLockPlaylist expects the playlist to be locked and renumbered with the songs it has.
+func expectLockPlaylist(mockk pgxmock.PgxPoolIface, id int, length int) {
+	mockk.ExpectBegin()
+	mockk.ExpectQuery("SELECT id FROM playlists WHERE id = \\$1 AND tenant = \\$2 FOR UPDATE").
+		WithArgs(id, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(id))
+	mockk.ExpectExec("UPDATE playlist_songs p SET position = r.n").
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockk.ExpectQuery("SELECT COUNT\\(\\*\\) FROM playlist_songs WHERE playlist_id = \\$1").
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(length))
+}
+
+func TestUpdatePlaylistQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectExec("UPDATE playlists SET title = \\$1, description = \\$2 WHERE id = \\$3 AND tenant = \\$4").
+		WithArgs("Friday setlist", "", 7, "default").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	err = database.UpdatePlaylistQuery(context.Background(), 7, "Friday setlist", "")
+	assert.Equal(t, ErrPlaylistNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectPlaylistOwnerQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT owner FROM playlists WHERE id = \\$1 AND tenant = \\$2").
+		WithArgs(7, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"owner"}).AddRow("alice"))
+	mockk.ExpectQuery("SELECT owner FROM playlists").
+		WithArgs(8, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"owner"}))
+	owner, err := database.SelectPlaylistOwnerQuery(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", owner)
+	_, err = database.SelectPlaylistOwnerQuery(context.Background(), 8)
+	assert.Equal(t, ErrPlaylistNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectPlaylistQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT owner, title, COALESCE\\(description, ''\\) FROM playlists WHERE id = \\$1 AND tenant = \\$2").
+		WithArgs(1, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"owner", "title", "description"}).AddRow("alice", "Friday setlist", ""))
+	mockk.ExpectQuery("SELECT ROW_NUMBER\\(\\) OVER \\(ORDER BY p.position\\), g.group_name, s.song_name").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"position", "group_name", "song_name", "link"}).
+			AddRow(1, "Muse", "Uprising", "").
+			AddRow(2, "Muse", "Uprising", ""))
+	mockk.ExpectQuery("SELECT owner, title").
+		WithArgs(2, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"owner", "title", "description"}))
+	playlist, err := database.SelectPlaylistQuery(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PlaylistData{ID: 1, Owner: "alice", Title: "Friday setlist", Songs: []models.PlaylistSongData{
+		{Position: 1, Group: "Muse", Song: "Uprising"},
+		{Position: 2, Group: "Muse", Song: "Uprising"},
+	}}, playlist)
+	_, err = database.SelectPlaylistQuery(context.Background(), 2)
+	assert.Equal(t, ErrPlaylistNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertPlaylistSongQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	expectLockPlaylist(mockk, 1, 2)
+	mockk.ExpectQuery("SELECT s.id FROM songs s JOIN groups g").
+		WithArgs("Muse", "Uprising", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(5))
+	mockk.ExpectExec("UPDATE playlist_songs SET position = position \\+ 1 WHERE playlist_id = \\$1 AND position >= \\$2").
+		WithArgs(1, 3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockk.ExpectExec("INSERT INTO playlist_songs").
+		WithArgs(1, 5, 3).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectCommit()
+	position, err := database.InsertPlaylistSongQuery(context.Background(), 1, "Muse", "Uprising", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, position)
+	expectLockPlaylist(mockk, 1, 2)
+	mockk.ExpectRollback()
+	_, err = database.InsertPlaylistSongQuery(context.Background(), 1, "Muse", "Uprising", 4)
+	assert.EqualError(t, err, "Invalid position 4, the playlist has 2 songs")
+	expectLockPlaylist(mockk, 1, 2)
+	mockk.ExpectQuery("SELECT s.id FROM songs s JOIN groups g").
+		WithArgs("Muse", "Starlight", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	mockk.ExpectRollback()
+	_, err = database.InsertPlaylistSongQuery(context.Background(), 1, "Muse", "Starlight", 1)
+	assert.Equal(t, ErrSongNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeletePlaylistSongQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	expectLockPlaylist(mockk, 1, 3)
+	mockk.ExpectExec("DELETE FROM playlist_songs WHERE playlist_id = \\$1 AND position = \\$2").
+		WithArgs(1, 2).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockk.ExpectExec("UPDATE playlist_songs SET position = position - 1 WHERE playlist_id = \\$1 AND position > \\$2").
+		WithArgs(1, 2).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectCommit()
+	err = database.DeletePlaylistSongQuery(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMovePlaylistSongQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	expectLockPlaylist(mockk, 1, 4)
+	mockk.ExpectExec("UPDATE playlist_songs SET position = 0 WHERE playlist_id = \\$1 AND position = \\$2").
+		WithArgs(1, 4).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectExec("UPDATE playlist_songs SET position = position \\+ 1 WHERE playlist_id = \\$1 AND position >= \\$2 AND position < \\$3").
+		WithArgs(1, 2, 4).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mockk.ExpectExec("UPDATE playlist_songs SET position = \\$1 WHERE playlist_id = \\$2 AND position = 0").
+		WithArgs(2, 1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectCommit()
+	err = database.MovePlaylistSongQuery(context.Background(), 1, 4, 2)
+	assert.NoError(t, err)
+	expectLockPlaylist(mockk, 1, 4)
+	mockk.ExpectRollback()
+	err = database.MovePlaylistSongQuery(context.Background(), 1, 0, 2)
+	assert.ErrorIs(t, err, ErrInvalidPosition)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportPlaylistQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectBegin()
+	mockk.ExpectQuery("INSERT INTO playlists\\(owner, title, description, tenant\\)").
+		WithArgs("alice", "Friday setlist", "", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(3))
+	mockk.ExpectQuery("SELECT s.id, g.group_name, s.song_name, .* FROM UNNEST").
+		WithArgs([]string{"muse", "Muse", ""}, []string{"uprising", "Hysteria", "Starlight"}, "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "group_name", "song_name", "link"}).AddRow(5, "Muse", "Uprising", "https://www.youtube.com/watch?v=w8KQmps-Sog"))
+	mockk.ExpectExec("INSERT INTO playlist_songs").
+		WithArgs(3, 5, 1).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectCommit()
+	playlist, unresolved, err := database.ImportPlaylistQuery(context.Background(), models.PlaylistData{
+		Owner: "alice",
+		Title: "Friday setlist",
+		Songs: []models.PlaylistSongData{
+			{Position: 1, Group: "muse", Song: "uprising"},
+			{Position: 2, Group: "Muse", Song: "Hysteria", Link: "hysteria.mp3"},
+			{Position: 3, Song: "Starlight"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, playlist.ID)
+	assert.Equal(t, []models.PlaylistSongData{{Position: 1, Group: "Muse", Song: "Uprising", Link: "https://www.youtube.com/watch?v=w8KQmps-Sog"}}, playlist.Songs)
+	assert.Equal(t, []models.PlaylistSongData{
+		{Position: 2, Group: "Muse", Song: "Hysteria", Link: "hysteria.mp3"},
+		{Position: 3, Song: "Starlight"},
+	}, unresolved)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// UseQuotaQuery counts a request of the client in the class on the day and returns the number
+// of its requests that day with this one.
+func (db *PGXDatabase) UseQuotaQuery(ctx context.Context, client string, class string, day time.Time) (int, error) {
+	var used int
+	err := db.pool.QueryRow(ctx, "INSERT INTO quotas(client, class, day, used) values($1, $2, $3::date, 1) ON CONFLICT (client, class, day) DO UPDATE SET used = quotas.used + 1 RETURNING used",
+		client, class, day.Format(time.DateOnly)).Scan(&used)
+	return used, err
+}
+
+// DeleteQuotasQuery deletes the counts of the days before the day.
+func (db *PGXDatabase) DeleteQuotasQuery(ctx context.Context, day time.Time) error {
+	_, err := db.pool.Exec(ctx, "DELETE FROM quotas WHERE day < $1::date", day.Format(time.DateOnly))
+	return err
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestUseQuotaQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	day := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+	mockk.ExpectQuery("INSERT INTO quotas\\(client, class, day, used\\) values\\(\\$1, \\$2, \\$3::date, 1\\) ON CONFLICT \\(client, class, day\\) DO UPDATE SET used = quotas.used \\+ 1 RETURNING used").
+		WithArgs("key:3", "enrich", "2024-05-01").
+		WillReturnRows(pgxmock.NewRows([]string{"used"}).AddRow(11))
+	mockk.ExpectExec("DELETE FROM quotas WHERE day < \\$1::date").
+		WithArgs("2024-05-01").
+		WillReturnResult(pgxmock.NewResult("DELETE", 4))
+	used, err := database.UseQuotaQuery(context.Background(), "key:3", "enrich", day)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, used)
+	err = database.DeleteQuotasQuery(context.Background(), day)
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
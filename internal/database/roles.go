@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"test/internal/models"
+	"test/internal/tenant"
+)
+
+var (
+	ErrRoleNotFound  = errors.New("The role is not found")
+	ErrGroupNotFound = errors.New("The group is not found")
+	ErrGroupExists   = errors.New("The group already exists")
+)
+
+func (db *PGXDatabase) roleExists(ctx context.Context, role string) error {
+	var exists bool
+	err := db.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)", role).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// SelectRolesQuery selects the roles with their permissions.
+func (db *PGXDatabase) SelectRolesQuery(ctx context.Context) ([]models.RoleData, error) {
+	var roles []models.RoleData
+	rows, err := db.pool.Query(ctx, "SELECT r.name, COALESCE(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}') FROM roles r LEFT JOIN role_permissions p ON p.role = r.name GROUP BY r.name ORDER BY r.name")
+	if err != nil {
+		return roles, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role models.RoleData
+		if err := rows.Scan(&role.Name, &role.Permissions); err != nil {
+			return roles, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// UpsertRoleQuery creates the role or replaces its permissions in one transaction.
+func (db *PGXDatabase) UpsertRoleQuery(ctx context.Context, name string, permissions []string) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx, "INSERT INTO roles(name) values($1) ON CONFLICT (name) DO NOTHING", name)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "DELETE FROM role_permissions WHERE role = $1", name)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO role_permissions(role, permission) SELECT $1, UNNEST($2::text[])", name, permissions)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// AssignRoleQuery gives the user the role, the keys of the user get its permissions on their next
+// request.
+func (db *PGXDatabase) AssignRoleQuery(ctx context.Context, user string, role string) error {
+	if err := db.roleExists(ctx, role); err != nil {
+		return err
+	}
+	tag, err := db.pool.Exec(ctx, "UPDATE users SET role = $2 WHERE name = $1 AND tenant = $3", user, role, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// DeleteGroupQuery deletes the group with its songs.
+func (db *PGXDatabase) DeleteGroupQuery(ctx context.Context, group string) error {
+	tag, err := db.pool.Exec(ctx, "DELETE FROM groups WHERE group_name = $1 AND tenant = $2", group, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// RenameGroupQuery renames the group, the name must not be taken by another group.
+func (db *PGXDatabase) RenameGroupQuery(ctx context.Context, group string, name string) error {
+	tag, err := db.pool.Exec(ctx, "UPDATE groups SET group_name = $2 WHERE group_name = $1 AND tenant = $3", group, name, tenant.From(ctx))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrGroupExists
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// SelectGroupsQuery selects the groups of the library with the number of their songs.
+func (db *PGXDatabase) SelectGroupsQuery(ctx context.Context) ([]models.GroupData, error) {
+	var groups []models.GroupData
+	rows, err := db.pool.Query(ctx, "SELECT g.id, g.group_name, COUNT(s.id) FROM groups g LEFT JOIN songs s ON s.group_id = g.id WHERE g.tenant = $1 GROUP BY g.id, g.group_name ORDER BY g.group_name", tenant.From(ctx))
+	if err != nil {
+		return groups, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var group models.GroupData
+		if err := rows.Scan(&group.ID, &group.Name, &group.Songs); err != nil {
+			return groups, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"test/internal/models"
+	"testing"
+)
+
+func TestSelectRolesQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT r.name, .* FROM roles r LEFT JOIN role_permissions p ON p.role = r.name GROUP BY r.name ORDER BY r.name").
+		WillReturnRows(pgxmock.NewRows([]string{"name", "permissions"}).
+			AddRow("auditor", []string{}).
+			AddRow("viewer", []string{"songs:read"}))
+	roles, err := database.SelectRolesQuery(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RoleData{{Name: "auditor", Permissions: []string{}}, {Name: "viewer", Permissions: []string{"songs:read"}}}, roles)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpsertRoleQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectBegin()
+	mockk.ExpectExec("INSERT INTO roles\\(name\\) values\\(\\$1\\) ON CONFLICT \\(name\\) DO NOTHING").
+		WithArgs("curator").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockk.ExpectExec("DELETE FROM role_permissions WHERE role = \\$1").
+		WithArgs("curator").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mockk.ExpectExec("INSERT INTO role_permissions\\(role, permission\\) SELECT \\$1, UNNEST\\(\\$2::text\\[\\]\\)").
+		WithArgs("curator", []string{"songs:read", "groups:manage"}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	mockk.ExpectCommit()
+	err = database.UpsertRoleQuery(context.Background(), "curator", []string{"songs:read", "groups:manage"})
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAssignRoleQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM roles WHERE name = \\$1\\)").
+		WithArgs("editor").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mockk.ExpectExec("UPDATE users SET role = \\$2 WHERE name = \\$1 AND tenant = \\$3").
+		WithArgs("alice", "editor", "default").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectQuery("SELECT EXISTS").
+		WithArgs("editor").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mockk.ExpectExec("UPDATE users").
+		WithArgs("bob", "editor", "default").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockk.ExpectQuery("SELECT EXISTS").
+		WithArgs("owner").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	assert.NoError(t, database.AssignRoleQuery(context.Background(), "alice", "editor"))
+	assert.Equal(t, ErrUserNotFound, database.AssignRoleQuery(context.Background(), "bob", "editor"))
+	assert.Equal(t, ErrRoleNotFound, database.AssignRoleQuery(context.Background(), "alice", "owner"))
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteGroupQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectExec("DELETE FROM groups WHERE group_name = \\$1 AND tenant = \\$2").
+		WithArgs("Muse", "default").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockk.ExpectExec("DELETE FROM groups").
+		WithArgs("Nobody", "default").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	assert.NoError(t, database.DeleteGroupQuery(context.Background(), "Muse"))
+	assert.Equal(t, ErrGroupNotFound, database.DeleteGroupQuery(context.Background(), "Nobody"))
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRenameGroupQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectExec("UPDATE groups SET group_name = \\$2 WHERE group_name = \\$1 AND tenant = \\$3").
+		WithArgs("Muse", "MUSE", "default").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockk.ExpectExec("UPDATE groups").
+		WithArgs("Muse", "Queen", "default").
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+	mockk.ExpectExec("UPDATE groups").
+		WithArgs("Nobody", "Queen", "default").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	assert.NoError(t, database.RenameGroupQuery(context.Background(), "Muse", "MUSE"))
+	assert.Equal(t, ErrGroupExists, database.RenameGroupQuery(context.Background(), "Muse", "Queen"))
+	assert.Equal(t, ErrGroupNotFound, database.RenameGroupQuery(context.Background(), "Nobody", "Queen"))
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectGroupsQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT g.id, g.group_name, COUNT\\(s.id\\) FROM groups g LEFT JOIN songs s ON s.group_id = g.id WHERE g.tenant = \\$1 GROUP BY g.id, g.group_name ORDER BY g.group_name").
+		WithArgs("default").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "group_name", "count"}).
+			AddRow(1, "Muse", int64(12)).
+			AddRow(2, "Queen", int64(0)))
+	groups, err := database.SelectGroupsQuery(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.GroupData{{ID: 1, Name: "Muse", Songs: 12}, {ID: 2, Name: "Queen", Songs: 0}}, groups)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"test/internal/tenant"
+)
+
+// TenantSetting is the setting of the connection the row level security policies compare the
+// tenant of the rows with.
+const TenantSetting = "app.tenant"
+
+// tenantTables are the tables with a tenant column that the policies apply to. The lines, chords
+// and lyrics are only reached through the songs. The users are left out, their keys are looked up
+// before the tenant of the request is known.
+var tenantTables = []string{"groups", "songs", "playlists"}
+
+// RowSecurityQuery enforces the tenants with row level security on top of the scoped queries, or
+// stops enforcing them. The policies apply to the owner of the tables as well, so SetTenant has
+// to run on every connection before it is used.
+func (db *PGXDatabase) RowSecurityQuery(ctx context.Context, enforce bool) error {
+	for _, table := range tenantTables {
+		statements := []string{
+			"ALTER TABLE " + table + " NO FORCE ROW LEVEL SECURITY;",
+			"ALTER TABLE " + table + " DISABLE ROW LEVEL SECURITY;",
+		}
+		if enforce {
+			statements = []string{
+				"DROP POLICY IF EXISTS tenant_isolation ON " + table + ";",
+				"CREATE POLICY tenant_isolation ON " + table + " USING (tenant = current_setting('" + TenantSetting + "', true)) WITH CHECK (tenant = current_setting('" + TenantSetting + "', true));",
+				"ALTER TABLE " + table + " ENABLE ROW LEVEL SECURITY;",
+				"ALTER TABLE " + table + " FORCE ROW LEVEL SECURITY;",
+			}
+		}
+		for _, statement := range statements {
+			if _, err := db.pool.Exec(ctx, statement); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetTenant sets the tenant of the context on the connection acquired for it, it is meant for
+// the BeforeAcquire hook of the pool. The connection is not used when it fails.
+func SetTenant(ctx context.Context, conn *pgx.Conn) bool {
+	_, err := conn.Exec(ctx, "SELECT set_config($1, $2, false)", TenantSetting, tenant.From(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set the tenant of the connection", "error", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"test/internal/tenant"
+	"testing"
+)
+
+func TestRowSecurityQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	for _, table := range []string{"groups", "songs", "playlists"} {
+		mockk.ExpectExec("DROP POLICY IF EXISTS tenant_isolation ON " + table).WillReturnResult(pgxmock.NewResult("DROP", 0))
+		mockk.ExpectExec("CREATE POLICY tenant_isolation ON " + table + " USING \\(tenant = current_setting\\('app.tenant', true\\)\\)").WillReturnResult(pgxmock.NewResult("CREATE", 0))
+		mockk.ExpectExec("ALTER TABLE " + table + " ENABLE ROW LEVEL SECURITY").WillReturnResult(pgxmock.NewResult("ALTER", 0))
+		mockk.ExpectExec("ALTER TABLE " + table + " FORCE ROW LEVEL SECURITY").WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	}
+	err = database.RowSecurityQuery(context.Background(), true)
+	assert.NoError(t, err)
+	for _, table := range []string{"groups", "songs", "playlists"} {
+		mockk.ExpectExec("ALTER TABLE " + table + " NO FORCE ROW LEVEL SECURITY").WillReturnResult(pgxmock.NewResult("ALTER", 0))
+		mockk.ExpectExec("ALTER TABLE " + table + " DISABLE ROW LEVEL SECURITY").WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	}
+	err = database.RowSecurityQuery(context.Background(), false)
+	assert.NoError(t, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteGroupQuery_Tenant(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectExec("DELETE FROM groups WHERE group_name = \\$1 AND tenant = \\$2").
+		WithArgs("Muse", "acme").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	err = database.DeleteGroupQuery(tenant.With(context.Background(), "acme"), "Muse")
+	assert.Equal(t, ErrGroupNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"test/internal/models"
+	"test/internal/tenant"
+)
+
+var (
+	ErrUserExists   = errors.New("The user already exists")
+	ErrUserNotFound = errors.New("The user is not found")
+	ErrKeyNotFound  = errors.New("The API key is not found or revoked")
+)
+
+// InsertUserQuery adds the user with the role, which has to exist, to the tenant. The names of
+// the users are unique across the tenants.
+func (db *PGXDatabase) InsertUserQuery(ctx context.Context, name string, role string) (int, error) {
+	var id int
+	if err := db.roleExists(ctx, role); err != nil {
+		return 0, err
+	}
+	err := db.pool.QueryRow(ctx, "INSERT INTO users(name, role, tenant) values($1, $2, $3) ON CONFLICT (name) DO NOTHING RETURNING id", name, role, tenant.From(ctx)).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return 0, ErrUserExists
+	}
+	return id, err
+}
+
+// InsertKeyQuery stores the prefix and hash of a new API key of the user of the tenant.
+func (db *PGXDatabase) InsertKeyQuery(ctx context.Context, user string, prefix string, hash string) (models.APIKeyData, error) {
+	key := models.APIKeyData{User: user, Prefix: prefix}
+	err := db.pool.QueryRow(ctx, "INSERT INTO api_keys(user_id, prefix, key_hash) SELECT id, $2, $3 FROM users WHERE name = $1 AND tenant = $4 RETURNING id, created_at", user, prefix, hash, tenant.From(ctx)).Scan(&key.ID, &key.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return key, ErrUserNotFound
+	}
+	return key, err
+}
+
+// RotateKeyQuery revokes the key and stores a new one for its user in the same transaction. The
+// key has to belong to the user, of any user of the tenant without one.
+func (db *PGXDatabase) RotateKeyQuery(ctx context.Context, id int, user string, prefix string, hash string) (models.APIKeyData, error) {
+	key := models.APIKeyData{Prefix: prefix}
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return key, err
+	}
+	defer tx.Rollback(ctx)
+	var userID int
+	err = tx.QueryRow(ctx, "UPDATE api_keys k SET revoked_at = now() FROM users u WHERE k.user_id = u.id AND k.id = $1 AND k.revoked_at IS NULL AND ($2 = '' OR u.name = $2) AND u.tenant = $3 RETURNING u.id, u.name", id, user, tenant.From(ctx)).Scan(&userID, &key.User)
+	if err == pgx.ErrNoRows {
+		return key, ErrKeyNotFound
+	}
+	if err != nil {
+		return key, err
+	}
+	err = tx.QueryRow(ctx, "INSERT INTO api_keys(user_id, prefix, key_hash) values($1, $2, $3) RETURNING id, created_at", userID, prefix, hash).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return key, err
+	}
+	return key, tx.Commit(ctx)
+}
+
+// RevokeKeyQuery revokes the key, which has to belong to the user, of any user of the tenant
+// without one.
+func (db *PGXDatabase) RevokeKeyQuery(ctx context.Context, id int, user string) error {
+	tag, err := db.pool.Exec(ctx, "UPDATE api_keys k SET revoked_at = now() FROM users u WHERE k.user_id = u.id AND k.id = $1 AND k.revoked_at IS NULL AND ($2 = '' OR u.name = $2) AND u.tenant = $3", id, user, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// SelectKeysQuery selects the keys of the user with the revoked ones, the keys of every user of
+// the tenant without one.
+func (db *PGXDatabase) SelectKeysQuery(ctx context.Context, user string) ([]models.APIKeyData, error) {
+	var keys []models.APIKeyData
+	rows, err := db.pool.Query(ctx, "SELECT k.id, u.name, k.prefix, k.created_at, k.revoked_at FROM api_keys k JOIN users u ON k.user_id = u.id WHERE ($1 = '' OR u.name = $1) AND u.tenant = $2 ORDER BY k.id", user, tenant.From(ctx))
+	if err != nil {
+		return keys, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key models.APIKeyData
+		if err := rows.Scan(&key.ID, &key.User, &key.Prefix, &key.CreatedAt, &key.RevokedAt); err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// SelectPrincipalQuery selects the user of the key with the hash, its tenant and the permissions
+// of its role, revoked keys are not found.
+func (db *PGXDatabase) SelectPrincipalQuery(ctx context.Context, hash string) (models.PrincipalData, error) {
+	var principal models.PrincipalData
+	err := db.pool.QueryRow(ctx, "SELECT u.id, u.name, u.role, u.tenant, k.id, COALESCE(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}') FROM api_keys k JOIN users u ON k.user_id = u.id LEFT JOIN role_permissions p ON p.role = u.role WHERE k.key_hash = $1 AND k.revoked_at IS NULL GROUP BY u.id, k.id", hash).Scan(&principal.UserID, &principal.User, &principal.Role, &principal.Tenant, &principal.KeyID, &principal.Permissions)
+	if err == pgx.ErrNoRows {
+		return principal, ErrKeyNotFound
+	}
+	return principal, err
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"test/internal/models"
+	"testing"
+	"time"
+)
+
+func TestInsertUserQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM roles WHERE name = \\$1\\)").
+		WithArgs("admin").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mockk.ExpectQuery("INSERT INTO users\\(name, role, tenant\\) values\\(\\$1, \\$2, \\$3\\) ON CONFLICT \\(name\\) DO NOTHING RETURNING id").
+		WithArgs("alice", "admin", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mockk.ExpectQuery("SELECT EXISTS").
+		WithArgs("editor").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mockk.ExpectQuery("INSERT INTO users").
+		WithArgs("alice", "editor", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	mockk.ExpectQuery("SELECT EXISTS").
+		WithArgs("owner").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	id, err := database.InsertUserQuery(context.Background(), "alice", "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+	_, err = database.InsertUserQuery(context.Background(), "alice", "editor")
+	assert.Equal(t, ErrUserExists, err)
+	_, err = database.InsertUserQuery(context.Background(), "bob", "owner")
+	assert.Equal(t, ErrRoleNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertKeyQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	created := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	mockk.ExpectQuery("INSERT INTO api_keys\\(user_id, prefix, key_hash\\) SELECT id, \\$2, \\$3 FROM users WHERE name = \\$1 AND tenant = \\$4").
+		WithArgs("alice", "9f86d081", "hash", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(3, created))
+	mockk.ExpectQuery("INSERT INTO api_keys").
+		WithArgs("bob", "9f86d081", "hash", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}))
+	key, err := database.InsertKeyQuery(context.Background(), "alice", "9f86d081", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, models.APIKeyData{ID: 3, User: "alice", Prefix: "9f86d081", CreatedAt: created}, key)
+	_, err = database.InsertKeyQuery(context.Background(), "bob", "9f86d081", "hash")
+	assert.Equal(t, ErrUserNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRotateKeyQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	created := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	mockk.ExpectBegin()
+	mockk.ExpectQuery("UPDATE api_keys k SET revoked_at = now\\(\\) FROM users u").
+		WithArgs(3, "alice", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+	mockk.ExpectQuery("INSERT INTO api_keys\\(user_id, prefix, key_hash\\) values\\(\\$1, \\$2, \\$3\\)").
+		WithArgs(1, "0a1b2c3d", "hash").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(4, created))
+	mockk.ExpectCommit()
+	key, err := database.RotateKeyQuery(context.Background(), 3, "alice", "0a1b2c3d", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, models.APIKeyData{ID: 4, User: "alice", Prefix: "0a1b2c3d", CreatedAt: created}, key)
+	mockk.ExpectBegin()
+	mockk.ExpectQuery("UPDATE api_keys k SET revoked_at = now\\(\\)").
+		WithArgs(3, "bob", "default").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name"}))
+	mockk.ExpectRollback()
+	_, err = database.RotateKeyQuery(context.Background(), 3, "bob", "0a1b2c3d", "hash")
+	assert.Equal(t, ErrKeyNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRevokeKeyQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectExec("UPDATE api_keys k SET revoked_at = now\\(\\) FROM users u .* AND k.revoked_at IS NULL").
+		WithArgs(3, "", "default").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	err = database.RevokeKeyQuery(context.Background(), 3, "")
+	assert.Equal(t, ErrKeyNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSelectPrincipalQuery(t *testing.T) {
+	mockk, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := NewPGXDatabase(mockk)
+	defer mockk.Close()
+	mockk.ExpectQuery("SELECT u.id, u.name, u.role, u.tenant, k.id, .* FROM api_keys k .* LEFT JOIN role_permissions p ON p.role = u.role WHERE k.key_hash = \\$1 AND k.revoked_at IS NULL").
+		WithArgs("hash").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "role", "tenant", "id", "permissions"}).AddRow(1, "alice", "viewer", "acme", 3, []string{"songs:read"}))
+	mockk.ExpectQuery("SELECT u.id, u.name, u.role, u.tenant, k.id, .* FROM api_keys k").
+		WithArgs("revoked").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "role", "tenant", "id", "permissions"}))
+	principal, err := database.SelectPrincipalQuery(context.Background(), "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, models.PrincipalData{UserID: 1, User: "alice", Role: "viewer", Tenant: "acme", KeyID: 3, Permissions: []string{"songs:read"}}, principal)
+	_, err = database.SelectPrincipalQuery(context.Background(), "revoked")
+	assert.Equal(t, ErrKeyNotFound, err)
+	if err := mockk.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
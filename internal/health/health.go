@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"test/internal/breaker"
+	"test/internal/models"
+	"time"
+)
+
+// The states of the server and of its dependencies.
+const (
+	OK   = "ok"
+	Fail = "fail"
+)
+
+// Check reports whether a dependency is ready, the detail describes its state.
+type Check func(ctx context.Context) (detail string, err error)
+
+// Checker runs the readiness checks of the dependencies.
+type Checker struct {
+	// Timeout bounds every check, a check still running then fails.
+	Timeout time.Duration
+	Checks  map[string]Check
+}
+
+// Ready runs the checks concurrently and returns the outcome of each, the server is ready when
+// all of them pass.
+func (c *Checker) Ready(ctx context.Context) (models.HealthData, bool) {
+	result := models.HealthData{Status: OK, Checks: map[string]models.HealthCheckData{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range c.Checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data := run(ctx, c.Timeout, check)
+			mu.Lock()
+			defer mu.Unlock()
+			result.Checks[name] = data
+			if data.Status != OK {
+				result.Status = Fail
+			}
+		}()
+	}
+	wg.Wait()
+	return result, result.Status == OK
+}
+
+// run runs the check within the timeout, the check is given up on when it ignores its context.
+func run(ctx context.Context, timeout time.Duration, check Check) models.HealthCheckData {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	type outcome struct {
+		detail string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		detail, err := check(ctx)
+		done <- outcome{detail, err}
+	}()
+	var result outcome
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result.err = ctx.Err()
+	}
+	data := models.HealthCheckData{Status: OK, Duration: time.Since(start).String(), Detail: result.detail}
+	if result.err != nil {
+		data.Status, data.Error = Fail, result.err.Error()
+	}
+	return data
+}
+
+// Pinger is the database, *database.PGXDatabase.
+type Pinger interface {
+	PingQuery(ctx context.Context) error
+	SchemaVersionQuery(ctx context.Context) (int, error)
+}
+
+// Database checks that the database answers.
+func Database(db Pinger) Check {
+	return func(ctx context.Context) (string, error) {
+		return "", db.PingQuery(ctx)
+	}
+}
+
+// Migrations checks that the tables are migrated to the version or a later one.
+func Migrations(db Pinger, version int) Check {
+	return func(ctx context.Context) (string, error) {
+		current, err := db.SchemaVersionQuery(ctx)
+		if err != nil {
+			return "", err
+		}
+		detail := fmt.Sprintf("schema version %d", current)
+		if current < version {
+			return detail, fmt.Errorf("The tables have schema version %d, expected %d", current, version)
+		}
+		return detail, nil
+	}
+}
+
+// Circuit checks that the circuit of the breaker is not open.
+func Circuit(b *breaker.Breaker) Check {
+	return func(ctx context.Context) (string, error) {
+		state, until := b.State()
+		if state == breaker.Open {
+			return state, fmt.Errorf("The circuit is open until %s", until.UTC().Format(time.RFC3339))
+		}
+		return state, nil
+	}
+}
@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"test/internal/breaker"
+	"test/internal/models"
+	"testing"
+	"time"
+)
+
+// database answers with the version, or fails with err.
+type database struct {
+	version int
+	err     error
+}
+
+func (db *database) PingQuery(ctx context.Context) error {
+	return db.err
+}
+
+func (db *database) SchemaVersionQuery(ctx context.Context) (int, error) {
+	return db.version, db.err
+}
+
+func TestChecker(t *testing.T) {
+	db := &database{version: 3}
+	circuit := breaker.New(1, time.Minute, func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) })
+	checker := &Checker{Timeout: 50 * time.Millisecond, Checks: map[string]Check{
+		"database":   Database(db),
+		"migrations": Migrations(db, 3),
+		"metadata":   Circuit(circuit),
+	}}
+	result, ready := checker.Ready(context.Background())
+	assert.True(t, ready)
+	assert.Equal(t, OK, result.Status)
+	assert.Equal(t, "schema version 3", result.Checks["migrations"].Detail)
+	assert.Equal(t, breaker.Closed, result.Checks["metadata"].Detail)
+
+	db.version = 2
+	circuit.Allow()
+	circuit.Done(false)
+	result, ready = checker.Ready(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, Fail, result.Status)
+	assert.Equal(t, OK, result.Checks["database"].Status)
+	assert.Equal(t, "The tables have schema version 2, expected 3", result.Checks["migrations"].Error)
+	assert.Equal(t, "The circuit is open until 2024-05-01T12:01:00Z", result.Checks["metadata"].Error)
+
+	db.err = errors.New("connection refused")
+	result, _ = checker.Ready(context.Background())
+	assert.Equal(t, models.HealthCheckData{Status: Fail, Duration: result.Checks["database"].Duration, Error: "connection refused"}, result.Checks["database"])
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := &Checker{Timeout: 10 * time.Millisecond, Checks: map[string]Check{
+		"stuck": func(ctx context.Context) (string, error) {
+			time.Sleep(time.Second)
+			return "", nil
+		},
+	}}
+	start := time.Now()
+	result, ready := checker.Ready(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, "context deadline exceeded", result.Checks["stuck"].Error)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
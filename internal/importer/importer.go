@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"test/internal/models"
+	"time"
+)
+
+// Formats are the formats of the files songs are imported from.
+var Formats = []string{"csv", "json", "ndjson"}
+
+// columns are the columns of the CSV files, in the form of the JSON keys of the songs.
+var columns = []string{"group", "song", "releaseDate", "text", "link"}
+
+// maxLine is the longest line of an NDJSON file, a song with its text.
+const maxLine = 1 << 20
+
+// Parse reads the songs of the file in the format, a CSV file starts with a header naming the
+// columns. Every row of the file is returned with its number, rows that can not be read or miss
+// the group or the song are marked invalid. An error is returned when the file itself can not be
+// read any further.
+func Parse(r io.Reader, format string) ([]models.ImportRowData, error) {
+	switch format {
+	case "csv":
+		return parseCSV(r)
+	case "json":
+		return parseJSON(r)
+	case "ndjson":
+		return parseNDJSON(r)
+	}
+	return nil, fmt.Errorf("Unknown format %q, expected csv, json or ndjson", format)
+}
+
+func parseCSV(r io.Reader) ([]models.ImportRowData, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CSV header: %w", err)
+	}
+	positions := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if !slices.Contains(columns, name) {
+			return nil, fmt.Errorf("Unknown CSV column %q, expected %s", name, strings.Join(columns, ", "))
+		}
+		positions[name] = i
+	}
+	var rows []models.ImportRowData
+	for number := 1; ; number++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return rows, err
+		}
+		if err != nil {
+			rows = append(rows, invalid(number, err))
+			continue
+		}
+		if len(record) != len(header) {
+			rows = append(rows, invalid(number, fmt.Errorf("expected %d columns, got %d", len(header), len(record))))
+			continue
+		}
+		value := func(name string) string {
+			if i, found := positions[name]; found {
+				return record[i]
+			}
+			return ""
+		}
+		rows = append(rows, validate(models.ImportRowData{
+			Row:   number,
+			Group: value("group"),
+			Song:  value("song"),
+			Date:  value("releaseDate"),
+			Text:  value("text"),
+			Link:  value("link"),
+		}))
+	}
+}
+
+func parseJSON(r io.Reader) ([]models.ImportRowData, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil || token != json.Delim('[') {
+		return nil, fmt.Errorf("Invalid JSON: expected an array of songs")
+	}
+	var rows []models.ImportRowData
+	for number := 1; decoder.More(); number++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return rows, fmt.Errorf("Invalid JSON at song %d: %w", number, err)
+		}
+		rows = append(rows, parseSong(number, raw))
+	}
+	if _, err := decoder.Token(); err != nil {
+		return rows, fmt.Errorf("Invalid JSON: %w", err)
+	}
+	return rows, nil
+}
+
+func parseNDJSON(r io.Reader) ([]models.ImportRowData, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLine)
+	var rows []models.ImportRowData
+	for number := 1; scanner.Scan(); number++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		rows = append(rows, parseSong(number, line))
+	}
+	if err := scanner.Err(); err != nil {
+		return rows, fmt.Errorf("Invalid NDJSON: %w", err)
+	}
+	return rows, nil
+}
+
+// parseSong reads the song from a JSON object with the keys of the songs of /getdata.
+func parseSong(number int, data []byte) models.ImportRowData {
+	var song models.RowDbData
+	if err := json.Unmarshal(data, &song); err != nil {
+		return invalid(number, err)
+	}
+	return validate(models.ImportRowData{
+		Row:   number,
+		Group: song.Group,
+		Song:  song.Song,
+		Date:  song.Date,
+		Text:  song.Text,
+		Link:  song.Link,
+	})
+}
+
+// validate marks the row invalid when it misses the group or the song or has a release date
+// not in the DD.MM.YYYY form.
+func validate(row models.ImportRowData) models.ImportRowData {
+	row.Group = strings.TrimSpace(row.Group)
+	row.Song = strings.TrimSpace(row.Song)
+	row.Date = strings.TrimSpace(row.Date)
+	switch {
+	case row.Group == "":
+		row.Status, row.Error = models.ImportInvalid, "The group is required"
+	case row.Song == "":
+		row.Status, row.Error = models.ImportInvalid, "The song is required"
+	case row.Date != "":
+		if _, err := time.Parse("02.01.2006", row.Date); err != nil {
+			row.Status, row.Error = models.ImportInvalid, fmt.Sprintf("Invalid release date %q, expected DD.MM.YYYY", row.Date)
+		}
+	}
+	return row
+}
+
+func invalid(number int, err error) models.ImportRowData {
+	return models.ImportRowData{Row: number, Status: models.ImportInvalid, Error: err.Error()}
+}
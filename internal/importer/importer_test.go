@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"test/internal/models"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	file := "\ufeffgroup,song,releaseDate,link\n" +
+		"Muse,Uprising,07.09.2009,https://www.youtube.com/watch?v=w8KQmps-Sog\n" +
+		" Muse ,\"Starlight, live\",,\n" +
+		",Hysteria,,\n" +
+		"Muse,Resistance,2009-09-14,\n" +
+		"Muse,Undisclosed Desires\n"
+	rows, err := Parse(strings.NewReader(file), "csv")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ImportRowData{
+		{Row: 1, Group: "Muse", Song: "Uprising", Date: "07.09.2009", Link: "https://www.youtube.com/watch?v=w8KQmps-Sog"},
+		{Row: 2, Group: "Muse", Song: "Starlight, live"},
+		{Row: 3, Song: "Hysteria", Status: models.ImportInvalid, Error: "The group is required"},
+		{Row: 4, Group: "Muse", Song: "Resistance", Date: "2009-09-14", Status: models.ImportInvalid, Error: "Invalid release date \"2009-09-14\", expected DD.MM.YYYY"},
+		{Row: 5, Status: models.ImportInvalid, Error: "expected 4 columns, got 2"},
+	}, rows)
+	_, err = Parse(strings.NewReader("group,title\n"), "csv")
+	assert.EqualError(t, err, "Unknown CSV column \"title\", expected group, song, releaseDate, text, link")
+}
+
+func TestParseJSON(t *testing.T) {
+	rows, err := Parse(strings.NewReader(`[{"group": "Muse", "song": "Uprising", "text": "Paranoia is in bloom"}, {"group": 1}]`), "json")
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportRowData{Row: 1, Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"}, rows[0])
+	assert.Equal(t, 2, rows[1].Row)
+	assert.Equal(t, models.ImportInvalid, rows[1].Status)
+	_, err = Parse(strings.NewReader(`{"group": "Muse"}`), "json")
+	assert.EqualError(t, err, "Invalid JSON: expected an array of songs")
+	_, err = Parse(strings.NewReader(`[{"group": "Muse"`), "json")
+	assert.Error(t, err)
+}
+
+func TestParseNDJSON(t *testing.T) {
+	rows, err := Parse(strings.NewReader("{\"group\": \"Muse\", \"song\": \"Uprising\"}\n\n{\"group\": \"Muse\"\n{\"group\": \"Muse\"}\n"), "ndjson")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 3, 4}, []int{rows[0].Row, rows[1].Row, rows[2].Row})
+	assert.Equal(t, "", rows[0].Status)
+	assert.Equal(t, models.ImportInvalid, rows[1].Status)
+	assert.Equal(t, "The song is required", rows[2].Error)
+	_, err = Parse(strings.NewReader(""), "xml")
+	assert.EqualError(t, err, "Unknown format \"xml\", expected csv, json or ndjson")
+}
@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"test/internal/models"
+)
+
+// Writer writes songs in a format they can be imported from, with the columns of the CSV files
+// and the same keys in JSON.
+type Writer struct {
+	w      io.Writer
+	format string
+	csv    *csv.Writer
+	count  int
+}
+
+// NewWriter returns the writer of the songs in the format, the header of a CSV file is written
+// right away.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	writer := &Writer{w: w, format: format}
+	switch format {
+	case "csv":
+		writer.csv = csv.NewWriter(w)
+		return writer, writer.csv.Write(columns)
+	case "json", "ndjson":
+		return writer, nil
+	}
+	return nil, fmt.Errorf("Unknown format %q, expected csv, json or ndjson", format)
+}
+
+// Write writes the song.
+func (w *Writer) Write(song models.RowDbData) error {
+	w.count++
+	if w.csv != nil {
+		return w.csv.Write([]string{song.Group, song.Song, song.Date, song.Text, song.Link})
+	}
+	song.Fields, song.GroupInfo = columns, nil
+	data, err := json.Marshal(song)
+	if err != nil {
+		return err
+	}
+	prefix := ""
+	if w.format == "json" {
+		prefix = ",\n"
+		if w.count == 1 {
+			prefix = "[\n"
+		}
+	}
+	_, err = w.w.Write(append([]byte(prefix), append(data, '\n')...))
+	return err
+}
+
+// Count returns the number of songs written.
+func (w *Writer) Count() int {
+	return w.count
+}
+
+// Close ends the file, it does not close the underlying writer.
+func (w *Writer) Close() error {
+	switch {
+	case w.csv != nil:
+		w.csv.Flush()
+		return w.csv.Error()
+	case w.format == "json" && w.count == 0:
+		_, err := io.WriteString(w.w, "[]\n")
+		return err
+	case w.format == "json":
+		_, err := io.WriteString(w.w, "]\n")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package importer
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"test/internal/models"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	songs := []models.RowDbData{
+		{Group: "Muse", Song: "Uprising", Date: "07.09.2009", Text: "Paranoia is in bloom", Lang: "en", LangConfidence: 0.9},
+		{Group: "Muse", Song: "Starlight, live", GroupInfo: &models.GroupData{ID: 1, Name: "Muse"}},
+	}
+	expected := map[string]string{
+		"csv": "group,song,releaseDate,text,link\nMuse,Uprising,07.09.2009,Paranoia is in bloom,\nMuse,\"Starlight, live\",,,\n",
+		"ndjson": `{"group":"Muse","song":"Uprising","releaseDate":"07.09.2009","text":"Paranoia is in bloom","link":""}` + "\n" +
+			`{"group":"Muse","song":"Starlight, live","releaseDate":"","text":"","link":""}` + "\n",
+		"json": "[\n" + `{"group":"Muse","song":"Uprising","releaseDate":"07.09.2009","text":"Paranoia is in bloom","link":""}` + "\n,\n" +
+			`{"group":"Muse","song":"Starlight, live","releaseDate":"","text":"","link":""}` + "\n]\n",
+	}
+	for _, format := range Formats {
+		var out bytes.Buffer
+		writer, err := NewWriter(&out, format)
+		assert.NoError(t, err)
+		for _, song := range songs {
+			assert.NoError(t, writer.Write(song))
+		}
+		assert.NoError(t, writer.Close())
+		assert.Equal(t, expected[format], out.String(), format)
+		assert.Equal(t, 2, writer.Count())
+		// the written songs are imported back
+		rows, err := Parse(&out, format)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.ImportRowData{
+			{Row: 1, Group: "Muse", Song: "Uprising", Date: "07.09.2009", Text: "Paranoia is in bloom"},
+			{Row: 2, Group: "Muse", Song: "Starlight, live"},
+		}, rows, format)
+	}
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWriter(&out, "json")
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, "[]\n", out.String())
+	_, err = NewWriter(&out, "xml")
+	assert.EqualError(t, err, "Unknown format \"xml\", expected csv, json or ndjson")
+}
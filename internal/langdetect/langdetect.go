@@ -0,0 +1,162 @@
+package langdetect
+
+import (
+	"embed"
+	"math"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// MinLetters is the minimal number of letters in the text to detect its language.
+const MinLetters = 10
+
+//go:embed profiles/*.txt
+var corpora embed.FS
+
+// scripts are the languages identified by their writing system alone.
+var scripts = []struct {
+	lang  string
+	table *unicode.RangeTable
+}{
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"ko", unicode.Hangul},
+	{"zh", unicode.Han},
+	{"ar", unicode.Arabic},
+	{"he", unicode.Hebrew},
+	{"el", unicode.Greek},
+	{"hi", unicode.Devanagari},
+	{"th", unicode.Thai},
+}
+
+// exclusive are the letters of a single language among the ones sharing the script, they
+// rule out the other languages of the script when the text has them.
+var exclusive = map[rune]string{
+	'ы': "ru", 'э': "ru", 'ъ': "ru", 'ё': "ru",
+	'і': "uk", 'ї': "uk", 'є': "uk", 'ґ': "uk",
+}
+
+type profile struct {
+	lang     string
+	logProbs map[string]float64
+	unknown  float64
+}
+
+var profiles = loadProfiles()
+
+// loadProfiles builds the trigram model of every language from the corpora bundled with the binary.
+func loadProfiles() []profile {
+	entries, err := corpora.ReadDir("profiles")
+	if err != nil {
+		panic(err)
+	}
+	var result []profile
+	for _, entry := range entries {
+		data, err := corpora.ReadFile(path.Join("profiles", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		counts := trigrams(string(data))
+		total := 0
+		for _, count := range counts {
+			total += count
+		}
+		// add-one smoothing, unseen trigrams share a single extra count
+		p := profile{
+			lang:     strings.TrimSuffix(entry.Name(), ".txt"),
+			logProbs: make(map[string]float64, len(counts)),
+			unknown:  math.Log(1 / float64(total+len(counts)+1)),
+		}
+		for key, count := range counts {
+			p.logProbs[key] = math.Log(float64(count+1) / float64(total+len(counts)+1))
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// trigrams counts the letter trigrams of the words of the text padded with spaces.
+func trigrams(text string) map[string]int {
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+	return counts
+}
+
+// Detect returns the language of the text as a BCP 47 tag with the confidence in [0, 1]. The
+// language is empty when the text is too short or has no letters.
+func Detect(text string) (string, float64) {
+	letters := 0
+	byScript := make(map[string]int)
+	marked := make(map[string]bool)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if lang, found := exclusive[unicode.ToLower(r)]; found {
+			marked[lang] = true
+		}
+		for _, script := range scripts {
+			if unicode.Is(script.table, r) {
+				byScript[script.lang]++
+				break
+			}
+		}
+	}
+	if letters < MinLetters {
+		return "", 0
+	}
+	// kana is mixed with han in japanese, so any amount of it decides
+	if byScript["ja"] > 0 && byScript["ja"]+byScript["zh"] > letters/2 {
+		return "ja", float64(byScript["ja"]+byScript["zh"]) / float64(letters)
+	}
+	for _, script := range scripts {
+		if byScript[script.lang] > letters/2 {
+			return script.lang, float64(byScript[script.lang]) / float64(letters)
+		}
+	}
+	counts := trigrams(text)
+	scores := make([]float64, len(profiles))
+	best := -1
+	for i, p := range profiles {
+		if len(marked) == 1 && excluded(p.lang, marked) {
+			scores[i] = math.Inf(-1)
+			continue
+		}
+		for key, count := range counts {
+			logProb, found := p.logProbs[key]
+			if !found {
+				logProb = p.unknown
+			}
+			scores[i] += float64(count) * logProb
+		}
+		if best < 0 || scores[i] > scores[best] {
+			best = i
+		}
+	}
+	// the posterior of the best language with equal priors
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - scores[best])
+	}
+	return profiles[best].lang, 1 / sum
+}
+
+// excluded reports whether the language has exclusive letters but none of them are in the text.
+func excluded(lang string, marked map[string]bool) bool {
+	for _, other := range exclusive {
+		if other == lang {
+			return !marked[lang]
+		}
+	}
+	return false
+}
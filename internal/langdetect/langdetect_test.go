@@ -0,0 +1,36 @@
+package langdetect
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	samples := map[string]string{
+		"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?": "en",
+		"Группа крови на рукаве, мой порядковый номер на рукаве":              "ru",
+		"Ой у лузі червона калина похилилася":                                 "uk",
+		"Ich will deine Hand halten und mit dir tanzen":                       "de",
+		"Je ne regrette rien, ni le bien qu'on m'a fait":                      "fr",
+		"Despacito, quiero respirar tu cuello despacito":                      "es",
+		"Nel blu dipinto di blu, felice di stare lassù":                       "it",
+		"Garota de Ipanema, olha que coisa mais linda":                        "pt",
+		"Sto lat, sto lat, niech żyje żyje nam":                               "pl",
+		"上を向いて歩こう 涙がこぼれないように":                                                 "ja",
+		"사랑해요 당신을 영원히 함께해요":                                                   "ko",
+	}
+	for text, expected := range samples {
+		lang, confidence := Detect(text)
+		assert.Equal(t, expected, lang, text)
+		assert.Greater(t, confidence, 0.5, text)
+		assert.LessOrEqual(t, confidence, 1.0, text)
+	}
+}
+
+func TestDetectShort(t *testing.T) {
+	lang, confidence := Detect("hello")
+	assert.Empty(t, lang)
+	assert.Zero(t, confidence)
+	lang, _ = Detect("1234567890 !!! 1234567890")
+	assert.Empty(t, lang)
+}
@@ -0,0 +1,141 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redacted are the attributes whose values are never logged.
+var Redacted = map[string]bool{
+	"key":           true,
+	"token":         true,
+	"authorization": true,
+	"password":      true,
+}
+
+// Truncated are the attributes holding lyrics and bodies, only their start is logged.
+var Truncated = map[string]bool{
+	"text":        true,
+	"translation": true,
+	"lyrics":      true,
+	"body":        true,
+	"source":      true,
+}
+
+const (
+	// truncatedLength is the length the lyrics and bodies are cut to.
+	truncatedLength = 64
+	// maxLength is the length any other string is cut to.
+	maxLength = 1024
+)
+
+// New returns a logger writing JSON lines at the level and above. The request attributes of the
+// context are added to every record, the sensitive attributes are redacted and the long ones
+// are truncated.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: redact})
+	return slog.New(&contextHandler{handler})
+}
+
+// ParseLevel parses debug, info, warn or error, the empty level is info.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("Invalid log level %q, expected debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	if Redacted[key] {
+		return slog.String(attr.Key, "[redacted]")
+	}
+	if attr.Value.Kind() != slog.KindString {
+		return attr
+	}
+	limit := maxLength
+	if Truncated[key] {
+		limit = truncatedLength
+	}
+	return slog.String(attr.Key, Truncate(attr.Value.String(), limit))
+}
+
+// Truncate cuts the value to the limit of bytes without splitting a character and tells how
+// much is left out.
+func Truncate(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s…(%d more bytes)", value[:cut], len(value)-cut)
+}
+
+// request holds the attributes of a request, the principal is set once it is known.
+type request struct {
+	id     string
+	route  string
+	user   string
+	tenant string
+}
+
+type requestKey struct{}
+
+// WithRequest returns the context of a request, the records logged with it carry the id and the
+// route of the request.
+func WithRequest(ctx context.Context, id string, route string) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{id: id, route: route})
+}
+
+// SetPrincipal adds the user and the tenant of the request to the records logged with its
+// context, the records of the middlewares that logged it before included.
+func SetPrincipal(ctx context.Context, user string, tenant string) {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		req.user, req.tenant = user, tenant
+	}
+}
+
+// RequestID returns the id of the request of the context.
+func RequestID(ctx context.Context) string {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		return req.id
+	}
+	return ""
+}
+
+// contextHandler adds the attributes of the request and the trace of the context to the records.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		record.AddAttrs(slog.String("request_id", req.id), slog.String("route", req.route))
+		if req.user != "" {
+			record.AddAttrs(slog.String("user", req.user), slog.String("tenant", req.tenant))
+		}
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
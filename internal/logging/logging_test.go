@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, slog.LevelInfo)
+	ctx := WithRequest(context.Background(), "4bf92f35", "/editsong")
+	SetPrincipal(ctx, "alice", "acme")
+	logger.DebugContext(ctx, "Request body")
+	logger.InfoContext(ctx, "Request data", "group", "Muse", "text", strings.Repeat("Paranoia is in bloom ", 10), "key", "sl_9f86d081_secret")
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "Request data", record["msg"])
+	assert.Equal(t, "Muse", record["group"])
+	assert.Equal(t, "Paranoia is in bloom Paranoia is in bloom Paranoia is in bloom P…(146 more bytes)", record["text"])
+	assert.Equal(t, "[redacted]", record["key"])
+	assert.Equal(t, "4bf92f35", record["request_id"])
+	assert.Equal(t, "/editsong", record["route"])
+	assert.Equal(t, "alice", record["user"])
+	assert.Equal(t, "acme", record["tenant"])
+	assert.NotContains(t, record, "trace_id")
+
+	out.Reset()
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	logger.InfoContext(ctx, "Added song")
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", record["span_id"])
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", Truncate("short", 10))
+	assert.Equal(t, "Пара…(16 more bytes)", Truncate("Паранойя расцветает"[:24], 9))
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelInfo, level)
+	level, err = ParseLevel("debug")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level)
+	_, err = ParseLevel("verbose")
+	assert.EqualError(t, err, `Invalid log level "verbose", expected debug, info, warn or error`)
+}
@@ -0,0 +1,214 @@
+package lrc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Word is a word of the enhanced LRC format with the time it starts, in milliseconds.
+type Word struct {
+	Time int64
+	Text string
+}
+
+// Line is a lyric line with the time it starts, in milliseconds.
+type Line struct {
+	Time  int64
+	Text  string
+	Words []Word
+}
+
+type Tag struct {
+	Key   string
+	Value string
+}
+
+type Lyrics struct {
+	Tags  []Tag
+	Lines []Line
+}
+
+// Parse reads LRC lyrics. A line may carry several timestamps, in which case it is repeated
+// at every one of them, and word-level <mm:ss.xx> tags of the enhanced format are kept in
+// Words. The [offset:] tag is applied to all the times, the lines are sorted by time.
+func Parse(text string) (Lyrics, error) {
+	var lyrics Lyrics
+	var offset int64
+	for number, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		var times []int64
+		for strings.HasPrefix(raw, "[") {
+			end := strings.Index(raw, "]")
+			if end < 0 {
+				return lyrics, fmt.Errorf("line %d: unclosed tag", number+1)
+			}
+			tag := raw[1:end]
+			raw = strings.TrimSpace(raw[end+1:])
+			if time, err := ParseTime(tag); err == nil {
+				times = append(times, time)
+				continue
+			}
+			key, value, found := strings.Cut(tag, ":")
+			if !found || len(times) > 0 {
+				return lyrics, fmt.Errorf("line %d: invalid tag [%s]", number+1, tag)
+			}
+			key = strings.TrimSpace(key)
+			value = strings.TrimSpace(value)
+			if key == "offset" {
+				parsed, err := strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64)
+				if err != nil {
+					return lyrics, fmt.Errorf("line %d: invalid offset %q", number+1, value)
+				}
+				offset = parsed
+			}
+			lyrics.Tags = append(lyrics.Tags, Tag{Key: key, Value: value})
+		}
+		if len(times) == 0 {
+			continue
+		}
+		words, plain, err := parseWords(raw)
+		if err != nil {
+			return lyrics, fmt.Errorf("line %d: %w", number+1, err)
+		}
+		for _, time := range times {
+			line := Line{Time: time, Text: plain}
+			for _, word := range words {
+				if word.Time < 0 {
+					word.Time = time
+				}
+				line.Words = append(line.Words, word)
+			}
+			lyrics.Lines = append(lyrics.Lines, line)
+		}
+	}
+	if len(lyrics.Lines) == 0 {
+		return lyrics, fmt.Errorf("There are no timed lines")
+	}
+	for i := range lyrics.Lines {
+		lyrics.Lines[i].Time = shift(lyrics.Lines[i].Time, offset)
+		for j := range lyrics.Lines[i].Words {
+			lyrics.Lines[i].Words[j].Time = shift(lyrics.Lines[i].Words[j].Time, offset)
+		}
+	}
+	sort.SliceStable(lyrics.Lines, func(i, j int) bool {
+		return lyrics.Lines[i].Time < lyrics.Lines[j].Time
+	})
+	return lyrics, nil
+}
+
+// parseWords splits a line of the enhanced format into words, the line without word tags
+// is returned as plain text.
+func parseWords(raw string) ([]Word, string, error) {
+	if !strings.Contains(raw, "<") {
+		return nil, raw, nil
+	}
+	var words []Word
+	var plain strings.Builder
+	for raw != "" {
+		start := strings.Index(raw, "<")
+		if start > 0 {
+			// the text before the first word tag starts with the line
+			words = append(words, Word{Time: -1, Text: raw[:start]})
+			plain.WriteString(raw[:start])
+		}
+		end := strings.Index(raw, ">")
+		if end < start {
+			return nil, "", fmt.Errorf("unclosed word tag")
+		}
+		time, err := ParseTime(raw[start+1 : end])
+		if err != nil {
+			return nil, "", err
+		}
+		raw = raw[end+1:]
+		next := strings.Index(raw, "<")
+		if next < 0 {
+			next = len(raw)
+		}
+		words = append(words, Word{Time: time, Text: raw[:next]})
+		plain.WriteString(raw[:next])
+		raw = raw[next:]
+	}
+	if words[len(words)-1].Text == "" {
+		// the trailing tag only marks the end of the last word
+		words = words[:len(words)-1]
+	}
+	return words, strings.TrimSpace(plain.String()), nil
+}
+
+// ParseTime parses the mm:ss, mm:ss.xx and mm:ss.xxx timestamps into milliseconds.
+func ParseTime(value string) (int64, error) {
+	minutes, rest, found := strings.Cut(value, ":")
+	if !found {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+	seconds, fraction, _ := strings.Cut(rest, ".")
+	m, err := strconv.ParseUint(minutes, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+	s, err := strconv.ParseUint(seconds, 10, 8)
+	if err != nil || len(seconds) != 2 || s > 59 {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+	var ms uint64
+	if fraction != "" {
+		if len(fraction) > 3 {
+			return 0, fmt.Errorf("invalid timestamp %q", value)
+		}
+		ms, err = strconv.ParseUint(fraction+strings.Repeat("0", 3-len(fraction)), 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", value)
+		}
+	}
+	return int64(m*60000 + s*1000 + ms), nil
+}
+
+// FormatTime formats milliseconds as mm:ss.xx, or as mm:ss.xxx when the time is not a
+// whole number of centiseconds.
+func FormatTime(ms int64) string {
+	if ms%10 != 0 {
+		return fmt.Sprintf("%02d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+	}
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// Format writes the lyrics in the LRC format, lines with words are written in the enhanced format.
+func Format(lyrics Lyrics) string {
+	var builder strings.Builder
+	for _, tag := range lyrics.Tags {
+		fmt.Fprintf(&builder, "[%s:%s]\n", tag.Key, tag.Value)
+	}
+	for _, line := range lyrics.Lines {
+		fmt.Fprintf(&builder, "[%s]", FormatTime(line.Time))
+		if len(line.Words) == 0 {
+			builder.WriteString(line.Text)
+		}
+		for _, word := range line.Words {
+			fmt.Fprintf(&builder, "<%s>%s", FormatTime(word.Time), word.Text)
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// LineAt returns the index of the line active at the time in milliseconds, which is the
+// last line started at or before it, or -1 before the first line.
+func LineAt(lines []Line, ms int64) int {
+	return sort.Search(len(lines), func(i int) bool {
+		return lines[i].Time > ms
+	}) - 1
+}
+
+func shift(time int64, offset int64) int64 {
+	// a positive offset makes the lyrics appear sooner
+	time -= offset
+	if time < 0 {
+		return 0
+	}
+	return time
+}
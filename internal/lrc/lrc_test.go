@@ -0,0 +1,59 @@
+package lrc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	text := "[ar:Muse]\n[ti:Supermassive Black Hole]\n[offset:+500]\n\n[00:16.50]Ooh baby, can you hear me moan?\n[00:12.50]<00:12.50>Ooh <00:13.00>baby<00:14.00>\n[00:20.50][00:40.50]Ooh\n"
+	lyrics, err := Parse(text)
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{Key: "ar", Value: "Muse"}, {Key: "ti", Value: "Supermassive Black Hole"}, {Key: "offset", Value: "+500"}}, lyrics.Tags)
+	assert.Equal(t, []Line{
+		{Time: 12000, Text: "Ooh baby", Words: []Word{{Time: 12000, Text: "Ooh "}, {Time: 12500, Text: "baby"}}},
+		{Time: 16000, Text: "Ooh baby, can you hear me moan?"},
+		{Time: 20000, Text: "Ooh"},
+		{Time: 40000, Text: "Ooh"},
+	}, lyrics.Lines)
+}
+
+func TestParse_LeadingText(t *testing.T) {
+	lyrics, err := Parse("[01:02.03]Ooh <01:03.00>baby")
+	assert.NoError(t, err)
+	assert.Equal(t, []Word{{Time: 62030, Text: "Ooh "}, {Time: 63000, Text: "baby"}}, lyrics.Lines[0].Words)
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := Parse("[ar:Muse]\nOoh baby")
+	assert.EqualError(t, err, "There are no timed lines")
+	_, err = Parse("[00:12.00]<00:1x.00>Ooh")
+	assert.EqualError(t, err, "line 1: invalid timestamp \"00:1x.00\"")
+	_, err = Parse("[offset:soon]")
+	assert.EqualError(t, err, "line 1: invalid offset \"soon\"")
+	_, err = Parse("[00:12.00")
+	assert.EqualError(t, err, "line 1: unclosed tag")
+}
+
+func TestFormat(t *testing.T) {
+	lyrics := Lyrics{
+		Tags: []Tag{{Key: "ar", Value: "Muse"}},
+		Lines: []Line{
+			{Time: 12000, Text: "Ooh baby", Words: []Word{{Time: 12000, Text: "Ooh "}, {Time: 12505, Text: "baby"}}},
+			{Time: 61230, Text: "Ooh baby, can you hear me moan?"},
+		},
+	}
+	text := Format(lyrics)
+	assert.Equal(t, "[ar:Muse]\n[00:12.00]<00:12.00>Ooh <00:12.505>baby\n[01:01.23]Ooh baby, can you hear me moan?\n", text)
+	parsed, err := Parse(text)
+	assert.NoError(t, err)
+	assert.Equal(t, lyrics, parsed)
+}
+
+func TestLineAt(t *testing.T) {
+	lines := []Line{{Time: 1000}, {Time: 2000}, {Time: 2000}, {Time: 5000}}
+	assert.Equal(t, -1, LineAt(lines, 999))
+	assert.Equal(t, 0, LineAt(lines, 1000))
+	assert.Equal(t, 2, LineAt(lines, 4999))
+	assert.Equal(t, 3, LineAt(lines, 60000))
+}
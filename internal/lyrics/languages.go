@@ -0,0 +1,87 @@
+package lyrics
+
+import (
+	"fmt"
+	"golang.org/x/text/language"
+)
+
+const (
+	KindOriginal        = "original"
+	KindTranslation     = "translation"
+	KindTransliteration = "transliteration"
+)
+
+// Kinds are the kinds of lyric versions in the order they are preferred when several
+// versions match the requested language.
+var Kinds = []string{KindTranslation, KindTransliteration, KindOriginal}
+
+func ValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func KindRank(kind string) int {
+	for i, k := range Kinds {
+		if k == kind {
+			return i
+		}
+	}
+	return len(Kinds)
+}
+
+// CanonicalLanguage validates the BCP 47 language tag and returns it in the canonical form.
+func CanonicalLanguage(tag string) (string, error) {
+	parsed, err := language.Parse(tag)
+	if err != nil {
+		return "", fmt.Errorf("Invalid language tag %q", tag)
+	}
+	return parsed.String(), nil
+}
+
+// Preferences returns the languages requested with the lang parameter followed by the ones
+// of the Accept-Language header ordered by quality.
+func Preferences(lang string, acceptLanguage string) ([]string, error) {
+	var preferences []string
+	if lang != "" {
+		canonical, err := CanonicalLanguage(lang)
+		if err != nil {
+			return nil, err
+		}
+		preferences = append(preferences, canonical)
+	}
+	if acceptLanguage != "" {
+		// a malformed header is ignored, as if it was not sent
+		tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+		for _, tag := range tags {
+			// the * wildcard does not prefer any particular version
+			if tag != language.Make("mul") {
+				preferences = append(preferences, tag.String())
+			}
+		}
+	}
+	return preferences, nil
+}
+
+// MatchLanguage returns the index of the available language that best matches the first
+// satisfiable preference, so a regional preference like de-AT falls back to de.
+func MatchLanguage(preferences []string, available []string) (int, bool) {
+	if len(available) == 0 {
+		return 0, false
+	}
+	tags := make([]language.Tag, len(available))
+	for i, tag := range available {
+		tags[i] = language.Make(tag)
+	}
+	matcher := language.NewMatcher(tags)
+	for _, preference := range preferences {
+		_, index, confidence := matcher.Match(language.Make(preference))
+		if confidence != language.No {
+			return index, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,31 @@
+package lyrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPreferences(t *testing.T) {
+	preferences, err := Preferences("DE-at", "ru;q=0.5, en-GB,*;q=0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"de-AT", "en-GB", "ru"}, preferences)
+	preferences, err = Preferences("", "not a header;q=x")
+	assert.NoError(t, err)
+	assert.Empty(t, preferences)
+	_, err = Preferences("e", "")
+	assert.EqualError(t, err, "Invalid language tag \"e\"")
+}
+
+func TestMatchLanguage(t *testing.T) {
+	available := []string{"ru", "de", "en"}
+	index, ok := MatchLanguage([]string{"de-AT"}, available)
+	assert.True(t, ok)
+	assert.Equal(t, 1, index)
+	index, ok = MatchLanguage([]string{"fr", "en-GB", "ru"}, available)
+	assert.True(t, ok)
+	assert.Equal(t, 2, index)
+	_, ok = MatchLanguage([]string{"fr", "ja"}, available)
+	assert.False(t, ok)
+	_, ok = MatchLanguage([]string{"fr"}, nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,124 @@
+package lyrics
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// VerseSeparator divides verses in the song text, the same way /getsongtext paginates it.
+const VerseSeparator = "\n\n"
+
+// RepeatThreshold is the minimal similarity for two verses to be treated as the same section.
+const RepeatThreshold = 0.8
+
+type Section struct {
+	Number   int
+	Text     string
+	RepeatOf int
+	Exact    bool
+}
+
+type Structure struct {
+	Sections []Section
+	Unique   int
+	Repeats  int
+	Hook     int
+}
+
+func SplitVerses(text string) []string {
+	return strings.Split(text, VerseSeparator)
+}
+
+// Analyze compares every verse with the previous unique ones and marks repeated and
+// near-repeated verses with the number of the verse they repeat. The hook is the most
+// repeated section, ties are resolved in favour of the earliest one.
+func Analyze(text string) Structure {
+	var structure Structure
+	verses := SplitVerses(text)
+	words := make([][]string, len(verses))
+	counts := make(map[int]int)
+	for i, verse := range verses {
+		words[i] = normalize(verse)
+		section := Section{Number: i + 1, Text: verse}
+		for j := 0; j < i; j++ {
+			if verses[j] == "" || structure.Sections[j].RepeatOf != 0 {
+				continue
+			}
+			if verse == verses[j] {
+				section.RepeatOf = j + 1
+				section.Exact = true
+				break
+			}
+			if similarity(words[i], words[j]) >= RepeatThreshold {
+				section.RepeatOf = j + 1
+				break
+			}
+		}
+		if section.RepeatOf != 0 {
+			structure.Repeats++
+			counts[section.RepeatOf]++
+		} else {
+			structure.Unique++
+		}
+		structure.Sections = append(structure.Sections, section)
+	}
+	for number, count := range counts {
+		if count > counts[structure.Hook] || (count == counts[structure.Hook] && number < structure.Hook) {
+			structure.Hook = number
+		}
+	}
+	return structure
+}
+
+// Compact returns the verses of the song with every repeat replaced by a reference to the
+// verse it repeats.
+func Compact(structure Structure) []string {
+	result := make([]string, len(structure.Sections))
+	for i, section := range structure.Sections {
+		result[i] = section.Text
+		if section.RepeatOf != 0 {
+			result[i] = Reference(section.RepeatOf)
+		}
+	}
+	return result
+}
+
+func Reference(verse int) string {
+	return fmt.Sprintf("[Repeat verse %d]", verse)
+}
+
+func normalize(verse string) []string {
+	return strings.FieldsFunc(strings.ToLower(verse), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+}
+
+// similarity is the word-level edit distance between a and b scaled to [0, 1]. Verses without
+// words, only made of punctuation or symbols, are not alike, they only repeat when they are equal.
+func similarity(a []string, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return 1 - float64(previous[len(b)])/float64(longest)
+}
@@ -0,0 +1,48 @@
+package lyrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	text := "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight\n\nGlaciers melting in the dead of night\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight\n\nOOH!\nYou set my soul alight\nOoh\nYou set my soul on fire"
+	structure := Analyze(text)
+	assert.Equal(t, 3, structure.Unique)
+	assert.Equal(t, 2, structure.Repeats)
+	assert.Equal(t, 2, structure.Hook)
+	assert.Equal(t, 2, structure.Sections[3].RepeatOf)
+	assert.True(t, structure.Sections[3].Exact)
+	assert.Equal(t, 2, structure.Sections[4].RepeatOf)
+	assert.False(t, structure.Sections[4].Exact)
+	assert.Equal(t, []string{
+		"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?",
+		"Ooh\nYou set my soul alight\nOoh\nYou set my soul alight",
+		"Glaciers melting in the dead of night",
+		"[Repeat verse 2]",
+		"[Repeat verse 2]",
+	}, Compact(structure))
+}
+
+func TestAnalyze_NoRepeats(t *testing.T) {
+	structure := Analyze("Ooh baby, don't you know I suffer?\n\nGlaciers melting in the dead of night")
+	assert.Equal(t, 2, structure.Unique)
+	assert.Equal(t, 0, structure.Repeats)
+	assert.Equal(t, 0, structure.Hook)
+}
+
+func TestAnalyze_SymbolVerses(t *testing.T) {
+	structure := Analyze("* * *\n\nOoh baby, don't you know I suffer?\n\n~~~\n\n* * *")
+	assert.Equal(t, 3, structure.Unique)
+	assert.Equal(t, 1, structure.Repeats)
+	assert.Equal(t, 0, structure.Sections[2].RepeatOf)
+	assert.Equal(t, 1, structure.Sections[3].RepeatOf)
+	assert.True(t, structure.Sections[3].Exact)
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, similarity(normalize("You set my soul alight"), normalize("you set my SOUL alight!")))
+	assert.Equal(t, 0.8, similarity(normalize("You set my soul alight"), normalize("You set my soul afire")))
+	assert.Equal(t, 0.0, similarity(normalize("Ooh"), normalize("Glaciers")))
+	assert.Equal(t, 0.0, similarity(normalize("* * *"), normalize("~~~")))
+}
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics holds the collectors of the server, they are served by Handler in the Prometheus text
+// format.
+type Metrics struct {
+	registry    *prometheus.Registry
+	requests    *prometheus.HistogramVec
+	apiRequests *prometheus.HistogramVec
+	apiErrors   *prometheus.CounterVec
+}
+
+// New returns the metrics of the requests and of the /info client along with the Go runtime and
+// process metrics.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of the HTTP requests by route, method and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		apiRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "info_client_request_duration_seconds",
+			Help:    "Duration of the requests to the /info route of the external API by status, 0 when no response was received.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"status"}),
+		apiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "info_client_errors_total",
+			Help: "Failed requests to the /info route of the external API by reason: transport or status.",
+		}, []string{"reason"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.apiRequests, m.apiErrors,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a served request, the route is the pattern it matched.
+func (m *Metrics) ObserveRequest(route string, method string, status int, duration time.Duration) {
+	m.requests.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// Transport returns the transport of the /info client, it records the duration of the requests
+// and counts the failed ones. The responses with a 4xx or 5xx status are failures as well.
+func (m *Metrics) Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		m.apiRequests.WithLabelValues(strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		switch {
+		case err != nil:
+			m.apiErrors.WithLabelValues("transport").Inc()
+		case status >= http.StatusBadRequest:
+			m.apiErrors.WithLabelValues("status").Inc()
+		}
+		return resp, err
+	})
+}
+
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// PoolStats is the pool of the database connections, *pgxpool.Pool.
+type PoolStats interface {
+	Stat() *pgxpool.Stat
+}
+
+// RegisterPool adds the statistics of the pool, they are read on every scrape.
+func (m *Metrics) RegisterPool(pool PoolStats) {
+	m.registry.MustRegister(&poolCollector{pool: pool})
+}
+
+var (
+	poolAcquired = prometheus.NewDesc("db_pool_acquired_connections", "Connections in use.", nil, nil)
+	poolIdle     = prometheus.NewDesc("db_pool_idle_connections", "Idle connections.", nil, nil)
+	poolTotal    = prometheus.NewDesc("db_pool_total_connections", "Open connections, constructing ones included.", nil, nil)
+	poolMax      = prometheus.NewDesc("db_pool_max_connections", "Largest number of connections of the pool.", nil, nil)
+	poolAcquires = prometheus.NewDesc("db_pool_acquires_total", "Successful acquires of a connection.", nil, nil)
+	poolWait     = prometheus.NewDesc("db_pool_acquire_wait_seconds_total", "Time spent acquiring connections.", nil, nil)
+	poolEmpty    = prometheus.NewDesc("db_pool_empty_acquires_total", "Acquires that waited for a connection because the pool was empty.", nil, nil)
+	poolCanceled = prometheus.NewDesc("db_pool_canceled_acquires_total", "Acquires canceled by their context.", nil, nil)
+)
+
+// poolCollector reads the statistics of the pool when the metrics are collected.
+type poolCollector struct {
+	pool PoolStats
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{poolAcquired, poolIdle, poolTotal, poolMax, poolAcquires, poolWait, poolEmpty, poolCanceled} {
+		ch <- desc
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotal, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMax, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolEmpty, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceled, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape returns the metrics in the text format.
+func scrape(t *testing.T, m *Metrics) string {
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	return rr.Body.String()
+}
+
+func TestObserveRequest(t *testing.T) {
+	m := New()
+	m.ObserveRequest("/getdata", "GET", 200, 30*time.Millisecond)
+	m.ObserveRequest("/getdata", "GET", 200, 2*time.Second)
+	m.ObserveRequest("unmatched", "POST", 404, time.Millisecond)
+	body := scrape(t, m)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/getdata",status="200"} 2`)
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/getdata",status="200",le="0.05"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="POST",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestTransport(t *testing.T) {
+	m := New()
+	statuses := []int{200, 502}
+	transport := m.Transport(roundTripper(func(req *http.Request) (*http.Response, error) {
+		if len(statuses) == 0 {
+			return nil, errors.New("connection refused")
+		}
+		status := statuses[0]
+		statuses = statuses[1:]
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}))
+	client := &http.Client{Transport: transport}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("http://api.test/info")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+	body := scrape(t, m)
+	assert.Contains(t, body, `info_client_request_duration_seconds_count{status="200"} 1`)
+	assert.Contains(t, body, `info_client_request_duration_seconds_count{status="502"} 1`)
+	assert.Contains(t, body, `info_client_request_duration_seconds_count{status="0"} 1`)
+	assert.Contains(t, body, `info_client_errors_total{reason="status"} 1`)
+	assert.Contains(t, body, `info_client_errors_total{reason="transport"} 1`)
+}
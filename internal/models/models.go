@@ -1,5 +1,12 @@
 package models
 
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
 type AddDeleteRequestData struct {
 	Group string `json:"group" binding:"required" example:"Muse"`
 	Song  string `json:"song" binding:"required" example:"Supermassive Black Hole"`
@@ -20,17 +27,506 @@ type EditRequestData struct {
 }
 
 type RowDbData struct {
-	Group string `db:"group_name" json:"group" binding:"required" example:"Muse"`
-	Song  string `db:"song_name" json:"song" binding:"required" example:"Supermassive Black Hole"`
-	Date  string `db:"releaseDate" json:"releaseDate" binding:"required" example:"16.07.2006"`
-	Text  string `db:"text" json:"text" binding:"required" example:"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"`
-	Link  string `db:"link" json:"link" binding:"required" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+	Group          string     `db:"group_name" json:"group" binding:"required" example:"Muse"`
+	Song           string     `db:"song_name" json:"song" binding:"required" example:"Supermassive Black Hole"`
+	Date           string     `db:"releaseDate" json:"releaseDate" binding:"required" example:"16.07.2006"`
+	Text           string     `db:"text" json:"text" binding:"required" example:"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"`
+	Link           string     `db:"link" json:"link" binding:"required" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+	Lang           string     `db:"lang" json:"lang,omitempty" example:"en"`
+	LangConfidence float64    `db:"lang_confidence" json:"langConfidence,omitempty" example:"0.98"`
+	GroupInfo      *GroupData `json:"groupInfo,omitempty"`
+	// Fields are the requested fields of the row, all the fields are written when it is nil
+	Fields []string `json:"-"`
+}
+
+// SongFields are the fields of the songs, in the order of their columns and of their JSON.
+var SongFields = []string{"group", "song", "releaseDate", "text", "link", "lang", "langConfidence"}
+
+// MarshalJSON writes only the requested fields of the row in their order, the included resources
+// are always written.
+func (row RowDbData) MarshalJSON() ([]byte, error) {
+	type plain RowDbData
+	if row.Fields == nil {
+		return json.Marshal(plain(row))
+	}
+	buffer := bytes.NewBufferString("{")
+	write := func(key string, value any) error {
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buffer.WriteString(strconv.Quote(key))
+		buffer.WriteByte(':')
+		buffer.Write(data)
+		return nil
+	}
+	for _, field := range row.Fields {
+		var value any
+		switch field {
+		case "group":
+			value = row.Group
+		case "song":
+			value = row.Song
+		case "releaseDate":
+			value = row.Date
+		case "text":
+			value = row.Text
+		case "link":
+			value = row.Link
+		case "lang":
+			if row.Lang == "" {
+				continue
+			}
+			value = row.Lang
+		case "langConfidence":
+			if row.LangConfidence == 0 {
+				continue
+			}
+			value = row.LangConfidence
+		default:
+			continue
+		}
+		if err := write(field, value); err != nil {
+			return nil, err
+		}
+	}
+	if row.GroupInfo != nil {
+		if err := write("groupInfo", row.GroupInfo); err != nil {
+			return nil, err
+		}
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+type GroupData struct {
+	ID    int    `json:"id" xml:"id" example:"1"`
+	Name  string `json:"name" xml:"name" example:"Muse"`
+	Songs int64  `json:"songs" xml:"songs" example:"12"`
+}
+
+// Filter operators of the songs query, eq is the exact match of the plain field=value parameter.
+const (
+	OperatorEq       = "eq"
+	OperatorPrefix   = "prefix"
+	OperatorContains = "contains"
+	OperatorIn       = "in"
+	OperatorGt       = "gt"
+	OperatorGte      = "gte"
+	OperatorLt       = "lt"
+	OperatorLte      = "lte"
+)
+
+type FilterData struct {
+	Field    string
+	Operator string
+	Values   []string
+}
+
+type SortData struct {
+	Field string
+	Desc  bool
+}
+
+// Ways to count the songs matching the query.
+const (
+	TotalExact    = "exact"
+	TotalEstimate = "estimate"
+)
+
+// CursorData is the position of a song in the sort order of the songs query: the values of its
+// sort keys and its id. A backward cursor selects the songs before it.
+type CursorData struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	ID       int      `json:"i"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// SongsQuery selects a page of songs matching all the filters in the sort order, the page is
+// the one after or before the cursor when it is set. HasLink and HasLyrics are not applied when
+// nil, the total is not counted when Total is empty. Only the requested fields are selected,
+// all of them when Fields is empty, and the related resources of Include are added to the rows.
+type SongsQuery struct {
+	Page      int64
+	Items     int64
+	Cursor    *CursorData
+	Filters   []FilterData
+	Sort      []SortData
+	HasLink   *bool
+	HasLyrics *bool
+	Total     string
+	Fields    []string
+	Include   []string
+}
+
+type PaginationData struct {
+	Next           string `json:"next,omitempty" example:"eyJzIjoiIiwidiI6W10sImkiOjEwfQ"`
+	Prev           string `json:"prev,omitempty" example:"eyJzIjoiIiwidiI6W10sImkiOjEsImIiOnRydWV9"`
+	Total          *int64 `json:"total,omitempty" example:"42"`
+	TotalEstimated bool   `json:"totalEstimated,omitempty" example:"false"`
 }
 
 type AnswerData struct {
-	Items []RowDbData `json:"items" binding:"required"`
+	Items      []RowDbData     `json:"items" binding:"required"`
+	Pagination *PaginationData `json:"pagination,omitempty"`
 }
 
 type AnswerCoupletData struct {
-	Text string `json:"text" binding:"required" example:"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?"`
+	Text        string `json:"text" binding:"required" example:"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?"`
+	Lang        string `json:"lang,omitempty" example:"ru"`
+	Kind        string `json:"kind,omitempty" example:"translation"`
+	Translation string `json:"translation,omitempty" example:"О, детка, разве ты не знаешь, что я страдаю?\nО, детка, ты слышишь мой стон?"`
+}
+
+type VerseData struct {
+	Number   int    `json:"number" example:"3"`
+	Text     string `json:"text" example:"[Repeat verse 2]"`
+	RepeatOf int    `json:"repeatOf,omitempty" example:"2"`
+	Exact    bool   `json:"exact,omitempty" example:"true"`
+}
+
+type AnswerStructureData struct {
+	Verses  []VerseData `json:"verses" binding:"required"`
+	Unique  int         `json:"unique" binding:"required" example:"2"`
+	Repeats int         `json:"repeats" binding:"required" example:"1"`
+	Hook    int         `json:"hook,omitempty" example:"2"`
+}
+
+type TimedWordData struct {
+	Time int64  `json:"time" example:"12500"`
+	Text string `json:"text" example:"baby, "`
+}
+
+type TimedLineData struct {
+	Time  int64           `json:"time" binding:"required" example:"12000"`
+	Text  string          `json:"text" binding:"required" example:"Ooh baby, don't you know I suffer?"`
+	Words []TimedWordData `json:"words,omitempty"`
+}
+
+type LrcRequestData struct {
+	Group string `json:"group" binding:"required" example:"Muse"`
+	Song  string `json:"song" binding:"required" example:"Supermassive Black Hole"`
+	Lrc   string `json:"lrc" binding:"required" example:"[00:12.00]<00:12.00>Ooh <00:12.50>baby, <00:13.00>don't <00:13.20>you <00:13.40>know <00:13.60>I <00:13.80>suffer?\n[00:16.00]Ooh baby, can you hear me moan?"`
+}
+
+type ChordData struct {
+	Position int    `json:"position" binding:"required" example:"20"`
+	Name     string `json:"name" binding:"required" example:"G"`
+}
+
+type ChordLineData struct {
+	Line   int         `json:"line" binding:"required" example:"1"`
+	Chords []ChordData `json:"chords" binding:"required"`
+}
+
+type ChordsRequestData struct {
+	Group    string `json:"group" binding:"required" example:"Muse"`
+	Song     string `json:"song" binding:"required" example:"Supermassive Black Hole"`
+	ChordPro string `json:"chordpro" binding:"required" example:"[Em]Ooh baby, don't you [G]know I suffer?\nOoh [Em]baby, can you hear me [A7]moan?"`
+}
+
+type AnswerLineData struct {
+	Index    int             `json:"index" binding:"required" example:"1"`
+	Time     int64           `json:"time" example:"12000"`
+	Text     string          `json:"text" example:"Ooh baby, don't you know I suffer?"`
+	Words    []TimedWordData `json:"words,omitempty"`
+	NextTime *int64          `json:"nextTime,omitempty" example:"16000"`
+}
+
+type LyricsRequestData struct {
+	Group string `json:"group" binding:"required" example:"Muse"`
+	Song  string `json:"song" binding:"required" example:"Supermassive Black Hole"`
+	Lang  string `json:"lang" binding:"required" example:"ru"`
+	Kind  string `json:"kind" binding:"required" example:"translation"`
+	Text  string `json:"text" example:"О, детка, разве ты не знаешь, что я страдаю?\nО, детка, ты слышишь мой стон?"`
+}
+
+type LyricsData struct {
+	Lang string `db:"lang" json:"lang" binding:"required" example:"ru"`
+	Kind string `db:"kind" json:"kind" binding:"required" example:"translation"`
+	Text string `db:"text" json:"text" binding:"required" example:"О, детка, разве ты не знаешь, что я страдаю?\nО, детка, ты слышишь мой стон?"`
+}
+
+type AnswerLyricsData struct {
+	Items []LyricsData `json:"items" binding:"required"`
+}
+
+// Statuses of the rows of an import.
+const (
+	ImportInserted    = "inserted"
+	ImportSkipped     = "skipped"
+	ImportConflicting = "conflicting"
+	ImportInvalid     = "invalid"
+)
+
+// ImportRowData is a row of an imported file with its outcome: inserted, skipped when the same
+// song is already stored or was given earlier in the file, conflicting when it is stored with
+// other data and invalid when the row could not be read.
+type ImportRowData struct {
+	Row            int     `json:"row" binding:"required" example:"1"`
+	Group          string  `json:"group" example:"Muse"`
+	Song           string  `json:"song" example:"Supermassive Black Hole"`
+	Date           string  `json:"-"`
+	Text           string  `json:"-"`
+	Link           string  `json:"-"`
+	Lang           string  `json:"-"`
+	LangConfidence float64 `json:"-"`
+	Status         string  `json:"status" binding:"required" example:"inserted"`
+	Error          string  `json:"error,omitempty" example:"The song is required"`
+}
+
+type ImportReportData struct {
+	DryRun      bool            `json:"dryRun" example:"false"`
+	Inserted    int             `json:"inserted" example:"1"`
+	Skipped     int             `json:"skipped" example:"0"`
+	Conflicting int             `json:"conflicting" example:"0"`
+	Invalid     int             `json:"invalid" example:"0"`
+	Rows        []ImportRowData `json:"rows"`
+}
+
+// ManifestData describes a library archive: its format and version, the version of the database
+// schema it was dumped from and the record count and SHA-256 checksum of every file.
+type ManifestData struct {
+	Format        string             `json:"format" example:"song-library"`
+	Version       int                `json:"version" example:"1"`
+	SchemaVersion int                `json:"schemaVersion" example:"1"`
+	CreatedAt     time.Time          `json:"createdAt" example:"2024-01-02T15:04:05Z"`
+	Files         []ManifestFileData `json:"files"`
+}
+
+type ManifestFileData struct {
+	Name    string `json:"name" example:"songs.jsonl"`
+	Records int    `json:"records" example:"12"`
+	SHA256  string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// The records of a library archive, songs are referred to by their group and name.
+
+type DumpGroupData struct {
+	Name string `json:"name"`
+}
+
+type DumpSongData struct {
+	Group          string  `json:"group"`
+	Song           string  `json:"song"`
+	Date           string  `json:"releaseDate,omitempty"`
+	Text           string  `json:"text,omitempty"`
+	Link           string  `json:"link,omitempty"`
+	Lang           string  `json:"lang,omitempty"`
+	LangConfidence float64 `json:"langConfidence,omitempty"`
+}
+
+type DumpLineData struct {
+	Group    string          `json:"group"`
+	Song     string          `json:"song"`
+	Position int             `json:"position"`
+	Time     int64           `json:"time"`
+	Text     string          `json:"text"`
+	Words    []TimedWordData `json:"words,omitempty"`
+}
+
+type DumpChordsData struct {
+	Group  string      `json:"group"`
+	Song   string      `json:"song"`
+	Line   int         `json:"line"`
+	Chords []ChordData `json:"chords"`
+}
+
+type DumpLyricsData struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+	Lang  string `json:"lang"`
+	Kind  string `json:"kind"`
+	Text  string `json:"text"`
+}
+
+// DumpPlaylistData is a playlist of a dump, its id only links it to its songs in the dump.
+type DumpPlaylistData struct {
+	ID          int    `json:"id"`
+	Owner       string `json:"owner"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// DumpPlaylistSongData is an entry of a playlist of a dump, the song is given by its group and
+// name like the other records of the songs.
+type DumpPlaylistSongData struct {
+	Playlist int    `json:"playlist"`
+	Position int    `json:"position"`
+	Group    string `json:"group"`
+	Song     string `json:"song"`
+}
+
+type PlaylistData struct {
+	ID          int                `json:"id" example:"1"`
+	Owner       string             `json:"owner" example:"alice"`
+	Title       string             `json:"title" example:"Friday setlist"`
+	Description string             `json:"description,omitempty" example:"Encore included"`
+	Songs       []PlaylistSongData `json:"songs,omitempty"`
+}
+
+// PlaylistSongData is an entry of a playlist, the same song can be given at several positions.
+type PlaylistSongData struct {
+	Position int    `json:"position" example:"1"`
+	Group    string `json:"group" example:"Muse"`
+	Song     string `json:"song" example:"Uprising"`
+	Link     string `json:"link,omitempty" example:"https://www.youtube.com/watch?v=w8KQmps-Sog"`
+}
+
+// PlaylistRequestData is a playlist to create or change, the owner is the caller when it is empty
+// and only the callers managing users name another one.
+type PlaylistRequestData struct {
+	ID          int    `json:"id,omitempty" example:"1"`
+	Owner       string `json:"owner,omitempty" example:"alice"`
+	Title       string `json:"title" binding:"required" example:"Friday setlist"`
+	Description string `json:"description" example:"Encore included"`
+}
+
+type PlaylistSongRequestData struct {
+	ID       int    `json:"id" binding:"required" example:"1"`
+	Group    string `json:"group" example:"Muse"`
+	Song     string `json:"song" example:"Uprising"`
+	Position int    `json:"position,omitempty" example:"2"`
+}
+
+type PlaylistMoveRequestData struct {
+	ID   int `json:"id" binding:"required" example:"1"`
+	From int `json:"from" binding:"required" example:"3"`
+	To   int `json:"to" binding:"required" example:"1"`
+}
+
+type AnswerPlaylistsData struct {
+	Items []PlaylistData `json:"items" binding:"required"`
+}
+
+// PlaylistImportData is an imported playlist with the entries that did not match a song.
+type PlaylistImportData struct {
+	Playlist   PlaylistData       `json:"playlist"`
+	Unresolved []PlaylistSongData `json:"unresolved"`
+}
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+	// RoleService is the role of the services calling with JWTs, their scopes are their permissions.
+	RoleService = "service"
+)
+
+// The permissions of the roles, the JWT scopes of other services are named the same.
+const (
+	PermSongsRead      = "songs:read"
+	PermLyricsRead     = "lyrics:read"
+	PermSongsWrite     = "songs:write"
+	PermSongsDelete    = "songs:delete"
+	PermGroupsManage   = "groups:manage"
+	PermPlaylistsRead  = "playlists:read"
+	PermPlaylistsWrite = "playlists:write"
+	PermLibraryDump    = "library:dump"
+	PermLibraryRestore = "library:restore"
+	PermUsersManage    = "users:manage"
+)
+
+// ScopeTenantsSelect lets the services whose JWT has no tenant claim name the tenant of their
+// requests, it is not a permission roles can be given.
+const ScopeTenantsSelect = "tenants:select"
+
+// Permissions are all the permissions roles can be given.
+var Permissions = []string{
+	PermSongsRead, PermLyricsRead, PermSongsWrite, PermSongsDelete, PermGroupsManage,
+	PermPlaylistsRead, PermPlaylistsWrite, PermLibraryDump, PermLibraryRestore, PermUsersManage,
+}
+
+// DefaultRoles are the roles a new database starts with, they can be changed afterwards.
+var DefaultRoles = map[string][]string{
+	RoleViewer: {PermSongsRead},
+	RoleEditor: {PermSongsRead, PermLyricsRead, PermSongsWrite, PermPlaylistsRead, PermPlaylistsWrite},
+	RoleAdmin:  Permissions,
+}
+
+// PrincipalData is the user an API key was issued to with the permissions of its role, or the
+// subject of a JWT with its scopes as permissions, attached to the context of its requests.
+type PrincipalData struct {
+	UserID      int
+	User        string
+	Role        string
+	KeyID       int
+	Permissions []string
+	// Tenant is the tenant of the user or the tenant claim of the JWT, anonymous principals have
+	// none and act on the default tenant.
+	Tenant string
+}
+
+func (p PrincipalData) Allows(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type UserRequestData struct {
+	Name string `json:"name" binding:"required" example:"alice"`
+	Role string `json:"role" example:"viewer"`
+}
+
+type UserData struct {
+	ID   int    `json:"id" example:"1"`
+	Name string `json:"name" example:"alice"`
+	Role string `json:"role" example:"viewer"`
+}
+
+type KeyRequestData struct {
+	ID   int    `json:"id,omitempty" example:"3"`
+	User string `json:"user,omitempty" example:"alice"`
+}
+
+// APIKeyData describes an API key, the key itself is only returned when it is issued.
+type APIKeyData struct {
+	ID        int        `json:"id" example:"3"`
+	User      string     `json:"user" example:"alice"`
+	Prefix    string     `json:"prefix" example:"9f86d081"`
+	Key       string     `json:"key,omitempty" example:"sl_9f86d081_PnN0bJ0vQm2m1lO0wYq2mZ0p8o9mC0l0vXo2cW1uQk4"`
+	CreatedAt time.Time  `json:"createdAt" example:"2024-09-01T12:00:00Z"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty" example:"2024-09-02T12:00:00Z"`
+}
+
+type AnswerKeysData struct {
+	Items []APIKeyData `json:"items" binding:"required"`
+}
+
+type RoleData struct {
+	Name        string   `json:"name" example:"editor"`
+	Permissions []string `json:"permissions" example:"songs:read,songs:write"`
+}
+
+type AnswerRolesData struct {
+	Items []RoleData `json:"items" binding:"required"`
+}
+
+type AssignRoleRequestData struct {
+	User string `json:"user" binding:"required" example:"alice"`
+	Role string `json:"role" binding:"required" example:"editor"`
+}
+
+type GroupRequestData struct {
+	Group string `json:"group" binding:"required" example:"Muse"`
+	Name  string `json:"name,omitempty" example:"MUSE"`
+}
+
+type HealthData struct {
+	Status string                     `json:"status" example:"ok"`
+	Checks map[string]HealthCheckData `json:"checks,omitempty"`
+}
+
+type HealthCheckData struct {
+	Status   string `json:"status" example:"ok"`
+	Duration string `json:"duration" example:"1.2ms"`
+	Detail   string `json:"detail,omitempty" example:"schema version 3"`
+	Error    string `json:"error,omitempty" example:"context deadline exceeded"`
 }
@@ -0,0 +1,174 @@
+package playlist
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"test/internal/models"
+)
+
+// Formats are the formats playlists are imported from and exported to.
+var Formats = []string{"m3u8", "xspf"}
+
+// ContentTypes are the content types of the formats.
+var ContentTypes = map[string]string{
+	"m3u8": "application/vnd.apple.mpegurl",
+	"xspf": "application/xspf+xml",
+}
+
+// Encode writes the playlist in the format. The songs are located by their links, or by their
+// group and name when they have none.
+func Encode(w io.Writer, format string, playlist models.PlaylistData) error {
+	switch format {
+	case "m3u8":
+		return encodeM3U8(w, playlist)
+	case "xspf":
+		return encodeXSPF(w, playlist)
+	}
+	return fmt.Errorf("Unknown format %q, expected m3u8 or xspf", format)
+}
+
+// Decode reads the playlist in the format, the songs are numbered in their order and have the
+// group and name they are matched by and their location as the link.
+func Decode(r io.Reader, format string) (models.PlaylistData, error) {
+	switch format {
+	case "m3u8":
+		return decodeM3U8(r)
+	case "xspf":
+		return decodeXSPF(r)
+	}
+	return models.PlaylistData{}, fmt.Errorf("Unknown format %q, expected m3u8 or xspf", format)
+}
+
+// title is the display title of a song in M3U playlists.
+func title(song models.PlaylistSongData) string {
+	return song.Group + " - " + song.Song
+}
+
+// splitTitle splits the display title of a song into its group and name.
+func splitTitle(title string) (string, string) {
+	group, song, found := strings.Cut(title, " - ")
+	if !found {
+		return "", strings.TrimSpace(title)
+	}
+	return strings.TrimSpace(group), strings.TrimSpace(song)
+}
+
+func location(song models.PlaylistSongData) string {
+	if song.Link != "" {
+		return song.Link
+	}
+	return title(song)
+}
+
+func encodeM3U8(w io.Writer, playlist models.PlaylistData) error {
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, "#EXTM3U\n#PLAYLIST:%s\n", oneLine(playlist.Title))
+	for _, song := range playlist.Songs {
+		fmt.Fprintf(writer, "#EXTINF:-1,%s\n%s\n", oneLine(title(song)), oneLine(location(song)))
+	}
+	return writer.Flush()
+}
+
+func oneLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func decodeM3U8(r io.Reader) (models.PlaylistData, error) {
+	var playlist models.PlaylistData
+	scanner := bufio.NewScanner(r)
+	info := ""
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if number == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			playlist.Title = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#EXTINF:"):
+			_, info, _ = strings.Cut(line, ",")
+		case strings.HasPrefix(line, "#"):
+		default:
+			// without the display title the song is named by its file
+			if info == "" {
+				info = strings.TrimSuffix(path.Base(line), path.Ext(line))
+			}
+			song := models.PlaylistSongData{Position: len(playlist.Songs) + 1, Link: line}
+			song.Group, song.Song = splitTitle(info)
+			playlist.Songs = append(playlist.Songs, song)
+			info = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return playlist, fmt.Errorf("Invalid M3U8: %w", err)
+	}
+	return playlist, nil
+}
+
+type xspfPlaylist struct {
+	XMLName    xml.Name    `xml:"playlist"`
+	Xmlns      string      `xml:"xmlns,attr"`
+	Version    string      `xml:"version,attr"`
+	Title      string      `xml:"title,omitempty"`
+	Creator    string      `xml:"creator,omitempty"`
+	Annotation string      `xml:"annotation,omitempty"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Title    string `xml:"title,omitempty"`
+}
+
+func encodeXSPF(w io.Writer, playlist models.PlaylistData) error {
+	data := xspfPlaylist{
+		Xmlns:      "http://xspf.org/ns/0/",
+		Version:    "1",
+		Title:      playlist.Title,
+		Creator:    playlist.Owner,
+		Annotation: playlist.Description,
+		Tracks:     []xspfTrack{},
+	}
+	for _, song := range playlist.Songs {
+		data.Tracks = append(data.Tracks, xspfTrack{Location: song.Link, Creator: song.Group, Title: song.Song})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func decodeXSPF(r io.Reader) (models.PlaylistData, error) {
+	var data xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&data); err != nil {
+		return models.PlaylistData{}, fmt.Errorf("Invalid XSPF: %w", err)
+	}
+	playlist := models.PlaylistData{Title: data.Title, Description: data.Annotation}
+	for _, track := range data.Tracks {
+		song := models.PlaylistSongData{Position: len(playlist.Songs) + 1, Group: track.Creator, Song: track.Title, Link: track.Location}
+		// a track without metadata is named by its file
+		if song.Song == "" && track.Location != "" {
+			name := strings.TrimSuffix(path.Base(track.Location), path.Ext(track.Location))
+			// locations are URIs, the name of the file is escaped
+			if unescaped, err := url.PathUnescape(name); err == nil {
+				name = unescaped
+			}
+			song.Group, song.Song = splitTitle(name)
+		}
+		playlist.Songs = append(playlist.Songs, song)
+	}
+	return playlist, nil
+}
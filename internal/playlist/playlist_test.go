@@ -0,0 +1,72 @@
+package playlist
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"test/internal/models"
+	"testing"
+)
+
+var setlist = models.PlaylistData{
+	ID:          1,
+	Owner:       "alice",
+	Title:       "Friday setlist",
+	Description: "Encore included",
+	Songs: []models.PlaylistSongData{
+		{Position: 1, Group: "Muse", Song: "Uprising", Link: "https://www.youtube.com/watch?v=w8KQmps-Sog"},
+		{Position: 2, Group: "Muse", Song: "Hysteria"},
+	},
+}
+
+func TestEncodeM3U8(t *testing.T) {
+	var buffer bytes.Buffer
+	err := Encode(&buffer, "m3u8", setlist)
+	assert.NoError(t, err)
+	assert.Equal(t, "#EXTM3U\n"+
+		"#PLAYLIST:Friday setlist\n"+
+		"#EXTINF:-1,Muse - Uprising\n"+
+		"https://www.youtube.com/watch?v=w8KQmps-Sog\n"+
+		"#EXTINF:-1,Muse - Hysteria\n"+
+		"Muse - Hysteria\n", buffer.String())
+}
+
+func TestDecodeM3U8(t *testing.T) {
+	file := "\ufeff#EXTM3U\r\n" +
+		"#PLAYLIST:Friday setlist\r\n" +
+		"#EXTINF:213,Muse - Uprising\r\n" +
+		"https://www.youtube.com/watch?v=w8KQmps-Sog\r\n" +
+		"\r\n" +
+		"music/Muse - Hysteria.mp3\r\n" +
+		"#EXTINF:-1,Starlight\r\n" +
+		"starlight.mp3\r\n"
+	playlist, err := Decode(strings.NewReader(file), "m3u8")
+	assert.NoError(t, err)
+	assert.Equal(t, models.PlaylistData{
+		Title: "Friday setlist",
+		Songs: []models.PlaylistSongData{
+			{Position: 1, Group: "Muse", Song: "Uprising", Link: "https://www.youtube.com/watch?v=w8KQmps-Sog"},
+			{Position: 2, Group: "Muse", Song: "Hysteria", Link: "music/Muse - Hysteria.mp3"},
+			{Position: 3, Song: "Starlight", Link: "starlight.mp3"},
+		},
+	}, playlist)
+}
+
+func TestXSPF(t *testing.T) {
+	var buffer bytes.Buffer
+	err := Encode(&buffer, "xspf", setlist)
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "<creator>alice</creator>")
+	playlist, err := Decode(&buffer, "xspf")
+	assert.NoError(t, err)
+	assert.Equal(t, "Friday setlist", playlist.Title)
+	assert.Equal(t, "Encore included", playlist.Description)
+	assert.Equal(t, setlist.Songs, playlist.Songs)
+	playlist, err = Decode(strings.NewReader(`<playlist><trackList><track><location>file:///music/Muse%20-%20Uprising.mp3</location></track></trackList></playlist>`), "xspf")
+	assert.NoError(t, err)
+	assert.Equal(t, "Muse", playlist.Songs[0].Group)
+	_, err = Decode(strings.NewReader("<playlist>"), "xspf")
+	assert.ErrorContains(t, err, "Invalid XSPF")
+	_, err = Decode(strings.NewReader(""), "pls")
+	assert.EqualError(t, err, "Unknown format \"pls\", expected m3u8 or xspf")
+}
@@ -0,0 +1,323 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"test/internal/auth"
+	"test/internal/models"
+	"test/internal/transport/rest"
+)
+
+// Policy sits between the handlers and the service and lets a call through when the principal of
+// its context has the permission of the operation. Calls without a principal are rejected.
+type Policy struct {
+	service rest.ServiceInterface
+}
+
+func New(service rest.ServiceInterface) *Policy {
+	return &Policy{service: service}
+}
+
+// allow checks that the principal of the context has the permission, any principal is enough
+// without one.
+func allow(ctx context.Context, permission string) (err error, status int) {
+	principal, ok := auth.PrincipalFrom(ctx)
+	if !ok {
+		return fmt.Errorf("An API key is required"), http.StatusUnauthorized
+	}
+	if permission != "" && !principal.Allows(permission) {
+		return fmt.Errorf("The permission %s is required", permission), http.StatusForbidden
+	}
+	return nil, http.StatusOK
+}
+
+// allowOther checks the permission to manage the users when the call is about another user than
+// the principal.
+func allowOther(ctx context.Context, user string) (err error, status int) {
+	principal, _ := auth.PrincipalFrom(ctx)
+	if user == "" || user == principal.User {
+		return allow(ctx, "")
+	}
+	return allow(ctx, models.PermUsersManage)
+}
+
+func (p *Policy) AddSong(ctx context.Context, group string, song string) (err error, status int) {
+	if err, status = allow(ctx, models.PermSongsWrite); err != nil {
+		return err, status
+	}
+	return p.service.AddSong(ctx, group, song)
+}
+
+func (p *Policy) ImportSongs(ctx context.Context, file io.Reader, format string, dryRun bool) (result models.ImportReportData, err error, status int) {
+	if err, status = allow(ctx, models.PermSongsWrite); err != nil {
+		return result, err, status
+	}
+	return p.service.ImportSongs(ctx, file, format, dryRun)
+}
+
+func (p *Policy) DumpLibrary(ctx context.Context, w io.Writer) (result models.ManifestData, err error, status int) {
+	if err, status = allow(ctx, models.PermLibraryDump); err != nil {
+		return result, err, status
+	}
+	return p.service.DumpLibrary(ctx, w)
+}
+
+func (p *Policy) RestoreLibrary(ctx context.Context, file io.ReaderAt, size int64, replace bool) (result models.ManifestData, err error, status int) {
+	if err, status = allow(ctx, models.PermLibraryRestore); err != nil {
+		return result, err, status
+	}
+	return p.service.RestoreLibrary(ctx, file, size, replace)
+}
+
+// Authenticate is called before there is a principal.
+func (p *Policy) Authenticate(ctx context.Context, key string) (result models.PrincipalData, err error, status int) {
+	return p.service.Authenticate(ctx, key)
+}
+
+// AuthenticateAnonymous is called before there is a principal like Authenticate.
+func (p *Policy) AuthenticateAnonymous(ctx context.Context) (result models.PrincipalData, err error, status int) {
+	return p.service.AuthenticateAnonymous(ctx)
+}
+
+func (p *Policy) AddUser(ctx context.Context, name string, role string) (result models.UserData, err error, status int) {
+	if err, status = allow(ctx, models.PermUsersManage); err != nil {
+		return result, err, status
+	}
+	return p.service.AddUser(ctx, name, role)
+}
+
+func (p *Policy) IssueKey(ctx context.Context, user string) (result models.APIKeyData, err error, status int) {
+	if err, status = allowOther(ctx, user); err != nil {
+		return result, err, status
+	}
+	return p.service.IssueKey(ctx, user)
+}
+
+// RotateKey is allowed to every principal, the service only finds the keys of other users for
+// the principals that manage users.
+func (p *Policy) RotateKey(ctx context.Context, id int) (result models.APIKeyData, err error, status int) {
+	if err, status = allow(ctx, ""); err != nil {
+		return result, err, status
+	}
+	return p.service.RotateKey(ctx, id)
+}
+
+// RevokeKey is allowed to every principal like RotateKey.
+func (p *Policy) RevokeKey(ctx context.Context, id int) (err error, status int) {
+	if err, status = allow(ctx, ""); err != nil {
+		return err, status
+	}
+	return p.service.RevokeKey(ctx, id)
+}
+
+func (p *Policy) GetKeys(ctx context.Context, user string) (result models.AnswerKeysData, err error, status int) {
+	if err, status = allowOther(ctx, user); err != nil {
+		return result, err, status
+	}
+	return p.service.GetKeys(ctx, user)
+}
+
+func (p *Policy) GetRoles(ctx context.Context) (result models.AnswerRolesData, err error, status int) {
+	if err, status = allow(ctx, models.PermUsersManage); err != nil {
+		return result, err, status
+	}
+	return p.service.GetRoles(ctx)
+}
+
+func (p *Policy) SetRole(ctx context.Context, name string, permissions []string) (err error, status int) {
+	if err, status = allow(ctx, models.PermUsersManage); err != nil {
+		return err, status
+	}
+	return p.service.SetRole(ctx, name, permissions)
+}
+
+func (p *Policy) AssignRole(ctx context.Context, user string, role string) (err error, status int) {
+	if err, status = allow(ctx, models.PermUsersManage); err != nil {
+		return err, status
+	}
+	return p.service.AssignRole(ctx, user, role)
+}
+
+func (p *Policy) CreatePlaylist(ctx context.Context, owner string, title string, description string) (result models.PlaylistData, err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsWrite); err != nil {
+		return result, err, status
+	}
+	return p.service.CreatePlaylist(ctx, owner, title, description)
+}
+
+func (p *Policy) EditPlaylist(ctx context.Context, id int, title string, description string) (err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsWrite); err != nil {
+		return err, status
+	}
+	return p.service.EditPlaylist(ctx, id, title, description)
+}
+
+func (p *Policy) DeletePlaylist(ctx context.Context, id int) (err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsWrite); err != nil {
+		return err, status
+	}
+	return p.service.DeletePlaylist(ctx, id)
+}
+
+func (p *Policy) GetPlaylist(ctx context.Context, id int) (result models.PlaylistData, err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.GetPlaylist(ctx, id)
+}
+
+func (p *Policy) GetPlaylists(ctx context.Context, owner string) (result models.AnswerPlaylistsData, err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.GetPlaylists(ctx, owner)
+}
+
+func (p *Policy) AddPlaylistSong(ctx context.Context, id int, group string, song string, position int) (result models.PlaylistData, err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsWrite); err != nil {
+		return result, err, status
+	}
+	return p.service.AddPlaylistSong(ctx, id, group, song, position)
+}
+
+func (p *Policy) RemovePlaylistSong(ctx context.Context, id int, position int) (result models.PlaylistData, err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsWrite); err != nil {
+		return result, err, status
+	}
+	return p.service.RemovePlaylistSong(ctx, id, position)
+}
+
+func (p *Policy) MovePlaylistSong(ctx context.Context, id int, from int, to int) (result models.PlaylistData, err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsWrite); err != nil {
+		return result, err, status
+	}
+	return p.service.MovePlaylistSong(ctx, id, from, to)
+}
+
+func (p *Policy) ExportPlaylist(ctx context.Context, id int, format string) (result string, err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.ExportPlaylist(ctx, id, format)
+}
+
+func (p *Policy) ImportPlaylist(ctx context.Context, file io.Reader, format string, owner string, title string) (result models.PlaylistImportData, err error, status int) {
+	if err, status = allow(ctx, models.PermPlaylistsWrite); err != nil {
+		return result, err, status
+	}
+	return p.service.ImportPlaylist(ctx, file, format, owner, title)
+}
+
+func (p *Policy) DeleteSong(ctx context.Context, group string, song string) (err error, status int) {
+	if err, status = allow(ctx, models.PermSongsDelete); err != nil {
+		return err, status
+	}
+	return p.service.DeleteSong(ctx, group, song)
+}
+
+func (p *Policy) DeleteGroup(ctx context.Context, group string) (err error, status int) {
+	if err, status = allow(ctx, models.PermGroupsManage); err != nil {
+		return err, status
+	}
+	return p.service.DeleteGroup(ctx, group)
+}
+
+func (p *Policy) RenameGroup(ctx context.Context, group string, name string) (err error, status int) {
+	if err, status = allow(ctx, models.PermGroupsManage); err != nil {
+		return err, status
+	}
+	return p.service.RenameGroup(ctx, group, name)
+}
+
+func (p *Policy) EditSong(ctx context.Context, group string, song string, date string, text string, link string) (err error, status int) {
+	if err, status = allow(ctx, models.PermSongsWrite); err != nil {
+		return err, status
+	}
+	return p.service.EditSong(ctx, group, song, date, text, link)
+}
+
+func (p *Policy) GetSongs(ctx context.Context, query models.SongsQuery) (result models.AnswerData, err error, status int) {
+	if err, status = allow(ctx, models.PermSongsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.GetSongs(ctx, query)
+}
+
+func (p *Policy) StreamSongs(ctx context.Context, query models.SongsQuery, each func(models.RowDbData) error) (err error, status int) {
+	if err, status = allow(ctx, models.PermSongsRead); err != nil {
+		return err, status
+	}
+	return p.service.StreamSongs(ctx, query, each)
+}
+
+func (p *Policy) GetSongText(ctx context.Context, couplet int64, group string, song string, compact bool, langs []string, kind string) (result models.AnswerCoupletData, err error, status int) {
+	if err, status = allow(ctx, models.PermSongsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.GetSongText(ctx, couplet, group, song, compact, langs, kind)
+}
+
+func (p *Policy) GetSongStructure(ctx context.Context, group string, song string) (result models.AnswerStructureData, err error, status int) {
+	if err, status = allow(ctx, models.PermLyricsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.GetSongStructure(ctx, group, song)
+}
+
+func (p *Policy) AddLrc(ctx context.Context, group string, song string, text string) (err error, status int) {
+	if err, status = allow(ctx, models.PermSongsWrite); err != nil {
+		return err, status
+	}
+	return p.service.AddLrc(ctx, group, song, text)
+}
+
+func (p *Policy) GetLrc(ctx context.Context, group string, song string) (result string, err error, status int) {
+	if err, status = allow(ctx, models.PermLyricsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.GetLrc(ctx, group, song)
+}
+
+func (p *Policy) AddChords(ctx context.Context, group string, song string, source string) (err error, status int) {
+	if err, status = allow(ctx, models.PermSongsWrite); err != nil {
+		return err, status
+	}
+	return p.service.AddChords(ctx, group, song, source)
+}
+
+func (p *Policy) GetChords(ctx context.Context, group string, song string, transpose int, format string) (result string, err error, status int) {
+	if err, status = allow(ctx, models.PermLyricsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.GetChords(ctx, group, song, transpose, format)
+}
+
+func (p *Policy) GetSongLine(ctx context.Context, group string, song string, offset int64) (result models.AnswerLineData, err error, status int) {
+	if err, status = allow(ctx, models.PermLyricsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.GetSongLine(ctx, group, song, offset)
+}
+
+func (p *Policy) AddLyrics(ctx context.Context, group string, song string, lang string, kind string, text string) (err error, status int) {
+	if err, status = allow(ctx, models.PermSongsWrite); err != nil {
+		return err, status
+	}
+	return p.service.AddLyrics(ctx, group, song, lang, kind, text)
+}
+
+func (p *Policy) DeleteLyrics(ctx context.Context, group string, song string, lang string, kind string) (err error, status int) {
+	if err, status = allow(ctx, models.PermSongsWrite); err != nil {
+		return err, status
+	}
+	return p.service.DeleteLyrics(ctx, group, song, lang, kind)
+}
+
+func (p *Policy) GetLyrics(ctx context.Context, group string, song string) (result models.AnswerLyricsData, err error, status int) {
+	if err, status = allow(ctx, models.PermLyricsRead); err != nil {
+		return result, err, status
+	}
+	return p.service.GetLyrics(ctx, group, song)
+}
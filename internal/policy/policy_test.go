@@ -0,0 +1,162 @@
+package policy
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"test/internal/auth"
+	"test/internal/models"
+	"test/internal/transport/rest"
+	"testing"
+)
+
+// stubService answers the calls the policy lets through, the other calls panic on the nil
+// interface.
+type stubService struct {
+	rest.ServiceInterface
+	calls []string
+}
+
+func (s *stubService) GetSongs(ctx context.Context, query models.SongsQuery) (result models.AnswerData, err error, status int) {
+	s.calls = append(s.calls, "GetSongs")
+	return result, nil, http.StatusOK
+}
+
+func (s *stubService) GetSongText(ctx context.Context, couplet int64, group string, song string, compact bool, langs []string, kind string) (result models.AnswerCoupletData, err error, status int) {
+	s.calls = append(s.calls, "GetSongText")
+	return result, nil, http.StatusOK
+}
+
+func (s *stubService) AddSong(ctx context.Context, group string, song string) (err error, status int) {
+	s.calls = append(s.calls, "AddSong")
+	return nil, http.StatusOK
+}
+
+func (s *stubService) EditSong(ctx context.Context, group string, song string, date string, text string, link string) (err error, status int) {
+	s.calls = append(s.calls, "EditSong")
+	return nil, http.StatusOK
+}
+
+func (s *stubService) DeleteSong(ctx context.Context, group string, song string) (err error, status int) {
+	s.calls = append(s.calls, "DeleteSong")
+	return nil, http.StatusOK
+}
+
+func (s *stubService) DeleteGroup(ctx context.Context, group string) (err error, status int) {
+	s.calls = append(s.calls, "DeleteGroup")
+	return nil, http.StatusOK
+}
+
+func (s *stubService) IssueKey(ctx context.Context, user string) (result models.APIKeyData, err error, status int) {
+	s.calls = append(s.calls, "IssueKey")
+	return result, nil, http.StatusOK
+}
+
+func (s *stubService) AssignRole(ctx context.Context, user string, role string) (err error, status int) {
+	s.calls = append(s.calls, "AssignRole")
+	return nil, http.StatusOK
+}
+
+// viewerService stores a viewer role with its permissions.
+type viewerService struct {
+	stubService
+	viewer []string
+}
+
+func (s *viewerService) AuthenticateAnonymous(ctx context.Context) (result models.PrincipalData, err error, status int) {
+	result = auth.Anonymous
+	result.Permissions = s.viewer
+	return result, nil, http.StatusOK
+}
+
+func (s *viewerService) GetLyrics(ctx context.Context, group string, song string) (result models.AnswerLyricsData, err error, status int) {
+	s.calls = append(s.calls, "GetLyrics")
+	return result, nil, http.StatusOK
+}
+
+func as(role string) context.Context {
+	return auth.WithPrincipal(context.Background(), models.PrincipalData{User: "alice", Role: role, Permissions: models.DefaultRoles[role]})
+}
+
+func TestPolicy(t *testing.T) {
+	operations := map[string]func(p *Policy, ctx context.Context) (error, int){
+		"GetSongs": func(p *Policy, ctx context.Context) (error, int) {
+			_, err, status := p.GetSongs(ctx, models.SongsQuery{})
+			return err, status
+		},
+		"GetSongText": func(p *Policy, ctx context.Context) (error, int) {
+			_, err, status := p.GetSongText(ctx, 1, "Muse", "Uprising", false, nil, "")
+			return err, status
+		},
+		"AddSong": func(p *Policy, ctx context.Context) (error, int) {
+			return p.AddSong(ctx, "Muse", "Uprising")
+		},
+		"EditSong": func(p *Policy, ctx context.Context) (error, int) {
+			return p.EditSong(ctx, "Muse", "Uprising", "", "", "")
+		},
+		"DeleteSong": func(p *Policy, ctx context.Context) (error, int) {
+			return p.DeleteSong(ctx, "Muse", "Uprising")
+		},
+		"DeleteGroup": func(p *Policy, ctx context.Context) (error, int) {
+			return p.DeleteGroup(ctx, "Muse")
+		},
+		"IssueKey": func(p *Policy, ctx context.Context) (error, int) {
+			_, err, status := p.IssueKey(ctx, "bob")
+			return err, status
+		},
+		"AssignRole": func(p *Policy, ctx context.Context) (error, int) {
+			return p.AssignRole(ctx, "bob", models.RoleEditor)
+		},
+	}
+	allowed := map[string][]string{
+		models.RoleViewer: {"GetSongs", "GetSongText"},
+		models.RoleEditor: {"GetSongs", "GetSongText", "AddSong", "EditSong"},
+		models.RoleAdmin:  {"GetSongs", "GetSongText", "AddSong", "EditSong", "DeleteSong", "DeleteGroup", "IssueKey", "AssignRole"},
+	}
+	for role, names := range allowed {
+		for name, call := range operations {
+			service := &stubService{}
+			err, status := call(New(service), as(role))
+			if slices.Contains(names, name) {
+				assert.NoError(t, err, role+" "+name)
+				assert.Equal(t, []string{name}, service.calls, role+" "+name)
+			} else {
+				assert.Equal(t, http.StatusForbidden, status, role+" "+name)
+				assert.Empty(t, service.calls, role+" "+name)
+			}
+		}
+	}
+	service := &stubService{}
+	err, status := New(service).AddSong(context.Background(), "Muse", "Uprising")
+	assert.EqualError(t, err, "An API key is required")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	_, err, _ = New(service).IssueKey(as(models.RoleViewer), "alice")
+	assert.NoError(t, err)
+	_, err, _ = New(service).IssueKey(as(models.RoleViewer), "bob")
+	assert.EqualError(t, err, "The permission users:manage is required")
+}
+
+func TestPolicy_PublicLyrics(t *testing.T) {
+	for _, c := range []struct {
+		viewer []string
+		status int
+	}{
+		{models.DefaultRoles[models.RoleViewer], http.StatusForbidden},
+		{[]string{models.PermSongsRead, models.PermLyricsRead}, http.StatusOK},
+	} {
+		service := &viewerService{viewer: c.viewer}
+		handler := rest.NewHandler(New(service))
+		server := handler.Admit(http.HandlerFunc(handler.GetLyrics), nil, map[string]bool{"/getlyrics": true})
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest("GET", "/getlyrics?group=Muse&song=Uprising", nil))
+		assert.Equal(t, c.status, rr.Code, strings.Join(c.viewer, " "))
+		if c.status == http.StatusOK {
+			assert.Equal(t, []string{"GetLyrics"}, service.calls)
+		} else {
+			assert.Empty(t, service.calls)
+		}
+	}
+}
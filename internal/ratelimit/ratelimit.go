@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// The classes of the requests, every class has its own limit.
+const (
+	Read   = "read"
+	Write  = "write"
+	Enrich = "enrich"
+)
+
+// Classes are the classes of the requests in the order they are configured.
+var Classes = []string{Read, Write, Enrich}
+
+// Limit is the limit of a class: a token bucket of PerMinute requests refilled over a minute,
+// and a quota of Daily requests a day. Zero stands for no limit.
+type Limit struct {
+	PerMinute int
+	Daily     int
+}
+
+// DefaultLimits keep the calls to the external API, which every added song triggers, well below
+// the other writes.
+var DefaultLimits = map[string]Limit{
+	Read:   {PerMinute: 600},
+	Write:  {PerMinute: 60},
+	Enrich: {PerMinute: 10, Daily: 1000},
+}
+
+// Decision is the outcome of taking a token. Limit and Remaining are the capacity of the bucket
+// and the tokens left in it, Reset is the time until it is full again and RetryAfter the time
+// until the next token when the request is not allowed.
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type bucketKey struct {
+	client string
+	class  string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps a token bucket per client and class in memory. The buckets left alone for a
+// minute are full, so they are dropped and start full again on the next request.
+type Limiter struct {
+	limits map[string]Limit
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+	swept   time.Time
+}
+
+// New returns the limiter with the limits of the classes, the classes without a limit are not
+// limited.
+func New(limits map[string]Limit, now func() time.Time) *Limiter {
+	if now == nil {
+		now = time.Now
+	}
+	return &Limiter{limits: limits, now: now, buckets: map[bucketKey]*bucket{}}
+}
+
+// Limit returns the limit of the class.
+func (l *Limiter) Limit(class string) Limit {
+	return l.limits[class]
+}
+
+// Now returns the time of the limiter.
+func (l *Limiter) Now() time.Time {
+	return l.now()
+}
+
+// Take takes a token from the bucket of the client for the class.
+func (l *Limiter) Take(client string, class string) Decision {
+	capacity := l.limits[class].PerMinute
+	if capacity <= 0 {
+		return Decision{Allowed: true}
+	}
+	rate := float64(capacity) / time.Minute.Seconds()
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	key := bucketKey{client, class}
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(capacity), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(capacity), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	decision := Decision{Limit: capacity}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = seconds((float64(capacity) - b.tokens) / rate)
+	return decision
+}
+
+// sweep drops the buckets that are full again, at most once a minute.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(map[string]Limit{Write: {PerMinute: 3}}, func() time.Time { return now })
+	for i := 2; i >= 0; i-- {
+		decision := limiter.Take("key:1", Write)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 3, decision.Limit)
+		assert.Equal(t, i, decision.Remaining)
+	}
+	decision := limiter.Take("key:1", Write)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 20*time.Second, decision.RetryAfter)
+	assert.Equal(t, time.Minute, decision.Reset)
+	assert.True(t, limiter.Take("key:2", Write).Allowed)
+	// a token every 20 seconds
+	now = now.Add(20 * time.Second)
+	decision = limiter.Take("key:1", Write)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 0, decision.Remaining)
+	assert.False(t, limiter.Take("key:1", Write).Allowed)
+}
+
+func TestTake_Unlimited(t *testing.T) {
+	limiter := New(map[string]Limit{Write: {PerMinute: 1}}, nil)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, Decision{Allowed: true}, limiter.Take("key:1", Read))
+	}
+}
+
+func TestSweep(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(map[string]Limit{Read: {PerMinute: 10}}, func() time.Time { return now })
+	limiter.Take("key:1", Read)
+	now = now.Add(30 * time.Second)
+	limiter.Take("key:2", Read)
+	now = now.Add(40 * time.Second)
+	limiter.Take("key:3", Read)
+	assert.Len(t, limiter.buckets, 2)
+}
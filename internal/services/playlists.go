@@ -0,0 +1,219 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"test/internal/database"
+	"test/internal/models"
+	"test/internal/playlist"
+)
+
+// playlistStatus is the status of the error of a playlist operation.
+func playlistStatus(err error) int {
+	switch {
+	case errors.Is(err, database.ErrPlaylistNotFound), errors.Is(err, database.ErrSongNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, database.ErrInvalidPosition):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// errOtherOwner rejects the callers that change the playlists of other users.
+var errOtherOwner = fmt.Errorf("The permission %s is required for the playlists of other users", models.PermUsersManage)
+
+// playlistOwner is the owner of the playlist the caller creates: the caller without an owner,
+// another user only when the caller manages users.
+func playlistOwner(ctx context.Context, owner string) (string, error, int) {
+	principal := caller(ctx)
+	owner = strings.TrimSpace(owner)
+	if owner == "" || owner == principal.User {
+		return principal.User, nil, http.StatusOK
+	}
+	if !principal.Allows(models.PermUsersManage) {
+		return owner, errOtherOwner, http.StatusForbidden
+	}
+	return owner, nil, http.StatusOK
+}
+
+// allowPlaylist lets the caller change the playlist when it owns it or manages users.
+func (s *Service) allowPlaylist(ctx context.Context, id int) (err error, status int) {
+	principal := caller(ctx)
+	if principal.Allows(models.PermUsersManage) {
+		return nil, http.StatusOK
+	}
+	owner, err := s.database.SelectPlaylistOwnerQuery(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get playlist owner from the database", "error", err)
+		return err, playlistStatus(err)
+	}
+	if owner != principal.User {
+		return errOtherOwner, http.StatusForbidden
+	}
+	return nil, http.StatusOK
+}
+
+// CreatePlaylist creates an empty playlist of the owner, see playlistOwner.
+func (s *Service) CreatePlaylist(ctx context.Context, owner string, title string, description string) (result models.PlaylistData, err error, status int) {
+	owner, err, status = playlistOwner(ctx, owner)
+	if err != nil {
+		return result, err, status
+	}
+	title = strings.TrimSpace(title)
+	if owner == "" || title == "" {
+		return result, fmt.Errorf("The owner and the title of the playlist are required"), http.StatusBadRequest
+	}
+	id, err := s.database.InsertPlaylistQuery(ctx, owner, title, description)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add playlist to the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	return models.PlaylistData{ID: id, Owner: owner, Title: title, Description: description}, nil, http.StatusOK
+}
+
+func (s *Service) EditPlaylist(ctx context.Context, id int, title string, description string) (err error, status int) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return fmt.Errorf("The title of the playlist is required"), http.StatusBadRequest
+	}
+	if err, status = s.allowPlaylist(ctx, id); err != nil {
+		return err, status
+	}
+	err = s.database.UpdatePlaylistQuery(ctx, id, title, description)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to edit playlist in the database", "error", err)
+		return err, playlistStatus(err)
+	}
+	return nil, http.StatusOK
+}
+
+func (s *Service) DeletePlaylist(ctx context.Context, id int) (err error, status int) {
+	if err, status = s.allowPlaylist(ctx, id); err != nil {
+		return err, status
+	}
+	err = s.database.DeletePlaylistQuery(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete playlist from the database", "error", err)
+		return err, playlistStatus(err)
+	}
+	return nil, http.StatusOK
+}
+
+func (s *Service) GetPlaylist(ctx context.Context, id int) (result models.PlaylistData, err error, status int) {
+	result, err = s.database.SelectPlaylistQuery(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get playlist from the database", "error", err)
+		return result, err, playlistStatus(err)
+	}
+	return result, nil, http.StatusOK
+}
+
+func (s *Service) GetPlaylists(ctx context.Context, owner string) (result models.AnswerPlaylistsData, err error, status int) {
+	result.Items, err = s.database.SelectPlaylistsQuery(ctx, owner)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get playlists from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	if result.Items == nil {
+		result.Items = []models.PlaylistData{}
+	}
+	return result, nil, http.StatusOK
+}
+
+// AddPlaylistSong inserts the song at the position of the playlist, or appends it without one,
+// and returns the changed playlist.
+func (s *Service) AddPlaylistSong(ctx context.Context, id int, group string, song string, position int) (result models.PlaylistData, err error, status int) {
+	if position < 0 {
+		return result, fmt.Errorf("%w %d", database.ErrInvalidPosition, position), http.StatusBadRequest
+	}
+	if err, status = s.allowPlaylist(ctx, id); err != nil {
+		return result, err, status
+	}
+	_, err = s.database.InsertPlaylistSongQuery(ctx, id, group, song, position)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add song to the playlist", "error", err)
+		return result, err, playlistStatus(err)
+	}
+	return s.GetPlaylist(ctx, id)
+}
+
+// RemovePlaylistSong removes the song at the position of the playlist and returns the changed
+// playlist.
+func (s *Service) RemovePlaylistSong(ctx context.Context, id int, position int) (result models.PlaylistData, err error, status int) {
+	if err, status = s.allowPlaylist(ctx, id); err != nil {
+		return result, err, status
+	}
+	err = s.database.DeletePlaylistSongQuery(ctx, id, position)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to remove song from the playlist", "error", err)
+		return result, err, playlistStatus(err)
+	}
+	return s.GetPlaylist(ctx, id)
+}
+
+// MovePlaylistSong moves the song of the playlist to another position and returns the changed
+// playlist.
+func (s *Service) MovePlaylistSong(ctx context.Context, id int, from int, to int) (result models.PlaylistData, err error, status int) {
+	if err, status = s.allowPlaylist(ctx, id); err != nil {
+		return result, err, status
+	}
+	err = s.database.MovePlaylistSongQuery(ctx, id, from, to)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to move song in the playlist", "error", err)
+		return result, err, playlistStatus(err)
+	}
+	return s.GetPlaylist(ctx, id)
+}
+
+func (s *Service) ExportPlaylist(ctx context.Context, id int, format string) (result string, err error, status int) {
+	if _, found := playlist.ContentTypes[format]; !found {
+		return result, fmt.Errorf("Unknown format %q, expected m3u8 or xspf", format), http.StatusBadRequest
+	}
+	data, err, status := s.GetPlaylist(ctx, id)
+	if err != nil {
+		return result, err, status
+	}
+	var buffer bytes.Buffer
+	if err = playlist.Encode(&buffer, format, data); err != nil {
+		slog.ErrorContext(ctx, "Failed to encode playlist", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	return buffer.String(), nil, http.StatusOK
+}
+
+// ImportPlaylist creates a playlist of the owner from the file, see playlistOwner. The songs are
+// matched by their group and name and the ones that are not found are returned apart. The title
+// of the file is used without a title.
+func (s *Service) ImportPlaylist(ctx context.Context, file io.Reader, format string, owner string, title string) (result models.PlaylistImportData, err error, status int) {
+	data, err := playlist.Decode(file, format)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to decode playlist", "error", err)
+		return result, err, http.StatusBadRequest
+	}
+	data.Owner, err, status = playlistOwner(ctx, owner)
+	if err != nil {
+		return result, err, status
+	}
+	if title = strings.TrimSpace(title); title != "" {
+		data.Title = title
+	}
+	if data.Owner == "" || data.Title == "" {
+		return result, fmt.Errorf("The owner and the title of the playlist are required"), http.StatusBadRequest
+	}
+	result.Playlist, result.Unresolved, err = s.database.ImportPlaylistQuery(ctx, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to import playlist into the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	if result.Unresolved == nil {
+		result.Unresolved = []models.PlaylistSongData{}
+	}
+	slog.InfoContext(ctx, "Imported playlist", "id", result.Playlist.ID, "songs", len(result.Playlist.Songs), "unresolved", len(result.Unresolved))
+	return result, nil, http.StatusOK
+}
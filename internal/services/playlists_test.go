@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"test/internal/database"
+	"test/internal/models"
+	"testing"
+)
+
+func TestCreatePlaylist(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	database.On("InsertPlaylistQuery", aliceCtx, "alice", "Friday setlist", "").
+		Return(4, nil).
+		Twice()
+	result, err, status := service.CreatePlaylist(aliceCtx, "", "Friday setlist", "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, models.PlaylistData{ID: 4, Owner: "alice", Title: "Friday setlist"}, result)
+	_, err, status = service.CreatePlaylist(aliceCtx, " alice ", "Friday setlist", "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	_, err, status = service.CreatePlaylist(aliceCtx, "alice", " ", "")
+	assert.EqualError(t, err, "The owner and the title of the playlist are required")
+	assert.Equal(t, http.StatusBadRequest, status)
+	// only the callers managing users create the playlists of other users
+	_, err, status = service.CreatePlaylist(aliceCtx, "bob", "Friday setlist", "")
+	assert.EqualError(t, err, "The permission users:manage is required for the playlists of other users")
+	assert.Equal(t, http.StatusForbidden, status)
+	database.On("InsertPlaylistQuery", adminCtx, "bob", "Friday setlist", "").
+		Return(5, nil).
+		Once()
+	result, err, status = service.CreatePlaylist(adminCtx, "bob", "Friday setlist", "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "bob", result.Owner)
+	database.AssertExpectations(t)
+}
+
+func TestEditPlaylist_NotFound(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("UpdatePlaylistQuery", adminCtx, 9, "Friday setlist", "").
+		Return(database.ErrPlaylistNotFound).
+		Once()
+	err, status := service.EditPlaylist(adminCtx, 9, "Friday setlist", "")
+	assert.Equal(t, database.ErrPlaylistNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.On("SelectPlaylistOwnerQuery", aliceCtx, 9).
+		Return("", database.ErrPlaylistNotFound).
+		Once()
+	err, status = service.EditPlaylist(aliceCtx, 9, "Friday setlist", "")
+	assert.Equal(t, database.ErrPlaylistNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestPlaylists_OtherOwner(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	database.On("SelectPlaylistOwnerQuery", aliceCtx, 3).
+		Return("bob", nil)
+	err, status := service.EditPlaylist(aliceCtx, 3, "Friday setlist", "")
+	assert.EqualError(t, err, "The permission users:manage is required for the playlists of other users")
+	assert.Equal(t, http.StatusForbidden, status)
+	err, status = service.DeletePlaylist(aliceCtx, 3)
+	assert.Equal(t, http.StatusForbidden, status)
+	_, err, status = service.AddPlaylistSong(aliceCtx, 3, "Muse", "Uprising", 0)
+	assert.Equal(t, http.StatusForbidden, status)
+	_, err, status = service.RemovePlaylistSong(aliceCtx, 3, 1)
+	assert.Equal(t, http.StatusForbidden, status)
+	_, err, status = service.MovePlaylistSong(aliceCtx, 3, 2, 1)
+	assert.Equal(t, http.StatusForbidden, status)
+	database.AssertExpectations(t)
+	database.AssertNumberOfCalls(t, "SelectPlaylistOwnerQuery", 5)
+}
+
+func TestGetPlaylists(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	database.On("SelectPlaylistsQuery", context.Background(), "bob").
+		Return([]models.PlaylistData(nil), nil).
+		Once()
+	result, err, status := service.GetPlaylists(context.Background(), "bob")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, models.AnswerPlaylistsData{Items: []models.PlaylistData{}}, result)
+	database.AssertExpectations(t)
+}
+
+func TestAddPlaylistSong(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	playlist := models.PlaylistData{ID: 1, Owner: "alice", Title: "Friday setlist", Songs: []models.PlaylistSongData{{Position: 1, Group: "Muse", Song: "Uprising"}}}
+	mockdatabase.On("SelectPlaylistOwnerQuery", aliceCtx, 1).
+		Return("alice", nil).
+		Twice()
+	mockdatabase.On("InsertPlaylistSongQuery", aliceCtx, 1, "Muse", "Uprising", 0).
+		Return(1, nil).
+		Once()
+	mockdatabase.On("SelectPlaylistQuery", aliceCtx, 1).
+		Return(playlist, nil).
+		Once()
+	result, err, status := service.AddPlaylistSong(aliceCtx, 1, "Muse", "Uprising", 0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, playlist, result)
+	mockdatabase.On("InsertPlaylistSongQuery", aliceCtx, 1, "Muse", "Uprising", 5).
+		Return(0, fmt.Errorf("%w 5, the playlist has 1 songs", database.ErrInvalidPosition)).
+		Once()
+	_, err, status = service.AddPlaylistSong(aliceCtx, 1, "Muse", "Uprising", 5)
+	assert.EqualError(t, err, "Invalid position 5, the playlist has 1 songs")
+	assert.Equal(t, http.StatusBadRequest, status)
+	_, err, status = service.AddPlaylistSong(aliceCtx, 1, "Muse", "Uprising", -1)
+	assert.ErrorIs(t, err, database.ErrInvalidPosition)
+	assert.Equal(t, http.StatusBadRequest, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestMovePlaylistSong_Error(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	database.On("MovePlaylistSongQuery", adminCtx, 1, 2, 1).
+		Return(errors.New("Error moving song")).
+		Once()
+	_, err, status := service.MovePlaylistSong(adminCtx, 1, 2, 1)
+	assert.EqualError(t, err, "Error moving song")
+	assert.Equal(t, http.StatusInternalServerError, status)
+	database.AssertExpectations(t)
+}
+
+func TestExportPlaylist(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	database.On("SelectPlaylistQuery", context.Background(), 1).
+		Return(models.PlaylistData{ID: 1, Owner: "alice", Title: "Friday setlist", Songs: []models.PlaylistSongData{{Position: 1, Group: "Muse", Song: "Uprising"}}}, nil).
+		Once()
+	result, err, status := service.ExportPlaylist(context.Background(), 1, "m3u8")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "#EXTM3U\n#PLAYLIST:Friday setlist\n#EXTINF:-1,Muse - Uprising\nMuse - Uprising\n", result)
+	_, err, status = service.ExportPlaylist(context.Background(), 1, "pls")
+	assert.EqualError(t, err, "Unknown format \"pls\", expected m3u8 or xspf")
+	assert.Equal(t, http.StatusBadRequest, status)
+	database.AssertExpectations(t)
+}
+
+func TestImportPlaylist(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	file := "#EXTM3U\n#PLAYLIST:Friday setlist\n#EXTINF:-1,Muse - Uprising\nuprising.mp3\n"
+	entry := models.PlaylistSongData{Position: 1, Group: "Muse", Song: "Uprising", Link: "uprising.mp3"}
+	database.On("ImportPlaylistQuery", aliceCtx, models.PlaylistData{Owner: "alice", Title: "Encore", Songs: []models.PlaylistSongData{entry}}).
+		Return(models.PlaylistData{ID: 2, Owner: "alice", Title: "Encore"}, []models.PlaylistSongData{entry}, nil).
+		Once()
+	result, err, status := service.ImportPlaylist(aliceCtx, strings.NewReader(file), "m3u8", "", "Encore")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, models.PlaylistImportData{Playlist: models.PlaylistData{ID: 2, Owner: "alice", Title: "Encore"}, Unresolved: []models.PlaylistSongData{entry}}, result)
+	_, err, status = service.ImportPlaylist(aliceCtx, strings.NewReader(file), "m3u8", "bob", "")
+	assert.EqualError(t, err, "The permission users:manage is required for the playlists of other users")
+	assert.Equal(t, http.StatusForbidden, status)
+	_, err, status = service.ImportPlaylist(aliceCtx, strings.NewReader("#EXTM3U\n"), "m3u8", "", "")
+	assert.EqualError(t, err, "The owner and the title of the playlist are required")
+	assert.Equal(t, http.StatusBadRequest, status)
+	_, err, status = service.ImportPlaylist(aliceCtx, strings.NewReader("<playlist>"), "xspf", "alice", "")
+	assert.ErrorContains(t, err, "Invalid XSPF")
+	assert.Equal(t, http.StatusBadRequest, status)
+	database.AssertExpectations(t)
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"test/internal/database"
+	"test/internal/models"
+	"test/internal/tenant"
+)
+
+// GetRoles lists the roles with their permissions.
+func (s *Service) GetRoles(ctx context.Context) (result models.AnswerRolesData, err error, status int) {
+	result.Items, err = s.database.SelectRolesQuery(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get roles from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	if result.Items == nil {
+		result.Items = []models.RoleData{}
+	}
+	return result, nil, http.StatusOK
+}
+
+// SetRole creates the role or replaces its permissions, which have to be known. The roles are
+// shared by the tenants, so only the callers of the default tenant change them.
+func (s *Service) SetRole(ctx context.Context, name string, permissions []string) (err error, status int) {
+	if tenant.From(ctx) != tenant.Default {
+		return fmt.Errorf("The roles are only changed from the %s tenant", tenant.Default), http.StatusForbidden
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("The name of the role is required"), http.StatusBadRequest
+	}
+	for _, permission := range permissions {
+		if !slices.Contains(models.Permissions, permission) {
+			return fmt.Errorf("Unknown permission %q", permission), http.StatusBadRequest
+		}
+	}
+	permissions = slices.Clone(permissions)
+	slices.Sort(permissions)
+	permissions = slices.Compact(permissions)
+	err = s.database.UpsertRoleQuery(ctx, name, permissions)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set role in the database", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	slog.InfoContext(ctx, "Set role", "role", name, "permissions", strings.Join(permissions, " "))
+	return nil, http.StatusOK
+}
+
+// AssignRole gives the user the role.
+func (s *Service) AssignRole(ctx context.Context, user string, role string) (err error, status int) {
+	err = s.database.AssignRoleQuery(ctx, user, role)
+	if errors.Is(err, database.ErrUserNotFound) || errors.Is(err, database.ErrRoleNotFound) {
+		return err, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to assign role in the database", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	slog.InfoContext(ctx, "Assigned role", "role", role, "assignee", user)
+	return nil, http.StatusOK
+}
+
+// DeleteGroup deletes the group with all of its songs.
+func (s *Service) DeleteGroup(ctx context.Context, group string) (err error, status int) {
+	err = s.database.DeleteGroupQuery(ctx, group)
+	if errors.Is(err, database.ErrGroupNotFound) {
+		return err, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete group from the database", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	slog.InfoContext(ctx, "Deleted group", "group", group)
+	return nil, http.StatusOK
+}
+
+// RenameGroup renames the group, its songs keep their names.
+func (s *Service) RenameGroup(ctx context.Context, group string, name string) (err error, status int) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("The new name of the group is required"), http.StatusBadRequest
+	}
+	err = s.database.RenameGroupQuery(ctx, group, name)
+	if errors.Is(err, database.ErrGroupNotFound) {
+		return err, http.StatusNotFound
+	}
+	if errors.Is(err, database.ErrGroupExists) {
+		return err, http.StatusConflict
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to rename group in the database", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// GetGroups lists the groups of the library with the number of their songs.
+func (s *Service) GetGroups(ctx context.Context) (result []models.GroupData, err error, status int) {
+	result, err = s.database.SelectGroupsQuery(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get groups from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	if result == nil {
+		result = []models.GroupData{}
+	}
+	return result, nil, http.StatusOK
+}
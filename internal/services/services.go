@@ -3,13 +3,25 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
+	"test/internal/archive"
+	"test/internal/breaker"
+	"test/internal/chordpro"
 	"test/internal/database"
+	"test/internal/importer"
+	"test/internal/langdetect"
+	"test/internal/lrc"
+	"test/internal/lyrics"
 	"test/internal/models"
+	"time"
 )
 
 type httpClient interface {
@@ -26,74 +38,556 @@ func NewService(db database.Database, apiurl string, client httpClient) *Service
 	return &Service{database: db, apiurl: apiurl, client: client}
 }
 
-func (s *Service) AddSong(group string, song string) (err error, status int) {
+func (s *Service) AddSong(ctx context.Context, group string, song string) (err error, status int) {
+	reqdata, err, status := s.fetchInfo(ctx, group, song)
+	if err != nil {
+		return err, status
+	}
+	err = s.database.InsertQuery(ctx, group, song, reqdata.Date, reqdata.Text, reqdata.Link)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add song to the database", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	s.detectLanguage(ctx, group, song, reqdata.Text)
+	return nil, http.StatusOK
+}
+
+// EnrichSong fetches the details of a stored song from the metadata provider again and replaces
+// its release date, text and link with them, the details left empty by the provider are kept.
+func (s *Service) EnrichSong(ctx context.Context, group string, song string) (err error, status int) {
+	_, err = s.database.SelectTextQuery(ctx, group, song)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return database.ErrSongNotFound, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	reqdata, err, status := s.fetchInfo(ctx, group, song)
+	if err != nil {
+		return err, status
+	}
+	slog.InfoContext(ctx, "Enriched song", "group", group, "song", song)
+	return s.EditSong(ctx, group, song, reqdata.Date, reqdata.Text, reqdata.Link)
+}
+
+// fetchInfo gets the details of the song from the /info route of the metadata provider.
+func (s *Service) fetchInfo(ctx context.Context, group string, song string) (result models.AddResponseData, err error, status int) {
 	encodedGroup := url.QueryEscape(group)
 	encodedSong := url.QueryEscape(song)
 	urlStr := fmt.Sprintf("%s/info?group=%s&song=%s",
 		s.apiurl, encodedGroup, encodedSong)
-	log.Printf("INFO: Url for request: %s\n", urlStr)
-	req, err := http.NewRequest("GET", urlStr, nil)
+	slog.DebugContext(ctx, "Url for request", "url", urlStr)
+	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
-		log.Printf("ERROR: Failed to create API request: %v\n", err)
-		return err, http.StatusBadRequest
+		slog.ErrorContext(ctx, "Failed to create API request", "error", err)
+		return result, err, http.StatusBadRequest
 	}
 	resp, err := s.client.Do(req)
+	if errors.Is(err, breaker.ErrOpen) {
+		slog.WarnContext(ctx, "Skipped the request for additional song data", "error", breaker.ErrOpen)
+		return result, breaker.ErrOpen, http.StatusServiceUnavailable
+	}
 	if err != nil {
-		log.Printf("ERROR: Failed to get additional song data: %v\n", err)
-		return err, http.StatusInternalServerError
+		slog.ErrorContext(ctx, "Failed to get additional song data", "error", err)
+		return result, err, http.StatusInternalServerError
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("ERROR: Failed to read response body: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to read response body", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	slog.DebugContext(ctx, "Response body", "body", string(body))
+	if err = json.Unmarshal(body, &result); err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal response body", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// detectLanguage stores the language identified from the song text along with its confidence.
+// The song is already stored, so a failure is only logged and the song is left without a language.
+func (s *Service) detectLanguage(ctx context.Context, group string, song string, text string) {
+	lang, confidence := langdetect.Detect(text)
+	slog.InfoContext(ctx, "Detected language", "lang", lang, "confidence", confidence)
+	err := s.database.UpdateLanguageQuery(ctx, group, song, lang, confidence)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update song language in the database", "error", err)
+	}
+}
+
+// ImportSongs adds the songs of the file in the format with their detected languages and
+// reports the outcome of every row. Nothing is stored on a dry run.
+func (s *Service) ImportSongs(ctx context.Context, file io.Reader, format string, dryRun bool) (result models.ImportReportData, err error, status int) {
+	result.DryRun = dryRun
+	rows, err := importer.Parse(file, format)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse the imported songs", "error", err)
+		return result, err, http.StatusBadRequest
+	}
+	var valid []models.ImportRowData
+	for _, row := range rows {
+		if row.Status == models.ImportInvalid {
+			continue
+		}
+		row.Lang, row.LangConfidence = langdetect.Detect(row.Text)
+		valid = append(valid, row)
+	}
+	if len(valid) > 0 {
+		err = s.database.ImportQuery(ctx, valid, dryRun)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to import songs into the database", "error", err)
+			return result, err, http.StatusInternalServerError
+		}
+	}
+	for i := range rows {
+		if rows[i].Status != models.ImportInvalid {
+			rows[i], valid = valid[0], valid[1:]
+		}
+		switch rows[i].Status {
+		case models.ImportInserted:
+			result.Inserted++
+		case models.ImportSkipped:
+			result.Skipped++
+		case models.ImportConflicting:
+			result.Conflicting++
+		case models.ImportInvalid:
+			result.Invalid++
+		}
+	}
+	result.Rows = rows
+	if result.Rows == nil {
+		result.Rows = []models.ImportRowData{}
+	}
+	slog.InfoContext(ctx, "Imported songs", "inserted", result.Inserted, "skipped", result.Skipped, "conflicting", result.Conflicting, "invalid", result.Invalid, "dryRun", dryRun)
+	return result, nil, http.StatusOK
+}
+
+// DumpLibrary writes an archive of the whole library to w and returns its manifest. The archive
+// is streamed, the status is only meaningful when nothing was written.
+func (s *Service) DumpLibrary(ctx context.Context, w io.Writer) (result models.ManifestData, err error, status int) {
+	writer := archive.NewWriter(w, database.SchemaVersion, time.Now())
+	err = s.database.DumpQuery(ctx, writer)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to dump the library", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	result, err = writer.Close()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write the library archive", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// RestoreLibrary loads the archive into the library, replacing it or merging into it, and
+// returns the manifest of the archive.
+func (s *Service) RestoreLibrary(ctx context.Context, file io.ReaderAt, size int64, replace bool) (result models.ManifestData, err error, status int) {
+	reader, err := archive.NewReader(file, size)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read the library archive", "error", err)
+		return result, err, http.StatusBadRequest
+	}
+	result = reader.Manifest
+	if result.SchemaVersion > database.SchemaVersion {
+		err = fmt.Errorf("The archive was dumped from schema version %d, the database has version %d", result.SchemaVersion, database.SchemaVersion)
+		return result, err, http.StatusBadRequest
+	}
+	err = s.database.RestoreQuery(ctx, reader, replace)
+	var invalid *database.DumpError
+	if errors.As(err, &invalid) {
+		slog.InfoContext(ctx, "Rejected the library archive", "error", err)
+		return result, err, http.StatusBadRequest
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to restore the library", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+func (s *Service) DeleteSong(ctx context.Context, group string, song string) (err error, status int) {
+	err = s.database.DeleteQuery(ctx, group, song)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete song from the database", "error", err)
 		return err, http.StatusInternalServerError
 	}
-	log.Printf("DEBUG: Request body: %s\n", string(body))
-	var reqdata models.AddResponseData
-	if err = json.Unmarshal(body, &reqdata); err != nil {
-		log.Printf("ERROR: Failed to unmarshal response body: %v\n", err)
+	return nil, http.StatusOK
+}
+
+func (s *Service) EditSong(ctx context.Context, group string, song string, date string, text string, link string) (err error, status int) {
+	err = s.database.EditQuery(ctx, group, song, date, text, link)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to edit song in the database", "error", err)
 		return err, http.StatusInternalServerError
 	}
-	err = s.database.InsertQuery(context.Background(), group, song, reqdata.Date, reqdata.Text, reqdata.Link)
+	if text != "" {
+		s.detectLanguage(ctx, group, song, text)
+	}
+	return nil, http.StatusOK
+}
+
+func (s *Service) GetSongs(ctx context.Context, query models.SongsQuery) (result models.AnswerData, err error, status int) {
+	query.Filters, err = canonicalFilters(query.Filters)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse language", "error", err)
+		return result, err, http.StatusBadRequest
+	}
+	result, err = s.database.SelectDataQuery(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// StreamSongs passes the songs of the query to each as they are read, all of them when the
+// items are not limited. The status is only meaningful before the first song is passed.
+func (s *Service) StreamSongs(ctx context.Context, query models.SongsQuery, each func(models.RowDbData) error) (err error, status int) {
+	if query.Cursor != nil && query.Cursor.Backward {
+		return fmt.Errorf("Previous page cursors can not be exported"), http.StatusBadRequest
+	}
+	query.Filters, err = canonicalFilters(query.Filters)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse language", "error", err)
+		return err, http.StatusBadRequest
+	}
+	err = s.database.StreamDataQuery(ctx, query, each)
 	if err != nil {
-		log.Printf("ERROR: Failed to add song to the database: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to stream data from the database", "error", err)
 		return err, http.StatusInternalServerError
 	}
 	return nil, http.StatusOK
 }
 
-func (s *Service) DeleteSong(group string, song string) (err error, status int) {
-	err = s.database.DeleteQuery(context.Background(), group, song)
+// canonicalFilters returns a copy of the filters with the languages in the canonical form they
+// are stored in.
+func canonicalFilters(filters []models.FilterData) ([]models.FilterData, error) {
+	filters = append([]models.FilterData(nil), filters...)
+	for i, filter := range filters {
+		if filter.Field != "lang" {
+			continue
+		}
+		values := make([]string, len(filter.Values))
+		for j, value := range filter.Values {
+			var err error
+			values[j], err = lyrics.CanonicalLanguage(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		filters[i].Values = values
+	}
+	return filters, nil
+}
+
+func (s *Service) GetSongText(ctx context.Context, couplet int64, group string, song string, compact bool, langs []string, kind string) (result models.AnswerCoupletData, err error, status int) {
+	if !compact && len(langs) == 0 {
+		result, err = s.database.SelectCoupletQuery(ctx, group, song, couplet)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return result, database.ErrSongNotFound, http.StatusNotFound
+		}
+		if errors.Is(err, database.ErrCoupletNotFound) {
+			return result, err, http.StatusNotFound
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
+			return result, err, http.StatusInternalServerError
+		}
+		return result, nil, http.StatusOK
+	}
+	text, err := s.database.SelectTextQuery(ctx, group, song)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result, database.ErrSongNotFound, http.StatusNotFound
+	}
 	if err != nil {
-		log.Printf("ERROR: Failed to delete song from the database: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	verses := lyrics.SplitVerses(text)
+	structure := lyrics.Analyze(text)
+	if compact {
+		verses = lyrics.Compact(structure)
+	}
+	if couplet < 1 || couplet > int64(len(verses)) {
+		return result, database.ErrCoupletNotFound, http.StatusNotFound
+	}
+	result.Text = verses[couplet-1]
+	if len(langs) == 0 {
+		return result, nil, http.StatusOK
+	}
+	versions, err := s.database.SelectLyricsQuery(ctx, group, song)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	version, found := pickVersion(versions, langs, kind)
+	if !found {
+		return result, nil, http.StatusOK
+	}
+	result.Lang = version.Lang
+	result.Kind = version.Kind
+	if version.Kind == lyrics.KindOriginal {
+		return result, nil, http.StatusOK
+	}
+	if compact && structure.Sections[couplet-1].RepeatOf != 0 {
+		result.Translation = result.Text
+		return result, nil, http.StatusOK
+	}
+	translated := lyrics.SplitVerses(version.Text)
+	if couplet <= int64(len(translated)) {
+		result.Translation = translated[couplet-1]
+	}
+	return result, nil, http.StatusOK
+}
+
+// pickVersion chooses the version in the most preferred language, translations are
+// preferred to transliterations when the kind is not requested.
+func pickVersion(versions []models.LyricsData, langs []string, kind string) (models.LyricsData, bool) {
+	var candidates []models.LyricsData
+	for _, version := range versions {
+		if kind == "" || version.Kind == kind {
+			candidates = append(candidates, version)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return lyrics.KindRank(candidates[i].Kind) < lyrics.KindRank(candidates[j].Kind)
+	})
+	available := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		available[i] = candidate.Lang
+	}
+	index, found := lyrics.MatchLanguage(langs, available)
+	if !found {
+		return models.LyricsData{}, false
+	}
+	return candidates[index], true
+}
+
+func (s *Service) AddLyrics(ctx context.Context, group string, song string, lang string, kind string, text string) (err error, status int) {
+	lang, err = lyrics.CanonicalLanguage(lang)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	if !lyrics.ValidKind(kind) {
+		return fmt.Errorf("Invalid lyrics kind %q", kind), http.StatusBadRequest
+	}
+	err = s.database.UpsertLyricsQuery(ctx, group, song, lang, kind, text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return database.ErrSongNotFound, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add lyrics to the database", "error", err)
 		return err, http.StatusInternalServerError
 	}
 	return nil, http.StatusOK
 }
 
-func (s *Service) EditSong(group string, song string, date string, text string, link string) (err error, status int) {
-	err = s.database.EditQuery(context.Background(), group, song, date, text, link)
+func (s *Service) DeleteLyrics(ctx context.Context, group string, song string, lang string, kind string) (err error, status int) {
+	lang, err = lyrics.CanonicalLanguage(lang)
 	if err != nil {
-		log.Printf("ERROR: Failed to edit song in the database: %v\n", err)
+		return err, http.StatusBadRequest
+	}
+	err = s.database.DeleteLyricsQuery(ctx, group, song, lang, kind)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return database.ErrSongNotFound, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete lyrics from the database", "error", err)
 		return err, http.StatusInternalServerError
 	}
 	return nil, http.StatusOK
 }
 
-func (s *Service) GetSongs(page int64, items int64, group string, song string, date string, text string, link string) (result models.AnswerData, err error, status int) {
-	result, err = s.database.SelectDataQuery(context.Background(), page, items, group, song, date, text, link)
+func (s *Service) GetLyrics(ctx context.Context, group string, song string) (result models.AnswerLyricsData, err error, status int) {
+	result.Items, err = s.database.SelectLyricsQuery(ctx, group, song)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result, database.ErrSongNotFound, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+func (s *Service) GetSongStructure(ctx context.Context, group string, song string) (result models.AnswerStructureData, err error, status int) {
+	text, err := s.database.SelectTextQuery(ctx, group, song)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result, database.ErrSongNotFound, http.StatusNotFound
+	}
 	if err != nil {
-		log.Printf("ERROR: Failed to get data from the database: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
 		return result, err, http.StatusInternalServerError
 	}
+	structure := lyrics.Analyze(text)
+	compact := lyrics.Compact(structure)
+	for i, section := range structure.Sections {
+		result.Verses = append(result.Verses, models.VerseData{
+			Number:   section.Number,
+			Text:     compact[i],
+			RepeatOf: section.RepeatOf,
+			Exact:    section.Exact,
+		})
+	}
+	result.Unique = structure.Unique
+	result.Repeats = structure.Repeats
+	result.Hook = structure.Hook
 	return result, nil, http.StatusOK
 }
 
-func (s *Service) GetSongText(couplet int64, group string, song string) (result models.AnswerCoupletData, err error, status int) {
-	result, err = s.database.SelectCoupletQuery(context.Background(), group, song, couplet)
+func (s *Service) AddLrc(ctx context.Context, group string, song string, text string) (err error, status int) {
+	parsed, err := lrc.Parse(text)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse LRC", "error", err)
+		return err, http.StatusBadRequest
+	}
+	lines := make([]models.TimedLineData, len(parsed.Lines))
+	for i, line := range parsed.Lines {
+		lines[i] = models.TimedLineData{Time: line.Time, Text: line.Text}
+		for _, word := range line.Words {
+			lines[i].Words = append(lines[i].Words, models.TimedWordData{Time: word.Time, Text: word.Text})
+		}
+	}
+	err = s.database.InsertLinesQuery(ctx, group, song, lines)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return database.ErrSongNotFound, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add song lines to the database", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+func (s *Service) GetLrc(ctx context.Context, group string, song string) (result string, err error, status int) {
+	lines, err := s.database.SelectLinesQuery(ctx, group, song)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result, database.ErrSongNotFound, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	if len(lines) == 0 {
+		return result, fmt.Errorf("There are no timed lines for the song"), http.StatusNotFound
+	}
+	lyrics := lrc.Lyrics{Tags: []lrc.Tag{{Key: "ar", Value: group}, {Key: "ti", Value: song}}}
+	for _, line := range lines {
+		lyrics.Lines = append(lyrics.Lines, toLrcLine(line))
+	}
+	return lrc.Format(lyrics), nil, http.StatusOK
+}
+
+func (s *Service) GetSongLine(ctx context.Context, group string, song string, offset int64) (result models.AnswerLineData, err error, status int) {
+	lines, err := s.database.SelectLinesQuery(ctx, group, song)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result, database.ErrSongNotFound, http.StatusNotFound
+	}
 	if err != nil {
-		log.Printf("ERROR: Failed to get data from the database: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
 		return result, err, http.StatusInternalServerError
 	}
+	if len(lines) == 0 {
+		return result, fmt.Errorf("There are no timed lines for the song"), http.StatusNotFound
+	}
+	timed := make([]lrc.Line, len(lines))
+	for i, line := range lines {
+		timed[i] = toLrcLine(line)
+	}
+	index := lrc.LineAt(timed, offset)
+	if index >= 0 {
+		result.Time = lines[index].Time
+		result.Text = lines[index].Text
+		result.Words = lines[index].Words
+	}
+	result.Index = index + 1
+	if index+1 < len(lines) {
+		result.NextTime = &lines[index+1].Time
+	}
 	return result, nil, http.StatusOK
 }
+
+func (s *Service) AddChords(ctx context.Context, group string, song string, source string) (err error, status int) {
+	lines, err := chordpro.Parse(source)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse ChordPro", "error", err)
+		return err, http.StatusBadRequest
+	}
+	var chords []models.ChordLineData
+	for i, line := range lines {
+		if len(line.Chords) == 0 {
+			continue
+		}
+		data := models.ChordLineData{Line: i + 1}
+		for _, chord := range line.Chords {
+			data.Chords = append(data.Chords, models.ChordData{Position: chord.Position, Name: chord.Name})
+		}
+		chords = append(chords, data)
+	}
+	text := chordpro.Lyrics(lines)
+	err = s.database.InsertChordsQuery(ctx, group, song, text, chords)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return database.ErrSongNotFound, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add chords to the database", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	s.detectLanguage(ctx, group, song, text)
+	return nil, http.StatusOK
+}
+
+// GetChords renders the song text with the stored chords over its lines, either as plain
+// text or in the ChordPro format, transposed by the number of semitones.
+func (s *Service) GetChords(ctx context.Context, group string, song string, transpose int, format string) (result string, err error, status int) {
+	if format != "text" && format != "chordpro" {
+		return result, fmt.Errorf("Unknown format %q", format), http.StatusBadRequest
+	}
+	chords, err := s.database.SelectChordsQuery(ctx, group, song)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result, database.ErrSongNotFound, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	if len(chords) == 0 {
+		return result, fmt.Errorf("There are no chords for the song"), http.StatusNotFound
+	}
+	text, err := s.database.SelectTextQuery(ctx, group, song)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result, database.ErrSongNotFound, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get data from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	var lines []chordpro.Line
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, chordpro.Line{Text: line})
+	}
+	for _, line := range chords {
+		// the text may have been edited since, chords past its end are dropped
+		if line.Line < 1 || line.Line > len(lines) {
+			continue
+		}
+		for _, chord := range line.Chords {
+			lines[line.Line-1].Chords = append(lines[line.Line-1].Chords, chordpro.Chord{Position: chord.Position, Name: chord.Name})
+		}
+	}
+	lines = chordpro.Transpose(lines, transpose)
+	if format == "chordpro" {
+		return fmt.Sprintf("{title: %s}\n{artist: %s}\n%s\n", song, group, chordpro.Format(lines)), nil, http.StatusOK
+	}
+	return chordpro.Render(lines) + "\n", nil, http.StatusOK
+}
+
+func toLrcLine(line models.TimedLineData) lrc.Line {
+	result := lrc.Line{Time: line.Time, Text: line.Text}
+	for _, word := range line.Words {
+		result.Words = append(result.Words, lrc.Word{Time: word.Time, Text: word.Text})
+	}
+	return result
+}
@@ -5,13 +5,22 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
+	"test/internal/archive"
+	"test/internal/breaker"
+	"test/internal/database"
+	"test/internal/langdetect"
 	"test/internal/models"
 	"testing"
+	"time"
 )
 
 type MockDatabase struct {
@@ -37,21 +46,202 @@ func (m *MockDatabase) DeleteQuery(ctx context.Context, group_name string, song_
 	return args.Error(0)
 }
 
-func (m *MockDatabase) SelectDataQuery(ctx context.Context, page int64, items int64, group string, song string, releaseDate string, text string, link string) (models.AnswerData, error) {
-	args := m.Called(ctx, page, items, group, song, releaseDate, text, link)
+func (m *MockDatabase) SelectDataQuery(ctx context.Context, songs models.SongsQuery) (models.AnswerData, error) {
+	args := m.Called(ctx, songs)
 	return args.Get(0).(models.AnswerData), args.Error(1)
 }
 
+// StreamDataQuery passes the rows given to Return to each before returning the error.
+func (m *MockDatabase) StreamDataQuery(ctx context.Context, songs models.SongsQuery, each func(models.RowDbData) error) error {
+	args := m.Called(ctx, songs)
+	for _, row := range args.Get(0).([]models.RowDbData) {
+		if err := each(row); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockDatabase) ImportQuery(ctx context.Context, rows []models.ImportRowData, dryRun bool) error {
+	args := m.Called(ctx, rows, dryRun)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) DumpQuery(ctx context.Context, dump database.DumpWriter) error {
+	args := m.Called(ctx, dump)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) RestoreQuery(ctx context.Context, dump database.DumpReader, replace bool) error {
+	args := m.Called(ctx, dump, replace)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) InsertUserQuery(ctx context.Context, name string, role string) (int, error) {
+	args := m.Called(ctx, name, role)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDatabase) InsertKeyQuery(ctx context.Context, user string, prefix string, hash string) (models.APIKeyData, error) {
+	args := m.Called(ctx, user, prefix, hash)
+	return args.Get(0).(models.APIKeyData), args.Error(1)
+}
+
+func (m *MockDatabase) RotateKeyQuery(ctx context.Context, id int, user string, prefix string, hash string) (models.APIKeyData, error) {
+	args := m.Called(ctx, id, user, prefix, hash)
+	return args.Get(0).(models.APIKeyData), args.Error(1)
+}
+
+func (m *MockDatabase) RevokeKeyQuery(ctx context.Context, id int, user string) error {
+	args := m.Called(ctx, id, user)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) SelectKeysQuery(ctx context.Context, user string) ([]models.APIKeyData, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).([]models.APIKeyData), args.Error(1)
+}
+
+func (m *MockDatabase) SelectRolesQuery(ctx context.Context) ([]models.RoleData, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.RoleData), args.Error(1)
+}
+
+func (m *MockDatabase) UpsertRoleQuery(ctx context.Context, name string, permissions []string) error {
+	args := m.Called(ctx, name, permissions)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) AssignRoleQuery(ctx context.Context, user string, role string) error {
+	args := m.Called(ctx, user, role)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) DeleteGroupQuery(ctx context.Context, group string) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) RenameGroupQuery(ctx context.Context, group string, name string) error {
+	args := m.Called(ctx, group, name)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) SelectGroupsQuery(ctx context.Context) ([]models.GroupData, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.GroupData), args.Error(1)
+}
+
+func (m *MockDatabase) SelectPrincipalQuery(ctx context.Context, hash string) (models.PrincipalData, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(models.PrincipalData), args.Error(1)
+}
+
+func (m *MockDatabase) InsertPlaylistQuery(ctx context.Context, owner string, title string, description string) (int, error) {
+	args := m.Called(ctx, owner, title, description)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDatabase) UpdatePlaylistQuery(ctx context.Context, id int, title string, description string) error {
+	args := m.Called(ctx, id, title, description)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) DeletePlaylistQuery(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) SelectPlaylistQuery(ctx context.Context, id int) (models.PlaylistData, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.PlaylistData), args.Error(1)
+}
+
+func (m *MockDatabase) SelectPlaylistOwnerQuery(ctx context.Context, id int) (string, error) {
+	args := m.Called(ctx, id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockDatabase) SelectPlaylistsQuery(ctx context.Context, owner string) ([]models.PlaylistData, error) {
+	args := m.Called(ctx, owner)
+	return args.Get(0).([]models.PlaylistData), args.Error(1)
+}
+
+func (m *MockDatabase) InsertPlaylistSongQuery(ctx context.Context, id int, group string, song string, position int) (int, error) {
+	args := m.Called(ctx, id, group, song, position)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDatabase) DeletePlaylistSongQuery(ctx context.Context, id int, position int) error {
+	args := m.Called(ctx, id, position)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) MovePlaylistSongQuery(ctx context.Context, id int, from int, to int) error {
+	args := m.Called(ctx, id, from, to)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) ImportPlaylistQuery(ctx context.Context, playlist models.PlaylistData) (models.PlaylistData, []models.PlaylistSongData, error) {
+	args := m.Called(ctx, playlist)
+	return args.Get(0).(models.PlaylistData), args.Get(1).([]models.PlaylistSongData), args.Error(2)
+}
+
 func (m *MockDatabase) SelectCoupletQuery(ctx context.Context, group string, song string, couplet int64) (models.AnswerCoupletData, error) {
 	args := m.Called(ctx, group, song, couplet)
 	return args.Get(0).(models.AnswerCoupletData), args.Error(1)
 }
 
+func (m *MockDatabase) SelectTextQuery(ctx context.Context, group string, song string) (string, error) {
+	args := m.Called(ctx, group, song)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockDatabase) EditQuery(ctx context.Context, group_name string, song_name string, releaseDate string, text string, link string) error {
 	args := m.Called(ctx, group_name, song_name, releaseDate, text, link)
 	return args.Error(0)
 }
 
+func (m *MockDatabase) UpdateLanguageQuery(ctx context.Context, group_name string, song_name string, lang string, confidence float64) error {
+	args := m.Called(ctx, group_name, song_name, lang, confidence)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) InsertLinesQuery(ctx context.Context, group string, song string, lines []models.TimedLineData) error {
+	args := m.Called(ctx, group, song, lines)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) SelectLinesQuery(ctx context.Context, group string, song string) ([]models.TimedLineData, error) {
+	args := m.Called(ctx, group, song)
+	return args.Get(0).([]models.TimedLineData), args.Error(1)
+}
+
+func (m *MockDatabase) InsertChordsQuery(ctx context.Context, group string, song string, text string, chords []models.ChordLineData) error {
+	args := m.Called(ctx, group, song, text, chords)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) SelectChordsQuery(ctx context.Context, group string, song string) ([]models.ChordLineData, error) {
+	args := m.Called(ctx, group, song)
+	return args.Get(0).([]models.ChordLineData), args.Error(1)
+}
+
+func (m *MockDatabase) UpsertLyricsQuery(ctx context.Context, group string, song string, lang string, kind string, text string) error {
+	args := m.Called(ctx, group, song, lang, kind, text)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) DeleteLyricsQuery(ctx context.Context, group string, song string, lang string, kind string) error {
+	args := m.Called(ctx, group, song, lang, kind)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) SelectLyricsQuery(ctx context.Context, group string, song string) ([]models.LyricsData, error) {
+	args := m.Called(ctx, group, song)
+	return args.Get(0).([]models.LyricsData), args.Error(1)
+}
+
 type MockHttpClient struct {
 	mock.Mock
 }
@@ -90,7 +280,10 @@ func TestAddSong(t *testing.T) {
 	database.On("InsertQuery", context.Background(), group, song, responseData.Date, responseData.Text, responseData.Link).
 		Return(nil).
 		Once()
-	err, status := service.AddSong(group, song)
+	database.On("UpdateLanguageQuery", context.Background(), group, song, "en", mock.AnythingOfType("float64")).
+		Return(nil).
+		Once()
+	err, status := service.AddSong(context.Background(), group, song)
 	assert.Equal(t, nil, err)
 	assert.Equal(t, http.StatusOK, status)
 	database.AssertExpectations(t)
@@ -103,7 +296,7 @@ func TestAddSong_NewRequestError(t *testing.T) {
 	service := NewService(database, "://", client)
 	group := "Muse"
 	song := "Supermassive Black Hole"
-	err, status := service.AddSong(group, song)
+	err, status := service.AddSong(context.Background(), group, song)
 	log.Println(err)
 	assert.EqualError(t, err, `parse ":///info?group=Muse&song=Supermassive+Black+Hole": missing protocol scheme`)
 	assert.Equal(t, http.StatusBadRequest, status)
@@ -121,12 +314,25 @@ func TestAddSong_DoRequestError(t *testing.T) {
 		StatusCode: http.StatusInternalServerError,
 	}, errors.New("Error doing request")).
 		Once()
-	err, status := service.AddSong(group, song)
+	err, status := service.AddSong(context.Background(), group, song)
 	assert.Equal(t, errors.New("Error doing request"), err)
 	assert.Equal(t, http.StatusInternalServerError, status)
 	client.AssertExpectations(t)
 }
 
+func TestAddSong_CircuitOpen(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	client.On("Do", mock.Anything).Return((*http.Response)(nil), &url.Error{Op: "Get", URL: "http://localhost:8080/info", Err: breaker.ErrOpen}).
+		Once()
+	err, status := service.AddSong(context.Background(), "Muse", "Supermassive Black Hole")
+	assert.Equal(t, breaker.ErrOpen, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	client.AssertExpectations(t)
+	database.AssertExpectations(t)
+}
+
 type errReader struct{}
 
 func (e *errReader) Read(p []byte) (n int, err error) {
@@ -150,7 +356,7 @@ func TestAddSong_ReadRespBodyError(t *testing.T) {
 		Body:       &errReader{},
 	}, nil).
 		Once()
-	err, status := service.AddSong(group, song)
+	err, status := service.AddSong(context.Background(), group, song)
 	assert.Equal(t, errors.New("error reading body"), err)
 	assert.Equal(t, http.StatusInternalServerError, status)
 	client.AssertExpectations(t)
@@ -170,7 +376,7 @@ func TestAddSong_UnmarshalRespBodyError(t *testing.T) {
 		Body:       io.NopCloser(bytes.NewReader([]byte(invalidJSON))),
 	}, nil).
 		Once()
-	err, status := service.AddSong(group, song)
+	err, status := service.AddSong(context.Background(), group, song)
 	assert.EqualError(t, err, "unexpected end of JSON input")
 	assert.Equal(t, http.StatusInternalServerError, status)
 	client.AssertExpectations(t)
@@ -201,13 +407,65 @@ func TestAddSong_InsertQuerryError(t *testing.T) {
 	database.On("InsertQuery", context.Background(), group, song, responseData.Date, responseData.Text, responseData.Link).
 		Return(errors.New("Error inserting song")).
 		Once()
-	err, status := service.AddSong(group, song)
+	err, status := service.AddSong(context.Background(), group, song)
 	assert.Equal(t, errors.New("Error inserting song"), err)
 	assert.Equal(t, http.StatusInternalServerError, status)
 	database.AssertExpectations(t)
 	client.AssertExpectations(t)
 }
 
+func TestEnrichSong(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	responseData := models.AddResponseData{
+		Date: "19.06.2006",
+		Text: "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?",
+		Link: "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	}
+	jsonData, err := json.Marshal(responseData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.On("SelectTextQuery", context.Background(), group, song).
+		Return("Ooh baby", nil).
+		Once()
+	client.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Path == "/info" && req.URL.RawQuery == "group=Muse&song=Supermassive+Black+Hole"
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(jsonData)),
+	}, nil).
+		Once()
+	database.On("EditQuery", context.Background(), group, song, responseData.Date, responseData.Text, responseData.Link).
+		Return(nil).
+		Once()
+	database.On("UpdateLanguageQuery", context.Background(), group, song, "en", mock.AnythingOfType("float64")).
+		Return(nil).
+		Once()
+	err, status := service.EnrichSong(context.Background(), group, song)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	database.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestEnrichSong_NotFound(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("SelectTextQuery", context.Background(), "Muse", "Nothing").
+		Return("", pgx.ErrNoRows).
+		Once()
+	err, status := service.EnrichSong(context.Background(), "Muse", "Nothing")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+	client.AssertNotCalled(t, "Do", mock.Anything)
+}
+
 func TestDeleteSong(t *testing.T) {
 	database := NewMockDatabase()
 	client := NewMockHttpClient()
@@ -217,7 +475,7 @@ func TestDeleteSong(t *testing.T) {
 	database.On("DeleteQuery", context.Background(), group, song).
 		Return(nil).
 		Once()
-	err, status := service.DeleteSong(group, song)
+	err, status := service.DeleteSong(context.Background(), group, song)
 	assert.Equal(t, nil, err)
 	assert.Equal(t, http.StatusOK, status)
 	database.AssertExpectations(t)
@@ -232,7 +490,7 @@ func TestDeleteSong_DeleteQueryError(t *testing.T) {
 	database.On("DeleteQuery", context.Background(), group, song).
 		Return(errors.New("Error deleting song")).
 		Once()
-	err, status := service.DeleteSong(group, song)
+	err, status := service.DeleteSong(context.Background(), group, song)
 	assert.Equal(t, errors.New("Error deleting song"), err)
 	assert.Equal(t, http.StatusInternalServerError, status)
 	database.AssertExpectations(t)
@@ -250,7 +508,46 @@ func TestEditSong(t *testing.T) {
 	database.On("EditQuery", context.Background(), group, song, date, text, link).
 		Return(nil).
 		Once()
-	err, status := service.EditSong(group, song, date, text, link)
+	database.On("UpdateLanguageQuery", context.Background(), group, song, "en", mock.AnythingOfType("float64")).
+		Return(nil).
+		Once()
+	err, status := service.EditSong(context.Background(), group, song, date, text, link)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	database.AssertExpectations(t)
+}
+
+func TestEditSong_WithoutText(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	link := "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+	database.On("EditQuery", context.Background(), group, song, "", "", link).
+		Return(nil).
+		Once()
+	err, status := service.EditSong(context.Background(), group, song, "", "", link)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	database.AssertExpectations(t)
+}
+
+func TestEditSong_UpdateLanguageQueryError(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "Группа крови на рукаве, мой порядковый номер на рукаве"
+	database.On("EditQuery", context.Background(), group, song, "", text, "").
+		Return(nil).
+		Once()
+	database.On("UpdateLanguageQuery", context.Background(), group, song, "ru", mock.AnythingOfType("float64")).
+		Return(errors.New("Error updating language")).
+		Once()
+	// the song is edited, it only misses its language
+	err, status := service.EditSong(context.Background(), group, song, "", text, "")
 	assert.Equal(t, nil, err)
 	assert.Equal(t, http.StatusOK, status)
 	database.AssertExpectations(t)
@@ -268,7 +565,7 @@ func TestEditSong_EditQueryError(t *testing.T) {
 	database.On("EditQuery", context.Background(), group, song, date, text, link).
 		Return(errors.New("Error editing song")).
 		Once()
-	err, status := service.EditSong(group, song, date, text, link)
+	err, status := service.EditSong(context.Background(), group, song, date, text, link)
 	assert.Equal(t, errors.New("Error editing song"), err)
 	assert.Equal(t, http.StatusInternalServerError, status)
 	database.AssertExpectations(t)
@@ -278,19 +575,30 @@ func TestGetSongs(t *testing.T) {
 	database := NewMockDatabase()
 	client := NewMockHttpClient()
 	service := NewService(database, "http://localhost:8080", client)
-	page := int64(1)
-	items := int64(1)
-	group := "Muse"
-	song := "Supermassive Black Hole"
-	date := "16.07.2006"
-	text := "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
-	link := "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
-	database.On("SelectDataQuery", context.Background(), page, items, group, song, date, text, link).
+	query := models.SongsQuery{
+		Page:  1,
+		Items: 1,
+		Filters: []models.FilterData{
+			{Field: "group", Operator: models.OperatorEq, Values: []string{"Muse"}},
+			{Field: "lang", Operator: models.OperatorIn, Values: []string{"EN", "ru"}},
+		},
+		Sort: []models.SortData{{Field: "releaseDate", Desc: true}},
+	}
+	database.On("SelectDataQuery", context.Background(), models.SongsQuery{
+		Page:  1,
+		Items: 1,
+		Filters: []models.FilterData{
+			{Field: "group", Operator: models.OperatorEq, Values: []string{"Muse"}},
+			{Field: "lang", Operator: models.OperatorIn, Values: []string{"en", "ru"}},
+		},
+		Sort: []models.SortData{{Field: "releaseDate", Desc: true}},
+	}).
 		Return(models.AnswerData{}, nil).
 		Once()
-	_, err, status := service.GetSongs(page, items, group, song, date, text, link)
+	_, err, status := service.GetSongs(context.Background(), query)
 	assert.Equal(t, nil, err)
 	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"EN", "ru"}, query.Filters[1].Values)
 	database.AssertExpectations(t)
 }
 
@@ -298,22 +606,192 @@ func TestGetSongs_SelectDataQueryError(t *testing.T) {
 	database := NewMockDatabase()
 	client := NewMockHttpClient()
 	service := NewService(database, "http://localhost:8080", client)
-	page := int64(1)
-	items := int64(1)
-	group := "Muse"
-	song := "Supermassive Black Hole"
-	date := "16.07.2006"
-	text := "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
-	link := "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
-	database.On("SelectDataQuery", context.Background(), page, items, group, song, date, text, link).
+	query := models.SongsQuery{Page: 1, Items: 1}
+	database.On("SelectDataQuery", context.Background(), query).
 		Return(models.AnswerData{}, errors.New("Error selecting data")).
 		Once()
-	_, err, status := service.GetSongs(page, items, group, song, date, text, link)
+	_, err, status := service.GetSongs(context.Background(), query)
 	assert.Equal(t, errors.New("Error selecting data"), err)
 	assert.Equal(t, http.StatusInternalServerError, status)
 	database.AssertExpectations(t)
 }
 
+func TestGetSongs_InvalidLanguage(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	_, err, status := service.GetSongs(context.Background(), models.SongsQuery{Page: 1, Items: 1, Filters: []models.FilterData{{Field: "lang", Operator: models.OperatorEq, Values: []string{"e"}}}})
+	assert.EqualError(t, err, "Invalid language tag \"e\"")
+	assert.Equal(t, http.StatusBadRequest, status)
+	database.AssertExpectations(t)
+}
+
+func TestImportSongs(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	file := "group,song,text\nMuse,Uprising,\"Paranoia is in bloom, the PR transmissions will resume\"\n,Hysteria,\nMuse,Starlight,\n"
+	_, confidence := langdetect.Detect("Paranoia is in bloom, the PR transmissions will resume")
+	database.On("ImportQuery", context.Background(), []models.ImportRowData{
+		{Row: 1, Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom, the PR transmissions will resume", Lang: "en", LangConfidence: confidence},
+		{Row: 3, Group: "Muse", Song: "Starlight"},
+	}, true).
+		Run(func(args mock.Arguments) {
+			rows := args.Get(1).([]models.ImportRowData)
+			rows[0].Status = models.ImportInserted
+			rows[1].Status, rows[1].Error = models.ImportConflicting, "The song is stored with other data"
+		}).
+		Return(nil).
+		Once()
+	result, err, status := service.ImportSongs(context.Background(), strings.NewReader(file), "csv", true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, models.ImportReportData{
+		DryRun:      true,
+		Inserted:    1,
+		Conflicting: 1,
+		Invalid:     1,
+		Rows: []models.ImportRowData{
+			{Row: 1, Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom, the PR transmissions will resume", Lang: "en", LangConfidence: confidence, Status: models.ImportInserted},
+			{Row: 2, Song: "Hysteria", Status: models.ImportInvalid, Error: "The group is required"},
+			{Row: 3, Group: "Muse", Song: "Starlight", Status: models.ImportConflicting, Error: "The song is stored with other data"},
+		},
+	}, result)
+	database.AssertExpectations(t)
+}
+
+func TestImportSongs_Errors(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	_, err, status := service.ImportSongs(context.Background(), strings.NewReader("[{"), "json", false)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, status)
+	database.On("ImportQuery", context.Background(), []models.ImportRowData{{Row: 1, Group: "Muse", Song: "Uprising"}}, false).
+		Return(errors.New("Error copying data")).
+		Once()
+	_, err, status = service.ImportSongs(context.Background(), strings.NewReader(`{"group": "Muse", "song": "Uprising"}`), "ndjson", false)
+	assert.EqualError(t, err, "Error copying data")
+	assert.Equal(t, http.StatusInternalServerError, status)
+	database.AssertExpectations(t)
+}
+
+func TestDumpLibrary(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	database.On("DumpQuery", context.Background(), mock.Anything).
+		Run(func(args mock.Arguments) {
+			dump := args.Get(1).(*archive.Writer)
+			dump.Section("groups")
+			dump.Record(models.DumpGroupData{Name: "Muse"})
+			dump.Section("songs")
+		}).
+		Return(nil).
+		Once()
+	var buffer bytes.Buffer
+	result, err, status := service.DumpLibrary(context.Background(), &buffer)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "song-library", result.Format)
+	reader, err := archive.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.NoError(t, err)
+	assert.Equal(t, result, reader.Manifest)
+	database.AssertExpectations(t)
+}
+
+func TestRestoreLibrary(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	var buffer bytes.Buffer
+	writer := archive.NewWriter(&buffer, 1, time.Now())
+	writer.Section("groups")
+	writer.Record(models.DumpGroupData{Name: "Muse"})
+	manifest, _ := writer.Close()
+	database.On("RestoreQuery", context.Background(), mock.Anything, true).
+		Return(nil).
+		Once()
+	result, err, status := service.RestoreLibrary(context.Background(), bytes.NewReader(buffer.Bytes()), int64(buffer.Len()), true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, manifest.Files, result.Files)
+	database.AssertExpectations(t)
+}
+
+func TestRestoreLibrary_Errors(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	_, err, status := service.RestoreLibrary(context.Background(), strings.NewReader("not a zip"), 9, false)
+	assert.ErrorContains(t, err, "Invalid archive")
+	assert.Equal(t, http.StatusBadRequest, status)
+	var buffer bytes.Buffer
+	writer := archive.NewWriter(&buffer, 1000, time.Now())
+	writer.Close()
+	_, err, status = service.RestoreLibrary(context.Background(), bytes.NewReader(buffer.Bytes()), int64(buffer.Len()), false)
+	assert.EqualError(t, err, "The archive was dumped from schema version 1000, the database has version 4")
+	assert.Equal(t, http.StatusBadRequest, status)
+	buffer.Reset()
+	writer = archive.NewWriter(&buffer, 4, time.Now())
+	writer.Section("songs")
+	writer.Record(models.DumpSongData{Group: "Muse", Song: "Uprising"})
+	writer.Close()
+	mockdatabase.On("RestoreQuery", context.Background(), mock.Anything, false).
+		Return(fmt.Errorf("Failed to restore songs: %w", &database.DumpError{Err: errors.New("The group \"Muse\" of the song \"Uprising\" is missing")})).
+		Once()
+	_, err, status = service.RestoreLibrary(context.Background(), bytes.NewReader(buffer.Bytes()), int64(buffer.Len()), false)
+	assert.EqualError(t, err, "Failed to restore songs: The group \"Muse\" of the song \"Uprising\" is missing")
+	assert.Equal(t, http.StatusBadRequest, status)
+	mockdatabase.On("RestoreQuery", context.Background(), mock.Anything, false).
+		Return(errors.New("Connection refused")).
+		Once()
+	_, err, status = service.RestoreLibrary(context.Background(), bytes.NewReader(buffer.Bytes()), int64(buffer.Len()), false)
+	assert.EqualError(t, err, "Connection refused")
+	assert.Equal(t, http.StatusInternalServerError, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestStreamSongs(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	rows := []models.RowDbData{{Group: "Muse", Song: "Uprising"}, {Group: "Muse", Song: "Supermassive Black Hole"}}
+	database.On("StreamDataQuery", context.Background(), models.SongsQuery{
+		Filters: []models.FilterData{{Field: "lang", Operator: models.OperatorEq, Values: []string{"en"}}},
+	}).
+		Return(rows, nil).
+		Once()
+	var streamed []models.RowDbData
+	err, status := service.StreamSongs(context.Background(), models.SongsQuery{
+		Filters: []models.FilterData{{Field: "lang", Operator: models.OperatorEq, Values: []string{"EN"}}},
+	}, func(row models.RowDbData) error {
+		streamed = append(streamed, row)
+		return nil
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, rows, streamed)
+	database.AssertExpectations(t)
+}
+
+func TestStreamSongs_Errors(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	database.On("StreamDataQuery", context.Background(), models.SongsQuery{}).
+		Return([]models.RowDbData{}, errors.New("Error selecting data")).
+		Once()
+	each := func(row models.RowDbData) error { return nil }
+	err, status := service.StreamSongs(context.Background(), models.SongsQuery{}, each)
+	assert.EqualError(t, err, "Error selecting data")
+	assert.Equal(t, http.StatusInternalServerError, status)
+	err, status = service.StreamSongs(context.Background(), models.SongsQuery{Items: 1, Cursor: &models.CursorData{ID: 1, Backward: true}}, each)
+	assert.EqualError(t, err, "Previous page cursors can not be exported")
+	assert.Equal(t, http.StatusBadRequest, status)
+	database.AssertExpectations(t)
+}
+
 func TestGetSongText(t *testing.T) {
 	database := NewMockDatabase()
 	client := NewMockHttpClient()
@@ -324,7 +802,7 @@ func TestGetSongText(t *testing.T) {
 	database.On("SelectCoupletQuery", context.Background(), group, song, couplet).
 		Return(models.AnswerCoupletData{}, nil).
 		Once()
-	_, err, status := service.GetSongText(couplet, group, song)
+	_, err, status := service.GetSongText(context.Background(), couplet, group, song, false, nil, "")
 	assert.Equal(t, nil, err)
 	assert.Equal(t, http.StatusOK, status)
 	database.AssertExpectations(t)
@@ -340,8 +818,550 @@ func TestGetSongText_SelectCoupletQueryError(t *testing.T) {
 	database.On("SelectCoupletQuery", context.Background(), group, song, couplet).
 		Return(models.AnswerCoupletData{}, errors.New("Error selecting data")).
 		Once()
-	_, err, status := service.GetSongText(couplet, group, song)
+	_, err, status := service.GetSongText(context.Background(), couplet, group, song, false, nil, "")
 	assert.Equal(t, errors.New("Error selecting data"), err)
 	assert.Equal(t, http.StatusInternalServerError, status)
 	database.AssertExpectations(t)
 }
+
+func TestGetSongText_Compact(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	couplet := int64(3)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "Ooh baby, don't you know I suffer?\n\nOoh\nYou set my soul alight\n\nOoh\nYou set my soul alight"
+	database.On("SelectTextQuery", context.Background(), group, song).
+		Return(text, nil).
+		Once()
+	result, err, status := service.GetSongText(context.Background(), couplet, group, song, true, nil, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "[Repeat verse 2]", result.Text)
+	database.AssertExpectations(t)
+}
+
+func TestGetSongText_CompactNoSuchCouplet(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	couplet := int64(4)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "Ooh baby, don't you know I suffer?\n\nOoh\nYou set my soul alight\n\nOoh\nYou set my soul alight"
+	mockdatabase.On("SelectTextQuery", context.Background(), group, song).
+		Return(text, nil).
+		Once()
+	_, err, status := service.GetSongText(context.Background(), couplet, group, song, true, nil, "")
+	assert.Equal(t, database.ErrCoupletNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetSongText_CompactNoSuchSong(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("SelectTextQuery", context.Background(), "Muse", "Unknown").
+		Return("", pgx.ErrNoRows).
+		Once()
+	_, err, status := service.GetSongText(context.Background(), 1, "Muse", "Unknown", true, nil, "")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetSongText_NoSuchSong(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("SelectCoupletQuery", context.Background(), "Muse", "Unknown", int64(1)).
+		Return(models.AnswerCoupletData{}, pgx.ErrNoRows).
+		Once()
+	_, err, status := service.GetSongText(context.Background(), 1, "Muse", "Unknown", false, nil, "")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetSongText_NoSuchCouplet(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("SelectCoupletQuery", context.Background(), "Muse", "Uprising", int64(9)).
+		Return(models.AnswerCoupletData{}, database.ErrCoupletNotFound).
+		Once()
+	_, err, status := service.GetSongText(context.Background(), 9, "Muse", "Uprising", false, nil, "")
+	assert.Equal(t, database.ErrCoupletNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetSongStructure(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "Ooh baby, don't you know I suffer?\n\nOoh\nYou set my soul alight\n\nOoh\nYou set my soul alight"
+	database.On("SelectTextQuery", context.Background(), group, song).
+		Return(text, nil).
+		Once()
+	result, err, status := service.GetSongStructure(context.Background(), group, song)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, models.AnswerStructureData{
+		Verses: []models.VerseData{
+			{Number: 1, Text: "Ooh baby, don't you know I suffer?"},
+			{Number: 2, Text: "Ooh\nYou set my soul alight"},
+			{Number: 3, Text: "[Repeat verse 2]", RepeatOf: 2, Exact: true},
+		},
+		Unique:  2,
+		Repeats: 1,
+		Hook:    2,
+	}, result)
+	database.AssertExpectations(t)
+}
+
+func TestGetSongStructure_SelectTextQueryError(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("SelectTextQuery", context.Background(), group, song).
+		Return("", errors.New("Error selecting data")).
+		Once()
+	_, err, status := service.GetSongStructure(context.Background(), group, song)
+	assert.Equal(t, errors.New("Error selecting data"), err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	database.AssertExpectations(t)
+}
+
+func TestGetSongStructure_NoSuchSong(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("SelectTextQuery", context.Background(), "Muse", "Unknown").
+		Return("", pgx.ErrNoRows).
+		Once()
+	_, err, status := service.GetSongStructure(context.Background(), "Muse", "Unknown")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestAddLrc(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "[ar:Muse]\n[00:16.00]Ooh baby, can you hear me moan?\n[00:12.00]<00:12.00>Ooh <00:12.50>baby"
+	database.On("InsertLinesQuery", context.Background(), group, song, []models.TimedLineData{
+		{Time: 12000, Text: "Ooh baby", Words: []models.TimedWordData{{Time: 12000, Text: "Ooh "}, {Time: 12500, Text: "baby"}}},
+		{Time: 16000, Text: "Ooh baby, can you hear me moan?"},
+	}).
+		Return(nil).
+		Once()
+	err, status := service.AddLrc(context.Background(), group, song, text)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	database.AssertExpectations(t)
+}
+
+func TestAddLrc_ParseError(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	err, status := service.AddLrc(context.Background(), "Muse", "Supermassive Black Hole", "Ooh baby")
+	assert.EqualError(t, err, "There are no timed lines")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestAddLrc_InsertLinesQueryError(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("InsertLinesQuery", context.Background(), group, song, []models.TimedLineData{{Time: 12000, Text: "Ooh"}}).
+		Return(errors.New("Error inserting data")).
+		Once()
+	err, status := service.AddLrc(context.Background(), group, song, "[00:12.00]Ooh")
+	assert.Equal(t, errors.New("Error inserting data"), err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	database.AssertExpectations(t)
+}
+
+func TestLrc_NoSuchSong(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("InsertLinesQuery", context.Background(), "Muse", "Unknown", []models.TimedLineData{{Time: 12000, Text: "Ooh"}}).
+		Return(pgx.ErrNoRows).
+		Once()
+	mockdatabase.On("SelectLinesQuery", context.Background(), "Muse", "Unknown").
+		Return([]models.TimedLineData(nil), pgx.ErrNoRows).
+		Twice()
+	err, status := service.AddLrc(context.Background(), "Muse", "Unknown", "[00:12.00]Ooh")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	_, err, status = service.GetLrc(context.Background(), "Muse", "Unknown")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	_, err, status = service.GetSongLine(context.Background(), "Muse", "Unknown", 12000)
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetLrc(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("SelectLinesQuery", context.Background(), group, song).
+		Return([]models.TimedLineData{
+			{Time: 12000, Text: "Ooh baby", Words: []models.TimedWordData{{Time: 12000, Text: "Ooh "}, {Time: 12500, Text: "baby"}}},
+			{Time: 16000, Text: "Ooh baby, can you hear me moan?"},
+		}, nil).
+		Once()
+	result, err, status := service.GetLrc(context.Background(), group, song)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "[ar:Muse]\n[ti:Supermassive Black Hole]\n[00:12.00]<00:12.00>Ooh <00:12.50>baby\n[00:16.00]Ooh baby, can you hear me moan?\n", result)
+	database.AssertExpectations(t)
+}
+
+func TestGetLrc_NoLines(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("SelectLinesQuery", context.Background(), group, song).
+		Return([]models.TimedLineData(nil), nil).
+		Once()
+	_, err, status := service.GetLrc(context.Background(), group, song)
+	assert.EqualError(t, err, "There are no timed lines for the song")
+	assert.Equal(t, http.StatusNotFound, status)
+	database.AssertExpectations(t)
+}
+
+func TestAddChords(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	source := "{title: Supermassive Black Hole}\n[Em]Ooh baby, don't you [G]know I suffer?\nOoh baby, can you hear me moan?\n\n[C]You set my soul alight"
+	text := "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\n\nYou set my soul alight"
+	database.On("InsertChordsQuery", context.Background(), group, song, text, []models.ChordLineData{
+		{Line: 1, Chords: []models.ChordData{{Position: 0, Name: "Em"}, {Position: 20, Name: "G"}}},
+		{Line: 4, Chords: []models.ChordData{{Position: 0, Name: "C"}}},
+	}).
+		Return(nil).
+		Once()
+	database.On("UpdateLanguageQuery", context.Background(), group, song, "en", mock.AnythingOfType("float64")).
+		Return(nil).
+		Once()
+	err, status := service.AddChords(context.Background(), group, song, source)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	database.AssertExpectations(t)
+}
+
+func TestAddChords_ParseError(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	err, status := service.AddChords(context.Background(), "Muse", "Supermassive Black Hole", "[Em Ooh baby")
+	assert.EqualError(t, err, "line 1: unclosed chord")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestAddChords_InsertChordsQueryError(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("InsertChordsQuery", context.Background(), group, song, "Ooh", []models.ChordLineData{{Line: 1, Chords: []models.ChordData{{Position: 0, Name: "C"}}}}).
+		Return(errors.New("Error inserting data")).
+		Once()
+	err, status := service.AddChords(context.Background(), group, song, "[C]Ooh")
+	assert.Equal(t, errors.New("Error inserting data"), err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	database.AssertExpectations(t)
+}
+
+func TestAddChords_NotFound(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("InsertChordsQuery", context.Background(), "Muse", "Uprising", "Ooh", []models.ChordLineData{{Line: 1, Chords: []models.ChordData{{Position: 0, Name: "C"}}}}).
+		Return(pgx.ErrNoRows).
+		Once()
+	err, status := service.AddChords(context.Background(), "Muse", "Uprising", "[C]Ooh")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetChords(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("SelectChordsQuery", context.Background(), group, song).
+		Return([]models.ChordLineData{
+			{Line: 1, Chords: []models.ChordData{{Position: 0, Name: "Em"}, {Position: 20, Name: "G"}}},
+			{Line: 5, Chords: []models.ChordData{{Position: 0, Name: "C"}}},
+		}, nil).
+		Twice()
+	database.On("SelectTextQuery", context.Background(), group, song).
+		Return("Ooh baby, don't you know I suffer?\n\nYou set my soul alight", nil).
+		Twice()
+	result, err, status := service.GetChords(context.Background(), group, song, 2, "text")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "F#m                 A\nOoh baby, don't you know I suffer?\n\nYou set my soul alight\n", result)
+	result, err, status = service.GetChords(context.Background(), group, song, -1, "chordpro")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "{title: Supermassive Black Hole}\n{artist: Muse}\n[D#m]Ooh baby, don't you [F#]know I suffer?\n\nYou set my soul alight\n", result)
+	database.AssertExpectations(t)
+}
+
+func TestGetChords_NoChords(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("SelectChordsQuery", context.Background(), group, song).
+		Return([]models.ChordLineData(nil), nil).
+		Once()
+	_, err, status := service.GetChords(context.Background(), group, song, 0, "text")
+	assert.EqualError(t, err, "There are no chords for the song")
+	assert.Equal(t, http.StatusNotFound, status)
+	database.AssertExpectations(t)
+}
+
+func TestGetChords_NoSuchSong(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("SelectChordsQuery", context.Background(), "Muse", "Unknown").
+		Return([]models.ChordLineData(nil), pgx.ErrNoRows).
+		Once()
+	_, err, status := service.GetChords(context.Background(), "Muse", "Unknown", 0, "text")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	// the song is deleted between reading its chords and its text
+	mockdatabase.On("SelectChordsQuery", context.Background(), "Muse", "Uprising").
+		Return([]models.ChordLineData{{Line: 1, Chords: []models.ChordData{{Position: 0, Name: "Em"}}}}, nil).
+		Once()
+	mockdatabase.On("SelectTextQuery", context.Background(), "Muse", "Uprising").
+		Return("", pgx.ErrNoRows).
+		Once()
+	_, err, status = service.GetChords(context.Background(), "Muse", "Uprising", 0, "text")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetChords_UnknownFormat(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	_, err, status := service.GetChords(context.Background(), "Muse", "Supermassive Black Hole", 0, "pdf")
+	assert.EqualError(t, err, "Unknown format \"pdf\"")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestGetSongLine(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	lines := []models.TimedLineData{
+		{Time: 12000, Text: "Ooh baby, don't you know I suffer?"},
+		{Time: 16000, Text: "Ooh baby, can you hear me moan?"},
+	}
+	database.On("SelectLinesQuery", context.Background(), group, song).
+		Return(lines, nil).
+		Times(3)
+	result, err, status := service.GetSongLine(context.Background(), group, song, 500)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	next := int64(12000)
+	assert.Equal(t, models.AnswerLineData{Index: 0, NextTime: &next}, result)
+	result, _, _ = service.GetSongLine(context.Background(), group, song, 12700)
+	next = int64(16000)
+	assert.Equal(t, models.AnswerLineData{Index: 1, Time: 12000, Text: "Ooh baby, don't you know I suffer?", NextTime: &next}, result)
+	result, _, _ = service.GetSongLine(context.Background(), group, song, 100000)
+	assert.Equal(t, models.AnswerLineData{Index: 2, Time: 16000, Text: "Ooh baby, can you hear me moan?"}, result)
+	database.AssertExpectations(t)
+}
+
+func TestGetSongLine_SelectLinesQueryError(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("SelectLinesQuery", context.Background(), group, song).
+		Return([]models.TimedLineData(nil), errors.New("Error selecting data")).
+		Once()
+	_, err, status := service.GetSongLine(context.Background(), group, song, 500)
+	assert.Equal(t, errors.New("Error selecting data"), err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	database.AssertExpectations(t)
+}
+
+func TestGetSongText_Translation(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "Ooh baby, don't you know I suffer?\n\nOoh\nYou set my soul alight\n\nOoh\nYou set my soul alight"
+	versions := []models.LyricsData{
+		{Lang: "en", Kind: "original", Text: text},
+		{Lang: "ru", Kind: "transliteration", Text: "U, beybi\n\nU\nYu set may soul elayt"},
+		{Lang: "ru", Kind: "translation", Text: "О, детка, разве ты не знаешь, что я страдаю?\n\nО\nТы зажгла мою душу"},
+	}
+	database.On("SelectTextQuery", context.Background(), group, song).
+		Return(text, nil)
+	database.On("SelectLyricsQuery", context.Background(), group, song).
+		Return(versions, nil)
+	result, err, status := service.GetSongText(context.Background(), 2, group, song, false, []string{"ru-RU", "en"}, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, models.AnswerCoupletData{
+		Text:        "Ooh\nYou set my soul alight",
+		Lang:        "ru",
+		Kind:        "translation",
+		Translation: "О\nТы зажгла мою душу",
+	}, result)
+	result, _, _ = service.GetSongText(context.Background(), 1, group, song, false, []string{"ru"}, "transliteration")
+	assert.Equal(t, "U, beybi", result.Translation)
+	result, _, _ = service.GetSongText(context.Background(), 3, group, song, false, []string{"ru"}, "")
+	assert.Equal(t, "", result.Translation)
+	result, _, _ = service.GetSongText(context.Background(), 3, group, song, true, []string{"ru"}, "")
+	assert.Equal(t, "[Repeat verse 2]", result.Translation)
+	result, _, _ = service.GetSongText(context.Background(), 1, group, song, false, []string{"en-GB"}, "")
+	assert.Equal(t, models.AnswerCoupletData{Text: "Ooh baby, don't you know I suffer?", Lang: "en", Kind: "original"}, result)
+	result, _, _ = service.GetSongText(context.Background(), 1, group, song, false, []string{"fr"}, "")
+	assert.Equal(t, models.AnswerCoupletData{Text: "Ooh baby, don't you know I suffer?"}, result)
+	database.AssertExpectations(t)
+}
+
+func TestGetSongText_SelectLyricsQueryError(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("SelectTextQuery", context.Background(), group, song).
+		Return("Ooh baby, don't you know I suffer?", nil).
+		Once()
+	database.On("SelectLyricsQuery", context.Background(), group, song).
+		Return([]models.LyricsData(nil), errors.New("Error selecting data")).
+		Once()
+	_, err, status := service.GetSongText(context.Background(), 1, group, song, false, []string{"ru"}, "")
+	assert.Equal(t, errors.New("Error selecting data"), err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	database.AssertExpectations(t)
+}
+
+func TestAddLyrics(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	text := "О, детка, разве ты не знаешь, что я страдаю?"
+	database.On("UpsertLyricsQuery", context.Background(), group, song, "pt-BR", "translation", text).
+		Return(nil).
+		Once()
+	err, status := service.AddLyrics(context.Background(), group, song, "pt-br", "translation", text)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	database.AssertExpectations(t)
+}
+
+func TestAddLyrics_Invalid(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	err, status := service.AddLyrics(context.Background(), "Muse", "Supermassive Black Hole", "e", "translation", "")
+	assert.EqualError(t, err, "Invalid language tag \"e\"")
+	assert.Equal(t, http.StatusBadRequest, status)
+	err, status = service.AddLyrics(context.Background(), "Muse", "Supermassive Black Hole", "ru", "cover", "")
+	assert.EqualError(t, err, "Invalid lyrics kind \"cover\"")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestDeleteLyrics(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	database.On("DeleteLyricsQuery", context.Background(), group, song, "ru", "translation").
+		Return(errors.New("Error deleting data")).
+		Once()
+	err, status := service.DeleteLyrics(context.Background(), group, song, "ru", "translation")
+	assert.Equal(t, errors.New("Error deleting data"), err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	database.AssertExpectations(t)
+}
+
+func TestLyrics_NoSuchSong(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("UpsertLyricsQuery", context.Background(), "Muse", "Unknown", "ru", "translation", "О").
+		Return(pgx.ErrNoRows).
+		Once()
+	mockdatabase.On("DeleteLyricsQuery", context.Background(), "Muse", "Unknown", "ru", "translation").
+		Return(pgx.ErrNoRows).
+		Once()
+	mockdatabase.On("SelectLyricsQuery", context.Background(), "Muse", "Unknown").
+		Return([]models.LyricsData(nil), pgx.ErrNoRows).
+		Once()
+	err, status := service.AddLyrics(context.Background(), "Muse", "Unknown", "ru", "translation", "О")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	err, status = service.DeleteLyrics(context.Background(), "Muse", "Unknown", "ru", "translation")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	_, err, status = service.GetLyrics(context.Background(), "Muse", "Unknown")
+	assert.Equal(t, database.ErrSongNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetLyrics(t *testing.T) {
+	database := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(database, "http://localhost:8080", client)
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	versions := []models.LyricsData{{Lang: "ru", Kind: "translation", Text: "О, детка"}}
+	database.On("SelectLyricsQuery", context.Background(), group, song).
+		Return(versions, nil).
+		Once()
+	result, err, status := service.GetLyrics(context.Background(), group, song)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, models.AnswerLyricsData{Items: versions}, result)
+	database.AssertExpectations(t)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"test/internal/auth"
+	"test/internal/database"
+	"test/internal/models"
+)
+
+// Authenticate returns the user of the API key, the key has to be issued and not revoked.
+func (s *Service) Authenticate(ctx context.Context, key string) (result models.PrincipalData, err error, status int) {
+	if !strings.HasPrefix(key, auth.KeyPrefix) {
+		return result, database.ErrKeyNotFound, http.StatusUnauthorized
+	}
+	result, err = s.database.SelectPrincipalQuery(ctx, auth.HashKey(key))
+	if errors.Is(err, database.ErrKeyNotFound) {
+		return result, err, http.StatusUnauthorized
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get API key from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// AuthenticateAnonymous returns auth.Anonymous with the permissions of the viewer role in the
+// database, so that the changes made to it with SetRole reach the callers of the public routes.
+func (s *Service) AuthenticateAnonymous(ctx context.Context) (result models.PrincipalData, err error, status int) {
+	roles, err := s.database.SelectRolesQuery(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get roles from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	result = auth.Anonymous
+	result.Permissions = []string{}
+	for _, role := range roles {
+		if role.Name == models.RoleViewer {
+			result.Permissions = role.Permissions
+		}
+	}
+	return result, nil, http.StatusOK
+}
+
+// AddUser creates a user with the role, viewer by default.
+func (s *Service) AddUser(ctx context.Context, name string, role string) (result models.UserData, err error, status int) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return result, fmt.Errorf("The name of the user is required"), http.StatusBadRequest
+	}
+	if role == "" {
+		role = models.RoleViewer
+	}
+	id, err := s.database.InsertUserQuery(ctx, name, role)
+	if errors.Is(err, database.ErrUserExists) {
+		return result, err, http.StatusConflict
+	}
+	if errors.Is(err, database.ErrRoleNotFound) {
+		return result, fmt.Errorf("Unknown role %q", role), http.StatusBadRequest
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add user to the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	slog.InfoContext(ctx, "Added user", "role", role, "name", name)
+	return models.UserData{ID: id, Name: name, Role: role}, nil, http.StatusOK
+}
+
+// caller is the principal of the request, the policy layer has checked its permissions.
+func caller(ctx context.Context) models.PrincipalData {
+	principal, _ := auth.PrincipalFrom(ctx)
+	return principal
+}
+
+// keyOwner is the user whose keys the caller manages: the caller itself, or any user when it
+// manages users. An empty owner stands for every user.
+func keyOwner(ctx context.Context, user string) string {
+	principal := caller(ctx)
+	if principal.Allows(models.PermUsersManage) {
+		return user
+	}
+	return principal.User
+}
+
+// IssueKey issues a new API key to the user, the caller without a user. The key is only
+// returned here, the database keeps its hash.
+func (s *Service) IssueKey(ctx context.Context, user string) (result models.APIKeyData, err error, status int) {
+	if user == "" {
+		user = caller(ctx).User
+	}
+	key, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate API key", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	result, err = s.database.InsertKeyQuery(ctx, user, prefix, hash)
+	if errors.Is(err, database.ErrUserNotFound) {
+		return result, err, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add API key to the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	result.Key = key
+	slog.InfoContext(ctx, "Issued key", "prefix", prefix, "owner", user)
+	return result, nil, http.StatusOK
+}
+
+// RotateKey revokes the key and issues a new one to its user.
+func (s *Service) RotateKey(ctx context.Context, id int) (result models.APIKeyData, err error, status int) {
+	owner := keyOwner(ctx, "")
+	key, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate API key", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	result, err = s.database.RotateKeyQuery(ctx, id, owner, prefix, hash)
+	if errors.Is(err, database.ErrKeyNotFound) {
+		return result, err, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to rotate API key", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	result.Key = key
+	slog.InfoContext(ctx, "Rotated key", "id", id, "owner", result.User)
+	return result, nil, http.StatusOK
+}
+
+// RevokeKey revokes the key, the requests made with it are rejected from then on.
+func (s *Service) RevokeKey(ctx context.Context, id int) (err error, status int) {
+	owner := keyOwner(ctx, "")
+	err = s.database.RevokeKeyQuery(ctx, id, owner)
+	if errors.Is(err, database.ErrKeyNotFound) {
+		return err, http.StatusNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke API key", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	slog.InfoContext(ctx, "Revoked key", "id", id)
+	return nil, http.StatusOK
+}
+
+// GetKeys lists the keys of the user without the keys themselves, of the caller without a user.
+// Callers that manage users get the keys of every user with the user *.
+func (s *Service) GetKeys(ctx context.Context, user string) (result models.AnswerKeysData, err error, status int) {
+	switch user {
+	case "":
+		user = caller(ctx).User
+	case "*":
+		user = ""
+	}
+	owner := keyOwner(ctx, user)
+	result.Items, err = s.database.SelectKeysQuery(ctx, owner)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get API keys from the database", "error", err)
+		return result, err, http.StatusInternalServerError
+	}
+	if result.Items == nil {
+		result.Items = []models.APIKeyData{}
+	}
+	return result, nil, http.StatusOK
+}
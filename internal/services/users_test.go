@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"strings"
+	"test/internal/auth"
+	"test/internal/database"
+	"test/internal/models"
+	"test/internal/tenant"
+	"testing"
+)
+
+var (
+	admin    = models.PrincipalData{UserID: 1, User: "root", Role: models.RoleAdmin, KeyID: 1, Permissions: models.Permissions}
+	alice    = models.PrincipalData{UserID: 2, User: "alice", Role: models.RoleEditor, KeyID: 2, Permissions: models.DefaultRoles[models.RoleEditor]}
+	adminCtx = auth.WithPrincipal(context.Background(), admin)
+	aliceCtx = auth.WithPrincipal(context.Background(), alice)
+)
+
+func TestAuthenticate(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	key, _, hash, _ := auth.GenerateKey()
+	mockdatabase.On("SelectPrincipalQuery", context.Background(), hash).
+		Return(alice, nil).
+		Once()
+	result, err, status := service.Authenticate(context.Background(), key)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, alice, result)
+	mockdatabase.On("SelectPrincipalQuery", context.Background(), auth.HashKey("sl_revoked")).
+		Return(models.PrincipalData{}, database.ErrKeyNotFound).
+		Once()
+	_, err, status = service.Authenticate(context.Background(), "sl_revoked")
+	assert.Equal(t, database.ErrKeyNotFound, err)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	_, _, status = service.Authenticate(context.Background(), "password")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestAuthenticateAnonymous(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("SelectRolesQuery", context.Background()).
+		Return([]models.RoleData{{Name: models.RoleAdmin, Permissions: models.Permissions}, {Name: models.RoleViewer, Permissions: []string{models.PermLyricsRead, models.PermSongsRead}}}, nil).
+		Once()
+	result, err, status := service.AuthenticateAnonymous(context.Background())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, auth.Anonymous.User, result.User)
+	assert.Equal(t, []string{models.PermLyricsRead, models.PermSongsRead}, result.Permissions)
+	mockdatabase.On("SelectRolesQuery", context.Background()).
+		Return([]models.RoleData(nil), nil).
+		Once()
+	result, err, _ = service.AuthenticateAnonymous(context.Background())
+	assert.Equal(t, nil, err)
+	assert.Empty(t, result.Permissions)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestAddUser(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("InsertUserQuery", adminCtx, "bob", models.RoleViewer).
+		Return(3, nil).
+		Once()
+	mockdatabase.On("InsertUserQuery", adminCtx, "alice", models.RoleAdmin).
+		Return(0, database.ErrUserExists).
+		Once()
+	mockdatabase.On("InsertUserQuery", adminCtx, "carol", "owner").
+		Return(0, database.ErrRoleNotFound).
+		Once()
+	result, err, status := service.AddUser(adminCtx, " bob ", "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, models.UserData{ID: 3, Name: "bob", Role: models.RoleViewer}, result)
+	_, err, status = service.AddUser(adminCtx, "alice", models.RoleAdmin)
+	assert.Equal(t, database.ErrUserExists, err)
+	assert.Equal(t, http.StatusConflict, status)
+	_, err, status = service.AddUser(adminCtx, "carol", "owner")
+	assert.EqualError(t, err, `Unknown role "owner"`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	_, _, status = service.AddUser(adminCtx, " ", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestIssueKey(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	var prefix, hash string
+	mockdatabase.On("InsertKeyQuery", aliceCtx, "alice", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			prefix, hash = args.String(2), args.String(3)
+		}).
+		Return(models.APIKeyData{ID: 5, User: "alice"}, nil).
+		Once()
+	result, err, status := service.IssueKey(aliceCtx, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, strings.HasPrefix(result.Key, auth.KeyPrefix+prefix+"_"))
+	assert.Equal(t, auth.HashKey(result.Key), hash)
+	mockdatabase.On("InsertKeyQuery", adminCtx, "carol", mock.Anything, mock.Anything).
+		Return(models.APIKeyData{}, database.ErrUserNotFound).
+		Once()
+	_, _, status = service.IssueKey(adminCtx, "carol")
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestRotateAndRevokeKey(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("RotateKeyQuery", aliceCtx, 2, "alice", mock.Anything, mock.Anything).
+		Return(models.APIKeyData{ID: 6, User: "alice"}, nil).
+		Once()
+	mockdatabase.On("RevokeKeyQuery", adminCtx, 7, "").
+		Return(database.ErrKeyNotFound).
+		Once()
+	result, err, status := service.RotateKey(aliceCtx, 2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 6, result.ID)
+	assert.NotEmpty(t, result.Key)
+	err, status = service.RevokeKey(adminCtx, 7)
+	assert.Equal(t, database.ErrKeyNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetKeys(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("SelectKeysQuery", adminCtx, "").
+		Return([]models.APIKeyData(nil), nil).
+		Once()
+	mockdatabase.On("SelectKeysQuery", aliceCtx, "alice").
+		Return([]models.APIKeyData{{ID: 2, User: "alice"}}, nil).
+		Once()
+	result, err, status := service.GetKeys(adminCtx, "*")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []models.APIKeyData{}, result.Items)
+	// callers that do not manage users only get their own keys
+	result, _, _ = service.GetKeys(aliceCtx, "*")
+	assert.Equal(t, []models.APIKeyData{{ID: 2, User: "alice"}}, result.Items)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestSetRole(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("UpsertRoleQuery", adminCtx, "curator", []string{"groups:manage", "songs:read"}).
+		Return(nil).
+		Once()
+	err, status := service.SetRole(adminCtx, " curator ", []string{"songs:read", "groups:manage", "songs:read"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	err, status = service.SetRole(adminCtx, "curator", []string{"songs:sing"})
+	assert.EqualError(t, err, `Unknown permission "songs:sing"`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	err, status = service.SetRole(tenant.With(adminCtx, "acme"), "curator", []string{"songs:read"})
+	assert.EqualError(t, err, "The roles are only changed from the default tenant")
+	assert.Equal(t, http.StatusForbidden, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestAssignRole(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("AssignRoleQuery", adminCtx, "alice", models.RoleAdmin).
+		Return(nil).
+		Once()
+	mockdatabase.On("AssignRoleQuery", adminCtx, "alice", "owner").
+		Return(database.ErrRoleNotFound).
+		Once()
+	err, status := service.AssignRole(adminCtx, "alice", models.RoleAdmin)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, status)
+	err, status = service.AssignRole(adminCtx, "alice", "owner")
+	assert.Equal(t, database.ErrRoleNotFound, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestRenameGroup(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("RenameGroupQuery", adminCtx, "Muse", "Queen").
+		Return(database.ErrGroupExists).
+		Once()
+	mockdatabase.On("DeleteGroupQuery", adminCtx, "Nobody").
+		Return(database.ErrGroupNotFound).
+		Once()
+	err, status := service.RenameGroup(adminCtx, "Muse", "Queen")
+	assert.Equal(t, database.ErrGroupExists, err)
+	assert.Equal(t, http.StatusConflict, status)
+	_, status = service.RenameGroup(adminCtx, "Muse", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	_, status = service.DeleteGroup(adminCtx, "Nobody")
+	assert.Equal(t, http.StatusNotFound, status)
+	mockdatabase.AssertExpectations(t)
+}
+
+func TestGetGroups(t *testing.T) {
+	mockdatabase := NewMockDatabase()
+	client := NewMockHttpClient()
+	service := NewService(mockdatabase, "http://localhost:8080", client)
+	mockdatabase.On("SelectGroupsQuery", adminCtx).
+		Return([]models.GroupData(nil), nil).
+		Once()
+	result, err, status := service.GetGroups(adminCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []models.GroupData{}, result)
+	mockdatabase.AssertExpectations(t)
+}
@@ -0,0 +1,41 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Default is the tenant of the requests that do not name one, and of the data stored before
+// there were tenants.
+const Default = "default"
+
+// Header names the tenant of the requests. It has to be the tenant of the principal, only the
+// services whose JWT has no tenant claim but the tenants:select scope choose another one.
+const Header = "X-Tenant"
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+type tenantKey struct{}
+
+// With returns the context of a request for the library of the tenant.
+func With(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, name)
+}
+
+// From returns the tenant of the request, Default when it has none.
+func From(ctx context.Context) string {
+	if name, ok := ctx.Value(tenantKey{}).(string); ok && name != "" {
+		return name
+	}
+	return Default
+}
+
+// Validate checks that the name is 1 to 63 lowercase letters, digits, dashes and underscores
+// starting with a letter or digit.
+func Validate(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("Invalid tenant %q, expected up to 63 lowercase letters, digits, - and _", name)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package tenant
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFrom(t *testing.T) {
+	assert.Equal(t, Default, From(context.Background()))
+	assert.Equal(t, "team-a", From(With(context.Background(), "team-a")))
+	assert.Equal(t, Default, From(With(context.Background(), "")))
+}
+
+func TestValidate(t *testing.T) {
+	for _, name := range []string{"default", "team-a", "team_2", "7"} {
+		assert.NoError(t, Validate(name), name)
+	}
+	for _, name := range []string{"", "Team", "-a", "a b", "a;drop", string(make([]byte, 64))} {
+		assert.Error(t, Validate(name), name)
+	}
+}
@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// record installs a provider recording the ended spans.
+func record(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+	file := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), File, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := Tracer().Start(context.Background(), "GET /addsong")
+	span.End()
+	assert.NoError(t, shutdown(context.Background()))
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"GET /addsong"`)
+	assert.Contains(t, string(data), `"Value":"go-music"`)
+
+	shutdown, err = Setup(context.Background(), "", "")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	_, err = Setup(context.Background(), File, "")
+	assert.EqualError(t, err, "The file exporter needs a file")
+	_, err = Setup(context.Background(), "jaeger", "")
+	assert.EqualError(t, err, `Invalid exporter "jaeger", expected otlp, file or none`)
+}
+
+func TestTransport(t *testing.T) {
+	recorder := record(t)
+	Setup(context.Background(), None, "")
+	var traceparent string
+	transport := Transport(roundTripper(func(req *http.Request) (*http.Response, error) {
+		traceparent = req.Header.Get("traceparent")
+		if req.URL.Query().Get("song") == "" {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	}))
+	ctx, parent := Tracer().Start(context.Background(), "POST /addsong")
+	client := &http.Client{Transport: transport}
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://api.test/info?group=Muse&song=Uprising", nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	req, _ = http.NewRequestWithContext(ctx, "GET", "http://api.test/info?group=Muse", nil)
+	_, err = client.Do(req)
+	assert.Error(t, err)
+	parent.End()
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 3) {
+		return
+	}
+	assert.Equal(t, "GET /info", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "00-"+spans[1].SpanContext().TraceID().String()+"-"+spans[1].SpanContext().SpanID().String()+"-01", traceparent)
+}
+
+func TestQueryTracer(t *testing.T) {
+	recorder := record(t)
+	tracer := QueryTracer{}
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT id FROM songs WHERE tenant = $1", Args: []any{"default"}})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 3")})
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "  delete from songs;"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("permission denied")})
+	ctx = tracer.TraceCopyFromStart(context.Background(), nil, pgx.TraceCopyFromStartData{TableName: pgx.Identifier{"songs"}})
+	tracer.TraceCopyFromEnd(ctx, nil, pgx.TraceCopyFromEndData{CommandTag: pgconn.NewCommandTag("COPY 2")})
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 3) {
+		return
+	}
+	assert.Equal(t, "db SELECT", spans[0].Name())
+	attributes := map[string]string{}
+	for _, attribute := range spans[0].Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+	assert.Equal(t, map[string]string{"db.system": "postgresql", "db.query.text": "SELECT id FROM songs WHERE tenant = $1", "db.rows_affected": "3"}, attributes)
+	assert.Equal(t, "db DELETE", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "db COPY", spans[2].Name())
+}
@@ -0,0 +1,254 @@
+package rest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"test/internal/models"
+)
+
+// formats are the representations of the songs, by the value of the format parameter, with
+// their content types. Only JSON is paginated, the others are exported row by row.
+var formats = map[string]string{
+	"json":   "application/json",
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"xml":    "application/xml; charset=utf-8",
+}
+
+// mediaFormats are the formats of the media types of the Accept header.
+var mediaFormats = map[string]string{
+	"application/json":     "json",
+	"text/csv":             "csv",
+	"application/x-ndjson": "ndjson",
+	"application/xml":      "xml",
+	"text/xml":             "xml",
+}
+
+// errNotAcceptable is returned when none of the accepted media types can be produced.
+var errNotAcceptable = errors.New("None of the accepted media types is supported, expected application/json, text/csv, application/x-ndjson or application/xml")
+
+// negotiateFormat returns the format of the songs from the format parameter or, without it, from
+// the Accept header. JSON is returned unless another format ranks strictly above every other
+// media type the client accepts, so browsers and clients accepting */* or only unknown types
+// keep getting JSON; the request is not acceptable only when JSON is refused with q=0.
+func negotiateFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, found := formats[format]; !found {
+			return "", fmt.Errorf("Unknown format %q, expected json, csv, ndjson or xml", format)
+		}
+		return format, nil
+	}
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return "json", nil
+	}
+	// qualities are the qualities of the formats and of the other media types by their name
+	qualities := map[string]float64{}
+	var order []string
+	for _, part := range strings.Split(accept, ",") {
+		mediatype, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, found := params["q"]; found {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		name := mediatype
+		if format, found := mediaFormats[mediatype]; found {
+			name = format
+		}
+		if best, found := qualities[name]; !found || quality > best {
+			if !found {
+				order = append(order, name)
+			}
+			qualities[name] = quality
+		}
+	}
+	// the first of the formats of the same quality is preferred
+	for _, format := range order {
+		if _, found := formats[format]; !found || format == "json" || qualities[format] <= 0 {
+			continue
+		}
+		above := true
+		for _, other := range order {
+			if other != format && qualities[other] >= qualities[format] {
+				above = false
+				break
+			}
+		}
+		if above {
+			return format, nil
+		}
+	}
+	if !jsonAccepted(qualities) {
+		return "", errNotAcceptable
+	}
+	return "json", nil
+}
+
+// jsonAccepted reports whether JSON is not refused by the most specific of its media ranges.
+func jsonAccepted(qualities map[string]float64) bool {
+	for _, mediatype := range []string{"json", "application/*", "*/*"} {
+		if quality, found := qualities[mediatype]; found {
+			return quality > 0
+		}
+	}
+	return true
+}
+
+// exporter writes the songs in one of the export formats, begin is called before the first song
+// and end after the last one.
+type exporter interface {
+	begin() error
+	write(row models.RowDbData) error
+	end() error
+}
+
+func newExporter(format string, w io.Writer, query models.SongsQuery) exporter {
+	fields := query.Fields
+	if len(fields) == 0 {
+		fields = models.SongFields
+	}
+	includeGroup := slices.Contains(query.Include, "group")
+	switch format {
+	case "csv":
+		return &csvExporter{writer: csv.NewWriter(w), fields: fields, includeGroup: includeGroup}
+	case "xml":
+		return &xmlExporter{w: w, encoder: xml.NewEncoder(w), fields: fields}
+	default:
+		return &ndjsonExporter{encoder: json.NewEncoder(w)}
+	}
+}
+
+// fieldValue returns the field of the song as text, the confidence is empty without a language.
+func fieldValue(row models.RowDbData, field string) string {
+	switch field {
+	case "group":
+		return row.Group
+	case "song":
+		return row.Song
+	case "releaseDate":
+		return row.Date
+	case "text":
+		return row.Text
+	case "link":
+		return row.Link
+	case "lang":
+		return row.Lang
+	case "langConfidence":
+		if row.Lang == "" {
+			return ""
+		}
+		return strconv.FormatFloat(row.LangConfidence, 'f', -1, 64)
+	}
+	return ""
+}
+
+// csvExporter writes a header of the fields followed by a record per song, the group is added as
+// the groupId, groupName and groupSongs columns.
+type csvExporter struct {
+	writer       *csv.Writer
+	fields       []string
+	includeGroup bool
+}
+
+func (e *csvExporter) begin() error {
+	header := append([]string(nil), e.fields...)
+	if e.includeGroup {
+		header = append(header, "groupId", "groupName", "groupSongs")
+	}
+	return e.writer.Write(header)
+}
+
+func (e *csvExporter) write(row models.RowDbData) error {
+	record := make([]string, 0, len(e.fields)+3)
+	for _, field := range e.fields {
+		record = append(record, fieldValue(row, field))
+	}
+	if e.includeGroup {
+		group := row.GroupInfo
+		if group == nil {
+			group = &models.GroupData{}
+		}
+		record = append(record, strconv.Itoa(group.ID), group.Name, strconv.FormatInt(group.Songs, 10))
+	}
+	return e.writer.Write(record)
+}
+
+func (e *csvExporter) end() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// ndjsonExporter writes every song as a JSON object on its own line.
+type ndjsonExporter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExporter) begin() error {
+	return nil
+}
+
+func (e *ndjsonExporter) write(row models.RowDbData) error {
+	return e.encoder.Encode(row)
+}
+
+func (e *ndjsonExporter) end() error {
+	return nil
+}
+
+// xmlExporter writes a songs document with a song element per song holding an element per field
+// and the groupInfo element.
+type xmlExporter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	fields  []string
+}
+
+func (e *xmlExporter) begin() error {
+	if _, err := io.WriteString(e.w, xml.Header); err != nil {
+		return err
+	}
+	return e.encoder.EncodeToken(xml.StartElement{Name: xml.Name{Local: "songs"}})
+}
+
+func (e *xmlExporter) write(row models.RowDbData) error {
+	song := xml.StartElement{Name: xml.Name{Local: "song"}}
+	if err := e.encoder.EncodeToken(song); err != nil {
+		return err
+	}
+	for _, field := range e.fields {
+		if err := e.encoder.EncodeElement(fieldValue(row, field), xml.StartElement{Name: xml.Name{Local: field}}); err != nil {
+			return err
+		}
+	}
+	if row.GroupInfo != nil {
+		if err := e.encoder.EncodeElement(row.GroupInfo, xml.StartElement{Name: xml.Name{Local: "groupInfo"}}); err != nil {
+			return err
+		}
+	}
+	if err := e.encoder.EncodeToken(song.End()); err != nil {
+		return err
+	}
+	// the song is written out as soon as it is read
+	return e.encoder.Flush()
+}
+
+func (e *xmlExporter) end() error {
+	if err := e.encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "songs"}}); err != nil {
+		return err
+	}
+	return e.encoder.Flush()
+}
@@ -1,20 +1,63 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"mime"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
+	"test/internal/lyrics"
 	"test/internal/models"
+	"time"
 )
 
 type ServiceInterface interface {
-	AddSong(group string, song string) (err error, status int)
-	DeleteSong(group string, song string) (err error, status int)
-	EditSong(group string, song string, date string, text string, link string) (err error, status int)
-	GetSongs(page int64, items int64, group string, song string, date string, text string, link string) (result models.AnswerData, err error, status int)
-	GetSongText(couplet int64, group string, song string) (result models.AnswerCoupletData, err error, status int)
+	AddSong(ctx context.Context, group string, song string) (err error, status int)
+	ImportSongs(ctx context.Context, file io.Reader, format string, dryRun bool) (result models.ImportReportData, err error, status int)
+	DumpLibrary(ctx context.Context, w io.Writer) (result models.ManifestData, err error, status int)
+	RestoreLibrary(ctx context.Context, file io.ReaderAt, size int64, replace bool) (result models.ManifestData, err error, status int)
+	Authenticate(ctx context.Context, key string) (result models.PrincipalData, err error, status int)
+	AuthenticateAnonymous(ctx context.Context) (result models.PrincipalData, err error, status int)
+	AddUser(ctx context.Context, name string, role string) (result models.UserData, err error, status int)
+	IssueKey(ctx context.Context, user string) (result models.APIKeyData, err error, status int)
+	RotateKey(ctx context.Context, id int) (result models.APIKeyData, err error, status int)
+	RevokeKey(ctx context.Context, id int) (err error, status int)
+	GetKeys(ctx context.Context, user string) (result models.AnswerKeysData, err error, status int)
+	GetRoles(ctx context.Context) (result models.AnswerRolesData, err error, status int)
+	SetRole(ctx context.Context, name string, permissions []string) (err error, status int)
+	AssignRole(ctx context.Context, user string, role string) (err error, status int)
+	CreatePlaylist(ctx context.Context, owner string, title string, description string) (result models.PlaylistData, err error, status int)
+	EditPlaylist(ctx context.Context, id int, title string, description string) (err error, status int)
+	DeletePlaylist(ctx context.Context, id int) (err error, status int)
+	GetPlaylist(ctx context.Context, id int) (result models.PlaylistData, err error, status int)
+	GetPlaylists(ctx context.Context, owner string) (result models.AnswerPlaylistsData, err error, status int)
+	AddPlaylistSong(ctx context.Context, id int, group string, song string, position int) (result models.PlaylistData, err error, status int)
+	RemovePlaylistSong(ctx context.Context, id int, position int) (result models.PlaylistData, err error, status int)
+	MovePlaylistSong(ctx context.Context, id int, from int, to int) (result models.PlaylistData, err error, status int)
+	ExportPlaylist(ctx context.Context, id int, format string) (result string, err error, status int)
+	ImportPlaylist(ctx context.Context, file io.Reader, format string, owner string, title string) (result models.PlaylistImportData, err error, status int)
+	DeleteSong(ctx context.Context, group string, song string) (err error, status int)
+	DeleteGroup(ctx context.Context, group string) (err error, status int)
+	RenameGroup(ctx context.Context, group string, name string) (err error, status int)
+	EditSong(ctx context.Context, group string, song string, date string, text string, link string) (err error, status int)
+	GetSongs(ctx context.Context, query models.SongsQuery) (result models.AnswerData, err error, status int)
+	StreamSongs(ctx context.Context, query models.SongsQuery, each func(models.RowDbData) error) (err error, status int)
+	GetSongText(ctx context.Context, couplet int64, group string, song string, compact bool, langs []string, kind string) (result models.AnswerCoupletData, err error, status int)
+	GetSongStructure(ctx context.Context, group string, song string) (result models.AnswerStructureData, err error, status int)
+	AddLrc(ctx context.Context, group string, song string, text string) (err error, status int)
+	GetLrc(ctx context.Context, group string, song string) (result string, err error, status int)
+	AddChords(ctx context.Context, group string, song string, source string) (err error, status int)
+	GetChords(ctx context.Context, group string, song string, transpose int, format string) (result string, err error, status int)
+	GetSongLine(ctx context.Context, group string, song string, offset int64) (result models.AnswerLineData, err error, status int)
+	AddLyrics(ctx context.Context, group string, song string, lang string, kind string, text string) (err error, status int)
+	DeleteLyrics(ctx context.Context, group string, song string, lang string, kind string) (err error, status int)
+	GetLyrics(ctx context.Context, group string, song string) (result models.AnswerLyricsData, err error, status int)
 }
 
 type Handler struct {
@@ -27,37 +70,236 @@ func NewHandler(service ServiceInterface) *Handler {
 
 // AddSong godoc
 // @Summary Add song
-// @Description Add song based on group and song provided as json.
+// @Description Add song based on group and song provided as json. The release date, text and link are fetched from the /info route of the metadata provider, after repeated failures of the provider the songs are rejected with 503 for a while without calling it.
 // @Tags song
 // @Accept json
 // @Produce  json
 // @Param data body models.AddDeleteRequestData true "JSON with group and song"
 // @Success 200 {object} nil "OK"
 // @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 429 {object} string "Too Many Requests"
 // @Failure 500 {object} string "Internal Server Error"
+// @Failure 503 {object} string "Service Unavailable, the circuit of the metadata provider is open"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /addsong [post]
 func (h *Handler) AddSong(w http.ResponseWriter, r *http.Request) {
-	log.Println("INFO: Received request to add song")
+	slog.DebugContext(r.Context(), "Received request to add song")
 	var respdata models.AddDeleteRequestData
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf("ERROR: Failed to read request body: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to read request body", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	log.Printf("DEBUG: Request body: %s\n", string(body))
+	slog.DebugContext(r.Context(), "Request body", "body", string(body))
 	if err = json.Unmarshal(body, &respdata); err != nil {
-		log.Printf("ERROR: Failed to unmarshal request body: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to unmarshal request body", "error", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	log.Printf("INFO: Request data: group=%s, song=%s\n", respdata.Group, respdata.Song)
-	err, status := h.service.AddSong(respdata.Group, respdata.Song)
+	slog.InfoContext(r.Context(), "Request data", "group", respdata.Group, "song", respdata.Song)
+	err, status := h.service.AddSong(r.Context(), respdata.Group, respdata.Song)
 	if err != nil {
 		http.Error(w, err.Error(), status)
 		return
 	}
-	log.Printf("INFO: Added song to the database\n")
+	slog.InfoContext(r.Context(), "Added song to the database")
+}
+
+// importFormats are the formats of the imported files by their content types.
+var importFormats = map[string]string{
+	"text/csv":             "csv",
+	"application/json":     "json",
+	"application/x-ndjson": "ndjson",
+}
+
+// maxImportSize is the largest file of songs that can be imported at once.
+const maxImportSize = 64 << 20
+
+// ImportSongs godoc
+// @Summary Import songs from a file
+// @Description Add the songs of a CSV file with a header of group, song, releaseDate, text and link columns, a JSON array or NDJSON of songs with the same keys. The format is given by the format parameter or the Content-Type header. The songs are copied in one transaction, the report lists every row as inserted, skipped when the song is already stored or given earlier with the same data, conflicting when it is given with other data or invalid. Nothing is stored with dryRun=true.
+// @Tags songs
+// @Accept text/csv
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce  json
+// @Param format query string false "Format of the file, overrides the Content-Type header" Enums(csv, json, ndjson)
+// @Param dryRun query boolean false "Only report the outcome of the rows"
+// @Param data body string true "File of songs"
+// @Success 200 {object} models.ImportReportData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 413 {object} string "Request Entity Too Large"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /importsongs [post]
+func (h *Handler) ImportSongs(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to import songs")
+	liftDeadlines(w)
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		format = importFormats[mediatype]
+	}
+	if format == "" {
+		http.Error(w, "The format of the file is required, expected csv, json or ndjson", http.StatusBadRequest)
+		return
+	}
+	dryRun := false
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid dryRun: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	slog.InfoContext(r.Context(), "Request data", "format", format, "dryRun", dryRun)
+	result, err, status := h.service.ImportSongs(r.Context(), http.MaxBytesReader(w, r.Body, maxImportSize), format, dryRun)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// maxArchiveSize is the largest library archive that can be restored.
+const maxArchiveSize = 1 << 30
+
+// startedWriter sets the headers of the response before its first byte, so that the response
+// can still be an error until something is written.
+type startedWriter struct {
+	w       http.ResponseWriter
+	headers map[string]string
+	started bool
+}
+
+func (sw *startedWriter) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if !sw.started {
+		sw.started = true
+		for name, value := range sw.headers {
+			sw.w.Header().Set(name, value)
+		}
+	}
+	return sw.w.Write(data)
+}
+
+// liftDeadlines lifts the read and write timeouts of the server for the request, the archives,
+// imports and exports take as long as their size needs. The writers without deadlines, such as
+// the recorders of the tests, are left as they are.
+func liftDeadlines(w http.ResponseWriter) {
+	controller := http.NewResponseController(w)
+	controller.SetReadDeadline(time.Time{})
+	controller.SetWriteDeadline(time.Time{})
+}
+
+// DumpLibrary godoc
+// @Summary Dump the library
+// @Description Export groups, songs, time-synced lines, chords, lyrics versions and playlists as a zip archive with a JSON lines file per table and a manifest.json with the format version, the schema version and the record count and SHA-256 checksum of every file. The archive is taken from a single snapshot and streamed.
+// @Tags library
+// @Produce  application/zip
+// @Success 200 {file} file "Library archive"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /dumplibrary [get]
+func (h *Handler) DumpLibrary(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to dump the library")
+	liftDeadlines(w)
+	writer := &startedWriter{w: w, headers: map[string]string{
+		"Content-Type":        "application/zip",
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"library-%s.zip\"", time.Now().UTC().Format("20060102-150405")),
+	}}
+	result, err, status := h.service.DumpLibrary(r.Context(), writer)
+	if err != nil && !writer.started {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to dump the library after the response was started", "error", err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Dumped the library", "files", len(result.Files))
+}
+
+// RestoreLibrary godoc
+// @Summary Restore the library
+// @Description Load a library archive of /dumplibrary in a single transaction after checking its manifest and checksums. By default the archive is merged into the library, the songs of the archive replace the stored ones with their lines, chords and lyrics and the other songs are kept, the playlists of the archive replace the stored ones of the same owner and title. With mode=replace the library and the playlists are emptied first.
+// @Tags library
+// @Accept application/zip
+// @Produce  json
+// @Param mode query string false "Merge into the library or replace it" Enums(merge, replace)
+// @Param data body string true "Library archive"
+// @Success 200 {object} models.ManifestData "Manifest of the restored archive"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 413 {object} string "Request Entity Too Large"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /restorelibrary [post]
+func (h *Handler) RestoreLibrary(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to restore the library")
+	liftDeadlines(w)
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != "merge" && mode != "replace" {
+		http.Error(w, fmt.Sprintf("Unknown mode %q, expected merge or replace", mode), http.StatusBadRequest)
+		return
+	}
+	// the archive is read from its end, so it is kept in a temporary file
+	file, err := os.CreateTemp("", "library-*.zip")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create a temporary file", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	size, err := io.Copy(file, http.MaxBytesReader(w, r.Body, maxArchiveSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read request body", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "mode", mode, "size", size)
+	result, err, status := h.service.RestoreLibrary(r.Context(), file, size, mode == "replace")
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 }
 
 // DeleteSong godoc
@@ -69,129 +311,276 @@ func (h *Handler) AddSong(w http.ResponseWriter, r *http.Request) {
 // @Param data body models.AddDeleteRequestData true "JSON with group and song"
 // @Success 200 {object} nil "OK"
 // @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 429 {object} string "Too Many Requests"
 // @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /deletesong [post]
 func (h *Handler) DeleteSong(w http.ResponseWriter, r *http.Request) {
-	log.Println("INFO: Received request to delete song")
+	slog.DebugContext(r.Context(), "Received request to delete song")
 	var respdata models.AddDeleteRequestData
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf("ERROR: Failed to read request body: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to read request body", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	log.Printf("DEBUG: Request body: %s\n", string(body))
+	slog.DebugContext(r.Context(), "Request body", "body", string(body))
 	if err = json.Unmarshal(body, &respdata); err != nil {
-		log.Printf("ERROR: Failed to unmarshal request body: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to unmarshal request body", "error", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	log.Printf("INFO: Request data: group=%s, song=%s\n", respdata.Group, respdata.Song)
-	err, status := h.service.DeleteSong(respdata.Group, respdata.Song)
+	slog.InfoContext(r.Context(), "Request data", "group", respdata.Group, "song", respdata.Song)
+	err, status := h.service.DeleteSong(r.Context(), respdata.Group, respdata.Song)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Deleted song from the database")
+}
+
+// DeleteGroup godoc
+// @Summary Delete group
+// @Description Delete the group with all of its songs based on group provided as json. Needs the groups:manage permission.
+// @Tags song
+// @Accept json
+// @Produce  json
+// @Param data body models.GroupRequestData true "JSON with group"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /deletegroup [post]
+func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to delete group")
+	var respdata models.GroupRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	err, status := h.service.DeleteGroup(r.Context(), respdata.Group)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Deleted group from the database")
+}
+
+// RenameGroup godoc
+// @Summary Rename group
+// @Description Rename the group based on group and name provided as json, the name must not be taken by another group. Needs the groups:manage permission.
+// @Tags song
+// @Accept json
+// @Produce  json
+// @Param data body models.GroupRequestData true "JSON with group and name"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 409 {object} string "Conflict"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /renamegroup [post]
+func (h *Handler) RenameGroup(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to rename group")
+	var respdata models.GroupRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	err, status := h.service.RenameGroup(r.Context(), respdata.Group, respdata.Name)
 	if err != nil {
 		http.Error(w, err.Error(), status)
 		return
 	}
-	log.Printf("INFO: Deleted song from the database\n")
 }
 
 // EditSong godoc
 // @Summary Edit song text
-// @Description Edit song releaseDate, text and link based on group and song provided as json.
+// @Description Edit song releaseDate, text and link based on group and song provided as json. A new text deletes the chords and the LRC lines of the song, they were kept by line number.
 // @Tags song
 // @Accept json
 // @Produce  json
 // @Param data body models.EditRequestData true "JSON with group, song, releaseDate, text, and link"
 // @Success 200 {object} nil "OK"
 // @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 429 {object} string "Too Many Requests"
 // @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /editsong [post]
 func (h *Handler) EditSong(w http.ResponseWriter, r *http.Request) {
-	log.Println("INFO: Received request to edit song")
+	slog.DebugContext(r.Context(), "Received request to edit song")
 	var respdata models.EditRequestData
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	log.Printf("DEBUG: Request body: %s\n", string(body))
+	slog.DebugContext(r.Context(), "Request body", "body", string(body))
 	if err = json.Unmarshal(body, &respdata); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	log.Printf("INFO: Request data: group=%s, song=%s, releaseDate=%s, text=%s, link=%s\n", respdata.Group, respdata.Song, respdata.Date, respdata.Text, respdata.Link)
-	err, status := h.service.EditSong(respdata.Group, respdata.Song, respdata.Date, respdata.Text, respdata.Link)
+	slog.InfoContext(r.Context(), "Request data", "group", respdata.Group, "song", respdata.Song, "releaseDate", respdata.Date)
+	err, status := h.service.EditSong(r.Context(), respdata.Group, respdata.Song, respdata.Date, respdata.Text, respdata.Link)
 	if err != nil {
 		http.Error(w, err.Error(), status)
 		return
 	}
-	log.Printf("INFO: Edited song in the database\n")
+	slog.InfoContext(r.Context(), "Edited song in the database")
 }
 
 // GetSongs godoc
 // @Summary Get all songs and their information with pagination
-// @Description Retrieve songs and their details with pagination based on the page and items and filtration based on group, song, releaseDate, text and link provided as query parameters.
+// @Description Retrieve songs and their details with pagination based on the page and items, filtration and sorting provided as query parameters. The songs are exported as CSV, NDJSON or XML by the format parameter or by the Accept header when that type ranks above every other accepted type, JSON otherwise, the exports are streamed and return all the matching songs when items is not given. A plain field=value parameter is an exact match, the other operators are given as field[operator]=value: prefix and contains (case-insensitive) and in for group and song, gt, gte, lt and lte for releaseDate, contains for text and in for lang. Values of in are given by repeating the parameter. Without sort songs are returned in the order they were added. The cursors of the next and previous pages are returned in the pagination and the Link header, the total is counted on request.
 // @Tags songs
 // @Produce  json
-// @Param page query integer true "Current page" example(1)
-// @Param items query integer true "Number of elements on the page" example(10)
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Produce  application/xml
+// @Param format query string false "Format of the songs, overrides the Accept header" Enums(json, csv, ndjson, xml)
+// @Param page query integer false "Current page, required without cursor" example(1)
+// @Param items query integer false "Number of elements on the page, at most 1000, required for JSON" example(10)
 // @Param group query string false "Group" example("Muse")
 // @Param song query string false "Song name" example("Supermassive Black Hole")
 // @Param releaseDate query string false "Release date in format DD.MM.YYYY" example("16.07.2006")
 // @Param text query string false "Song text (multiline allowed)" example("Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight")
 // @Param link query string false "Song link" example("https://www.youtube.com/watch?v=Xsp3_a-PMTw")
+// @Param lang query string false "Detected language of the song text as a BCP 47 tag" example("en")
+// @Param group[prefix] query string false "Group name starts with" example("Mu")
+// @Param song[contains] query string false "Song name contains" example("Black")
+// @Param group[in] query []string false "Group is one of" collectionFormat(multi)
+// @Param releaseDate[gte] query string false "Released on or after the date in format DD.MM.YYYY" example("01.01.2006")
+// @Param releaseDate[lt] query string false "Released before the date in format DD.MM.YYYY" example("01.01.2010")
+// @Param hasLink query boolean false "Only songs with (true) or without (false) a link"
+// @Param hasLyrics query boolean false "Only songs with (true) or without (false) lyrics"
+// @Param sort query string false "Comma-separated sort keys of group, song and releaseDate, prefixed with - for the descending order" example("-releaseDate,song")
+// @Param cursor query string false "Cursor of the next or previous page from the pagination or the Link header, replaces page"
+// @Param total query string false "Count the matching songs exactly or estimate the number" Enums(exact, estimate)
+// @Param fields query string false "Comma-separated fields of the songs to return, of group, song, releaseDate, text, link, lang and langConfidence, all by default" example("group,song,releaseDate")
+// @Param include query string false "Comma-separated related resources to add to the songs, group adds groupInfo" example("group")
 // @Success 200 {object} models.AnswerData "OK"
+// @Header 200 {string} Link "Links to the previous and next pages"
 // @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 406 {object} string "Not Acceptable"
+// @Failure 429 {object} string "Too Many Requests"
 // @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /getdata [get]
 func (h *Handler) GetSongs(w http.ResponseWriter, r *http.Request) {
-	log.Println("INFO: Received request to get songs")
-	query := r.URL.Query()
-	page, err := strconv.ParseInt(query.Get("page"), 10, 64)
+	slog.DebugContext(r.Context(), "Received request to get songs")
+	w.Header().Set("Vary", "Accept")
+	format, err := negotiateFormat(r)
+	if err == errNotAcceptable {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
 	if err != nil {
-		log.Printf("ERROR: Failed to parse page to int %v\n", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	items, err := strconv.ParseInt(query.Get("items"), 10, 64)
+	query, err := parseSongsQuery(r.URL.Query(), format == "json")
 	if err != nil {
-		log.Printf("ERROR: Failed to parse items to int %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to parse songs query", "error", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	group := query.Get("group")
-	song := query.Get("song")
-	releaseDate := query.Get("releaseDate")
-	text := query.Get("text")
-	link := query.Get("link")
-	log.Printf("INFO: Request data: page=%d, items=%d, group=%s, song=%s, releaseDate=%s, text=%s, link=%s\n", page, items, group, song, releaseDate, text, link)
-	result, err, status := h.service.GetSongs(page, items, group, song, releaseDate, text, link)
+	slog.InfoContext(r.Context(), "Request data", append([]any{"format", format}, songsQueryAttrs(query)...)...)
+	if format != "json" {
+		h.exportSongs(r.Context(), w, format, query)
+		return
+	}
+	result, err, status := h.service.GetSongs(r.Context(), query)
 	if err != nil {
 		http.Error(w, err.Error(), status)
 		return
 	}
-	log.Printf("INFO: Response data: items=%s\n", result.Items)
+	slog.DebugContext(r.Context(), "Response data", "items", len(result.Items))
+	if links := paginationLinks(r.URL, result.Pagination); links != "" {
+		w.Header().Set("Link", links)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		log.Printf("ERROR: Failed to encode response: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	log.Printf("INFO: Responded\n")
+}
+
+// exportSongs streams the songs in the format as they are read from the database. The response
+// is only started with the first song, so an error before it is still reported with its status,
+// an error after it can only cut the response short.
+func (h *Handler) exportSongs(ctx context.Context, w http.ResponseWriter, format string, query models.SongsQuery) {
+	liftDeadlines(w)
+	exporter := newExporter(format, w, query)
+	started := false
+	begin := func() error {
+		started = true
+		w.Header().Set("Content-Type", formats[format])
+		return exporter.begin()
+	}
+	rows := 0
+	err, status := h.service.StreamSongs(ctx, query, func(row models.RowDbData) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		rows++
+		return exporter.write(row)
+	})
+	if err != nil && !started {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if err == nil && !started {
+		err = begin()
+	}
+	if err == nil {
+		err = exporter.end()
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to export songs", "rows", rows, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "Exported songs", "rows", rows, "format", format)
 }
 
 // GetSongText godoc
 // @Summary Get songs text with pagination
-// @Description Retrieve song text with pagination based on the group, song and couplet provided as query parameters.
+// @Description Retrieve song text with pagination based on the group, song and couplet provided as query parameters. The verse of the lyrics version in the language of the lang parameter, or the Accept-Language header when it is missing, is returned alongside as translation.
 // @Tags song
 // @Produce  json
 // @Param group query string true "Group" example("Muse")
 // @Param song query string true "Song name" example("Supermassive Black Hole")
 // @Param couplet query integer true "Couplet" example(1)
+// @Param compact query boolean false "Replace repeated verses with references" example(true)
+// @Param lang query string false "BCP 47 language tag of the lyrics version" example("ru")
+// @Param kind query string false "Kind of the lyrics version: translation, transliteration or original" example("translation")
+// @Param Accept-Language header string false "Languages of the lyrics version when lang is missing" example("ru, en;q=0.5")
 // @Success 200 {object} models.AnswerCoupletData "OK"
 // @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
 // @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /getsongtext [get]
 func (h *Handler) GetSongText(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
@@ -199,25 +588,394 @@ func (h *Handler) GetSongText(w http.ResponseWriter, r *http.Request) {
 	song := query.Get("song")
 	couplet, err := strconv.ParseInt(query.Get("couplet"), 10, 64)
 	if err != nil {
-		log.Printf("ERROR: Failed to parse couplet to int %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to parse couplet to int", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	compact := false
+	if query.Has("compact") {
+		compact, err = strconv.ParseBool(query.Get("compact"))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to parse compact to bool", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	langs, err := lyrics.Preferences(query.Get("lang"), r.Header.Get("Accept-Language"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to parse lang", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	kind := query.Get("kind")
+	slog.InfoContext(r.Context(), "Request data", "group", group, "song", song, "couplet", couplet, "compact", compact, "langs", langs, "kind", kind)
+	result, err, status := h.service.GetSongText(r.Context(), couplet, group, song, compact, langs, kind)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.DebugContext(r.Context(), "Response data", "text", result.Text, "lang", result.Lang, "translation", result.Translation)
+	w.Header().Set("Vary", "Accept-Language")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetSongStructure godoc
+// @Summary Get song structure
+// @Description Retrieve the verses of the song with repeated and near-repeated verses replaced by references, the number of unique verses and repeats, and the hook (the most repeated verse), based on the group and song provided as query parameters.
+// @Tags song
+// @Produce  json
+// @Param group query string true "Group" example("Muse")
+// @Param song query string true "Song name" example("Supermassive Black Hole")
+// @Success 200 {object} models.AnswerStructureData "OK"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /getsongstructure [get]
+func (h *Handler) GetSongStructure(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	group := query.Get("group")
+	song := query.Get("song")
+	slog.InfoContext(r.Context(), "Request data", "group", group, "song", song)
+	result, err, status := h.service.GetSongStructure(r.Context(), group, song)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.DebugContext(r.Context(), "Response data", "unique", result.Unique, "repeats", result.Repeats, "hook", result.Hook)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddLrc godoc
+// @Summary Add time-synced lyrics
+// @Description Replace the time-synced lines of the song with the lyrics in the LRC format (enhanced word-level tags are supported) based on group, song and lrc provided as json.
+// @Tags lrc
+// @Accept json
+// @Produce  json
+// @Param data body models.LrcRequestData true "JSON with group, song and lrc"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /addlrc [post]
+func (h *Handler) AddLrc(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to add LRC")
+	var respdata models.LrcRequestData
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read request body", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = json.Unmarshal(body, &respdata); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to unmarshal request body", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "group", respdata.Group, "song", respdata.Song)
+	err, status := h.service.AddLrc(r.Context(), respdata.Group, respdata.Song, respdata.Lrc)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Added song lines to the database")
+}
+
+// GetLrc godoc
+// @Summary Get time-synced lyrics
+// @Description Export the time-synced lines of the song in the LRC format based on the group and song provided as query parameters.
+// @Tags lrc
+// @Produce  plain
+// @Param group query string true "Group" example("Muse")
+// @Param song query string true "Song name" example("Supermassive Black Hole")
+// @Success 200 {string} string "OK"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /getlrc [get]
+func (h *Handler) GetLrc(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	group := query.Get("group")
+	song := query.Get("song")
+	slog.InfoContext(r.Context(), "Request data", "group", group, "song", song)
+	result, err, status := h.service.GetLrc(r.Context(), group, song)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err = io.WriteString(w, result)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write response", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddChords godoc
+// @Summary Add a chord sheet
+// @Description Replace the song text with the lyrics of the chord sheet in the ChordPro format and store its chords separately, based on group, song and chordpro provided as json. Directives and lines with chords but no lyrics are skipped. A changed text deletes the LRC lines of the song.
+// @Tags chords
+// @Accept json
+// @Produce  json
+// @Param data body models.ChordsRequestData true "JSON with group, song and chordpro"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /addchords [post]
+func (h *Handler) AddChords(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to add chords")
+	var respdata models.ChordsRequestData
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read request body", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = json.Unmarshal(body, &respdata); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to unmarshal request body", "error", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	log.Printf("INFO: Request data: group=%s, song=%s, couplet=%d\n", group, song, couplet)
-	result, err, status := h.service.GetSongText(couplet, group, song)
+	slog.InfoContext(r.Context(), "Request data", "group", respdata.Group, "song", respdata.Song)
+	err, status := h.service.AddChords(r.Context(), respdata.Group, respdata.Song, respdata.ChordPro)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Added chords to the database")
+}
+
+// GetChords godoc
+// @Summary Get the chord sheet
+// @Description Render the song text with chords over the lyric lines, or export it in the ChordPro format, transposed by the number of semitones, based on the group, song, transpose and format provided as query parameters.
+// @Tags chords
+// @Produce  plain
+// @Param group query string true "Group" example("Muse")
+// @Param song query string true "Song name" example("Supermassive Black Hole")
+// @Param transpose query integer false "Semitones to transpose by, negative to transpose down" example(2)
+// @Param format query string false "text (default) or chordpro" example("text")
+// @Success 200 {string} string "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /getchords [get]
+func (h *Handler) GetChords(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	group := query.Get("group")
+	song := query.Get("song")
+	transpose := 0
+	// an unescaped + of ?transpose=+2 is decoded as a space
+	if value := strings.TrimSpace(query.Get("transpose")); value != "" {
+		var err error
+		transpose, err = strconv.Atoi(value)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to parse transpose to int", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = "text"
+	}
+	slog.InfoContext(r.Context(), "Request data", "group", group, "song", song, "transpose", transpose, "format", format)
+	result, err, status := h.service.GetChords(r.Context(), group, song, transpose, format)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err = io.WriteString(w, result)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write response", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetSongLine godoc
+// @Summary Get the lyric line at a playback offset
+// @Description Retrieve the time-synced line active at the playback offset in milliseconds and the time the next line starts, based on the group, song and offset provided as query parameters. Index is 0 before the first line.
+// @Tags lrc
+// @Produce  json
+// @Param group query string true "Group" example("Muse")
+// @Param song query string true "Song name" example("Supermassive Black Hole")
+// @Param offset query integer true "Playback offset in milliseconds" example(12700)
+// @Success 200 {object} models.AnswerLineData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /getsongline [get]
+func (h *Handler) GetSongLine(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	group := query.Get("group")
+	song := query.Get("song")
+	offset, err := strconv.ParseInt(query.Get("offset"), 10, 64)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to parse offset to int", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "group", group, "song", song, "offset", offset)
+	result, err, status := h.service.GetSongLine(r.Context(), group, song, offset)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddLyrics godoc
+// @Summary Add lyrics version
+// @Description Add or replace the lyrics version of the song based on group, song, BCP 47 language tag lang, kind (original, translation or transliteration) and text provided as json.
+// @Tags lyrics
+// @Accept json
+// @Produce  json
+// @Param data body models.LyricsRequestData true "JSON with group, song, lang, kind and text"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /addlyrics [post]
+func (h *Handler) AddLyrics(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to add lyrics")
+	var respdata models.LyricsRequestData
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read request body", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = json.Unmarshal(body, &respdata); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to unmarshal request body", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "group", respdata.Group, "song", respdata.Song, "lang", respdata.Lang, "kind", respdata.Kind)
+	err, status := h.service.AddLyrics(r.Context(), respdata.Group, respdata.Song, respdata.Lang, respdata.Kind, respdata.Text)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Added lyrics to the database")
+}
+
+// DeleteLyrics godoc
+// @Summary Delete lyrics version
+// @Description Delete the lyrics version of the song based on group, song, lang and kind provided as json.
+// @Tags lyrics
+// @Accept json
+// @Produce  json
+// @Param data body models.LyricsRequestData true "JSON with group, song, lang and kind"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /deletelyrics [post]
+func (h *Handler) DeleteLyrics(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to delete lyrics")
+	var respdata models.LyricsRequestData
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read request body", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = json.Unmarshal(body, &respdata); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to unmarshal request body", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "group", respdata.Group, "song", respdata.Song, "lang", respdata.Lang, "kind", respdata.Kind)
+	err, status := h.service.DeleteLyrics(r.Context(), respdata.Group, respdata.Song, respdata.Lang, respdata.Kind)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Deleted lyrics from the database")
+}
+
+// GetLyrics godoc
+// @Summary Get lyrics versions
+// @Description Retrieve all lyrics versions of the song based on the group and song provided as query parameters.
+// @Tags lyrics
+// @Produce  json
+// @Param group query string true "Group" example("Muse")
+// @Param song query string true "Song name" example("Supermassive Black Hole")
+// @Success 200 {object} models.AnswerLyricsData "OK"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /getlyrics [get]
+func (h *Handler) GetLyrics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	group := query.Get("group")
+	song := query.Get("song")
+	slog.InfoContext(r.Context(), "Request data", "group", group, "song", song)
+	result, err, status := h.service.GetLyrics(r.Context(), group, song)
 	if err != nil {
 		http.Error(w, err.Error(), status)
 		return
 	}
-	log.Printf("INFO: Response data: text=%s\n", result.Text)
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		log.Printf("ERROR: Failed to encode response: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	log.Printf("INFO: Responded\n")
 }
 
 // Can be used for /getinfo requests
@@ -226,18 +984,18 @@ func (h *Handler) Info(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	group := query.Get("group")
 	song := query.Get("song")
-	log.Printf("INFO: Request data: group=%s, song=%s\n", group, song)
+	slog.InfoContext(r.Context(), "Request data", "group", group, "song", song)
 	var result models.AddResponseData
 	result = models.AddResponseData{
 		Date: "16.07.2006",
 		Text: "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight",
 		Link: "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
 	}
-	log.Printf("INFO: Response data: releaseDate=%s, text=%s, link=%s\n", result.Date, result.Text, result.Link)
+	slog.DebugContext(r.Context(), "Response data", "releaseDate", result.Date, "text", result.Text, "link", result.Link)
 	w.Header().Set("Content-Type", "application/json")
 	err := json.NewEncoder(w).Encode(result)
 	if err != nil {
-		log.Printf("ERROR: Failed to encode response: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -2,7 +2,9 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
@@ -12,8 +14,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"test/internal/models"
 	"testing"
+	"time"
 )
 
 type MockInterface struct {
@@ -24,31 +28,231 @@ func NewMockInterface() *MockInterface {
 	return &MockInterface{}
 }
 
-func (m *MockInterface) AddSong(group string, song string) (err error, status int) {
+func (m *MockInterface) AddSong(ctx context.Context, group string, song string) (err error, status int) {
 	args := m.Called(group, song)
 	return args.Error(0), args.Get(1).(int)
 }
 
-func (m *MockInterface) DeleteSong(group string, song string) (err error, status int) {
+func (m *MockInterface) DeleteSong(ctx context.Context, group string, song string) (err error, status int) {
 	args := m.Called(group, song)
 	return args.Error(0), args.Get(1).(int)
 }
 
-func (m *MockInterface) EditSong(group string, song string, date string, text string, link string) (err error, status int) {
+func (m *MockInterface) EditSong(ctx context.Context, group string, song string, date string, text string, link string) (err error, status int) {
 	args := m.Called(group, song, date, text, link)
 	return args.Error(0), args.Get(1).(int)
 }
 
-func (m *MockInterface) GetSongs(page int64, items int64, group string, song string, date string, text string, link string) (result models.AnswerData, err error, status int) {
-	args := m.Called(page, items, group, song, date, text, link)
+func (m *MockInterface) GetSongs(ctx context.Context, query models.SongsQuery) (result models.AnswerData, err error, status int) {
+	args := m.Called(query)
 	return args.Get(0).(models.AnswerData), args.Error(1), args.Get(2).(int)
 }
 
-func (m *MockInterface) GetSongText(couplet int64, group string, song string) (result models.AnswerCoupletData, err error, status int) {
-	args := m.Called(couplet, group, song)
+// StreamSongs passes the rows given to Return to each before returning the error and status.
+func (m *MockInterface) StreamSongs(ctx context.Context, query models.SongsQuery, each func(models.RowDbData) error) (err error, status int) {
+	args := m.Called(query)
+	for _, row := range args.Get(0).([]models.RowDbData) {
+		if err := each(row); err != nil {
+			return err, http.StatusInternalServerError
+		}
+	}
+	return args.Error(1), args.Get(2).(int)
+}
+
+// ImportSongs reads the file so that it is matched by its content.
+func (m *MockInterface) ImportSongs(ctx context.Context, file io.Reader, format string, dryRun bool) (result models.ImportReportData, err error, status int) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return result, err, http.StatusRequestEntityTooLarge
+	}
+	args := m.Called(string(data), format, dryRun)
+	return args.Get(0).(models.ImportReportData), args.Error(1), args.Get(2).(int)
+}
+
+// DumpLibrary writes the archive given to Return before returning the manifest.
+func (m *MockInterface) DumpLibrary(ctx context.Context, w io.Writer) (result models.ManifestData, err error, status int) {
+	args := m.Called()
+	if _, err := io.WriteString(w, args.String(0)); err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return args.Get(1).(models.ManifestData), args.Error(2), args.Get(3).(int)
+}
+
+// RestoreLibrary reads the archive so that it is matched by its content.
+func (m *MockInterface) RestoreLibrary(ctx context.Context, file io.ReaderAt, size int64, replace bool) (result models.ManifestData, err error, status int) {
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, size))
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	args := m.Called(string(data), replace)
+	return args.Get(0).(models.ManifestData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) Authenticate(ctx context.Context, key string) (result models.PrincipalData, err error, status int) {
+	args := m.Called(key)
+	return args.Get(0).(models.PrincipalData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) AuthenticateAnonymous(ctx context.Context) (result models.PrincipalData, err error, status int) {
+	args := m.Called()
+	return args.Get(0).(models.PrincipalData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) GetRoles(ctx context.Context) (result models.AnswerRolesData, err error, status int) {
+	args := m.Called()
+	return args.Get(0).(models.AnswerRolesData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) SetRole(ctx context.Context, name string, permissions []string) (err error, status int) {
+	args := m.Called(name, permissions)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) AssignRole(ctx context.Context, user string, role string) (err error, status int) {
+	args := m.Called(user, role)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) DeleteGroup(ctx context.Context, group string) (err error, status int) {
+	args := m.Called(group)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) RenameGroup(ctx context.Context, group string, name string) (err error, status int) {
+	args := m.Called(group, name)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) AddUser(ctx context.Context, name string, role string) (result models.UserData, err error, status int) {
+	args := m.Called(name, role)
+	return args.Get(0).(models.UserData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) IssueKey(ctx context.Context, user string) (result models.APIKeyData, err error, status int) {
+	args := m.Called(user)
+	return args.Get(0).(models.APIKeyData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) RotateKey(ctx context.Context, id int) (result models.APIKeyData, err error, status int) {
+	args := m.Called(id)
+	return args.Get(0).(models.APIKeyData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) RevokeKey(ctx context.Context, id int) (err error, status int) {
+	args := m.Called(id)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) GetKeys(ctx context.Context, user string) (result models.AnswerKeysData, err error, status int) {
+	args := m.Called(user)
+	return args.Get(0).(models.AnswerKeysData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) CreatePlaylist(ctx context.Context, owner string, title string, description string) (result models.PlaylistData, err error, status int) {
+	args := m.Called(owner, title, description)
+	return args.Get(0).(models.PlaylistData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) EditPlaylist(ctx context.Context, id int, title string, description string) (err error, status int) {
+	args := m.Called(id, title, description)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) DeletePlaylist(ctx context.Context, id int) (err error, status int) {
+	args := m.Called(id)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) GetPlaylist(ctx context.Context, id int) (result models.PlaylistData, err error, status int) {
+	args := m.Called(id)
+	return args.Get(0).(models.PlaylistData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) GetPlaylists(ctx context.Context, owner string) (result models.AnswerPlaylistsData, err error, status int) {
+	args := m.Called(owner)
+	return args.Get(0).(models.AnswerPlaylistsData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) AddPlaylistSong(ctx context.Context, id int, group string, song string, position int) (result models.PlaylistData, err error, status int) {
+	args := m.Called(id, group, song, position)
+	return args.Get(0).(models.PlaylistData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) RemovePlaylistSong(ctx context.Context, id int, position int) (result models.PlaylistData, err error, status int) {
+	args := m.Called(id, position)
+	return args.Get(0).(models.PlaylistData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) MovePlaylistSong(ctx context.Context, id int, from int, to int) (result models.PlaylistData, err error, status int) {
+	args := m.Called(id, from, to)
+	return args.Get(0).(models.PlaylistData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) ExportPlaylist(ctx context.Context, id int, format string) (result string, err error, status int) {
+	args := m.Called(id, format)
+	return args.String(0), args.Error(1), args.Get(2).(int)
+}
+
+// ImportPlaylist reads the file so that it is matched by its content.
+func (m *MockInterface) ImportPlaylist(ctx context.Context, file io.Reader, format string, owner string, title string) (result models.PlaylistImportData, err error, status int) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return result, err, http.StatusRequestEntityTooLarge
+	}
+	args := m.Called(string(data), format, owner, title)
+	return args.Get(0).(models.PlaylistImportData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) GetSongText(ctx context.Context, couplet int64, group string, song string, compact bool, langs []string, kind string) (result models.AnswerCoupletData, err error, status int) {
+	args := m.Called(couplet, group, song, compact, langs, kind)
 	return args.Get(0).(models.AnswerCoupletData), args.Error(1), args.Get(2).(int)
 }
 
+func (m *MockInterface) GetSongStructure(ctx context.Context, group string, song string) (result models.AnswerStructureData, err error, status int) {
+	args := m.Called(group, song)
+	return args.Get(0).(models.AnswerStructureData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) AddLrc(ctx context.Context, group string, song string, text string) (err error, status int) {
+	args := m.Called(group, song, text)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) GetLrc(ctx context.Context, group string, song string) (result string, err error, status int) {
+	args := m.Called(group, song)
+	return args.String(0), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) AddChords(ctx context.Context, group string, song string, source string) (err error, status int) {
+	args := m.Called(group, song, source)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) GetChords(ctx context.Context, group string, song string, transpose int, format string) (result string, err error, status int) {
+	args := m.Called(group, song, transpose, format)
+	return args.String(0), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) GetSongLine(ctx context.Context, group string, song string, offset int64) (result models.AnswerLineData, err error, status int) {
+	args := m.Called(group, song, offset)
+	return args.Get(0).(models.AnswerLineData), args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockInterface) AddLyrics(ctx context.Context, group string, song string, lang string, kind string, text string) (err error, status int) {
+	args := m.Called(group, song, lang, kind, text)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) DeleteLyrics(ctx context.Context, group string, song string, lang string, kind string) (err error, status int) {
+	args := m.Called(group, song, lang, kind)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockInterface) GetLyrics(ctx context.Context, group string, song string) (result models.AnswerLyricsData, err error, status int) {
+	args := m.Called(group, song)
+	return args.Get(0).(models.AnswerLyricsData), args.Error(1), args.Get(2).(int)
+}
+
 func TestAddSong(t *testing.T) {
 	mockinterface := NewMockInterface()
 	handler := &Handler{
@@ -325,18 +529,32 @@ func TestGetSongs(t *testing.T) {
 	expectedResponse := models.AnswerData{
 		Items: []models.RowDbData{
 			{
-				Group: group,
-				Song:  song,
-				Date:  date,
-				Text:  text,
-				Link:  link,
+				Group:          group,
+				Song:           song,
+				Date:           date,
+				Text:           text,
+				Link:           link,
+				Lang:           "en",
+				LangConfidence: 0.98,
 			},
 		},
+		Pagination: &models.PaginationData{Next: "bmV4dA"},
 	}
-	mockinterface.On("GetSongs", int64(page), int64(items), group, song, date, text, link).
+	mockinterface.On("GetSongs", models.SongsQuery{
+		Page:  int64(page),
+		Items: int64(items),
+		Filters: []models.FilterData{
+			{Field: "group", Operator: models.OperatorEq, Values: []string{group}},
+			{Field: "lang", Operator: models.OperatorEq, Values: []string{"en"}},
+			{Field: "link", Operator: models.OperatorEq, Values: []string{link}},
+			{Field: "releaseDate", Operator: models.OperatorEq, Values: []string{date}},
+			{Field: "song", Operator: models.OperatorEq, Values: []string{song}},
+			{Field: "text", Operator: models.OperatorEq, Values: []string{text}},
+		},
+	}).
 		Return(expectedResponse, nil, http.StatusOK).
 		Once()
-	urlStr := fmt.Sprintf("/getdata?page=%d&items=%d&group=%s&song=%s&releaseDate=%s&text=%s&link=%s",
+	urlStr := fmt.Sprintf("/getdata?page=%d&items=%d&group=%s&song=%s&releaseDate=%s&text=%s&link=%s&lang=en",
 		page, items, url.QueryEscape(group), url.QueryEscape(song), url.QueryEscape(date), url.QueryEscape(text), url.QueryEscape(link))
 	req, err := http.NewRequest("GET", urlStr, nil)
 	if err != nil {
@@ -345,6 +563,8 @@ func TestGetSongs(t *testing.T) {
 	rr := httptest.NewRecorder()
 	handler.GetSongs(rr, req)
 	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Link"), "cursor=bmV4dA")
+	assert.Contains(t, rr.Header().Get("Link"), `rel="next"`)
 	var actualResponse models.AnswerData
 	err = json.NewDecoder(rr.Body).Decode(&actualResponse)
 	if err != nil {
@@ -354,6 +574,275 @@ func TestGetSongs(t *testing.T) {
 	mockinterface.AssertExpectations(t)
 }
 
+func TestGetSongs_Fields(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	fields := []string{"song", "releaseDate"}
+	mockinterface.On("GetSongs", models.SongsQuery{Page: 1, Items: 1, Fields: fields, Include: []string{"group"}}).
+		Return(models.AnswerData{
+			Items: []models.RowDbData{{
+				Song:      "Uprising",
+				Date:      "07.09.2009",
+				GroupInfo: &models.GroupData{ID: 1, Name: "Muse", Songs: 12},
+				Fields:    fields,
+			}},
+		}, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("GET", "/getdata?page=1&items=1&fields=song,releaseDate&include=group", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"items": [{"song": "Uprising", "releaseDate": "07.09.2009", "groupInfo": {"id": 1, "name": "Muse", "songs": 12}}]}`, rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetSongs_Export(t *testing.T) {
+	rows := []models.RowDbData{
+		{Group: "Muse", Song: "Uprising", Date: "07.09.2009", GroupInfo: &models.GroupData{ID: 1, Name: "Muse", Songs: 12}, Fields: []string{"group", "song", "releaseDate"}},
+		{Group: "Muse", Song: "Starlight, \"live\"", Date: "", GroupInfo: &models.GroupData{ID: 1, Name: "Muse", Songs: 12}, Fields: []string{"group", "song", "releaseDate"}},
+	}
+	cases := []struct {
+		name        string
+		target      string
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"csv", "/getdata?fields=group,song,releaseDate&include=group", "text/csv", "text/csv; charset=utf-8",
+			"group,song,releaseDate,groupId,groupName,groupSongs\nMuse,Uprising,07.09.2009,1,Muse,12\nMuse,\"Starlight, \"\"live\"\"\",,1,Muse,12\n"},
+		{"ndjson", "/getdata?fields=group,song,releaseDate&include=group", "application/json;q=0.5, application/x-ndjson", "application/x-ndjson",
+			`{"group":"Muse","song":"Uprising","releaseDate":"07.09.2009","groupInfo":{"id":1,"name":"Muse","songs":12}}` + "\n" +
+				`{"group":"Muse","song":"Starlight, \"live\"","releaseDate":"","groupInfo":{"id":1,"name":"Muse","songs":12}}` + "\n"},
+		{"xml", "/getdata?format=xml&fields=group,song,releaseDate&include=group", "text/csv", "application/xml; charset=utf-8",
+			xml.Header + "<songs><song><group>Muse</group><song>Uprising</song><releaseDate>07.09.2009</releaseDate><groupInfo><id>1</id><name>Muse</name><songs>12</songs></groupInfo></song>" +
+				"<song><group>Muse</group><song>Starlight, &#34;live&#34;</song><releaseDate></releaseDate><groupInfo><id>1</id><name>Muse</name><songs>12</songs></groupInfo></song></songs>"},
+	}
+	for _, c := range cases {
+		mockinterface := NewMockInterface()
+		handler := &Handler{
+			mockinterface,
+		}
+		mockinterface.On("StreamSongs", models.SongsQuery{Fields: []string{"group", "song", "releaseDate"}, Include: []string{"group"}}).
+			Return(rows, nil, http.StatusOK).
+			Once()
+		req, err := http.NewRequest("GET", c.target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", c.accept)
+		rr := httptest.NewRecorder()
+		handler.GetSongs(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, c.name)
+		assert.Equal(t, c.contentType, rr.Header().Get("Content-Type"), c.name)
+		assert.Equal(t, "Accept", rr.Header().Get("Vary"), c.name)
+		assert.Equal(t, c.body, rr.Body.String(), c.name)
+		mockinterface.AssertExpectations(t)
+	}
+}
+
+func TestGetSongs_ExportError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("StreamSongs", models.SongsQuery{Items: 10, Page: 2}).
+		Return([]models.RowDbData{}, errors.New("Error selecting data"), http.StatusInternalServerError).
+		Once()
+	req, err := http.NewRequest("GET", "/getdata?page=2&items=10&format=csv", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongs(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "Error selecting data\n", rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetSongs_Negotiation(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	cases := []struct {
+		target string
+		accept string
+		status int
+	}{
+		{"/getdata", "text/csv;q=0, application/json;q=0", http.StatusNotAcceptable},
+		{"/getdata", "text/csv;q=0, */*;q=0", http.StatusNotAcceptable},
+		{"/getdata?format=yaml", "", http.StatusBadRequest},
+		{"/getdata?page=1&format=csv", "", http.StatusBadRequest},
+		{"/getdata", "*/*", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", c.target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", c.accept)
+		rr := httptest.NewRecorder()
+		handler.GetSongs(rr, req)
+		assert.Equal(t, c.status, rr.Code, c.target+" "+c.accept)
+	}
+	mockinterface.AssertExpectations(t)
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		accept string
+		format string
+	}{
+		{"", "json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "json"},
+		{"text/html", "json"},
+		{"*/*", "json"},
+		{"application/xml", "xml"},
+		{"application/xml, text/xml", "xml"},
+		{"application/xml;q=0.9, */*;q=0.8", "xml"},
+		{"application/xml, application/json", "json"},
+		{"text/csv, application/json;q=0.5", "csv"},
+		{"application/json, text/csv;q=0", "json"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/getdata", nil)
+		req.Header.Set("Accept", c.accept)
+		format, err := negotiateFormat(req)
+		assert.NoError(t, err, c.accept)
+		assert.Equal(t, c.format, format, c.accept)
+	}
+}
+
+func TestImportSongs(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	file := "group,song\nMuse,Uprising\n"
+	report := models.ImportReportData{
+		DryRun:   true,
+		Inserted: 1,
+		Rows:     []models.ImportRowData{{Row: 1, Group: "Muse", Song: "Uprising", Status: models.ImportInserted}},
+	}
+	mockinterface.On("ImportSongs", file, "csv", true).
+		Return(report, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("POST", "/importsongs?dryRun=true", strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	rr := httptest.NewRecorder()
+	handler.ImportSongs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"dryRun": true, "inserted": 1, "skipped": 0, "conflicting": 0, "invalid": 0, "rows": [{"row": 1, "group": "Muse", "song": "Uprising", "status": "inserted"}]}`, rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestImportSongs_Errors(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("ImportSongs", "[", "json", false).
+		Return(models.ImportReportData{}, errors.New("Invalid JSON: expected an array of songs"), http.StatusBadRequest).
+		Once()
+	cases := []struct {
+		target      string
+		contentType string
+		status      int
+	}{
+		{"/importsongs", "text/plain", http.StatusBadRequest},
+		{"/importsongs?format=json&dryRun=maybe", "", http.StatusBadRequest},
+		{"/importsongs?format=json", "text/csv", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", c.target, strings.NewReader("["))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", c.contentType)
+		rr := httptest.NewRecorder()
+		handler.ImportSongs(rr, req)
+		assert.Equal(t, c.status, rr.Code, c.target)
+	}
+	mockinterface.AssertExpectations(t)
+}
+
+func TestDumpLibrary(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("DumpLibrary").
+		Return("PK", models.ManifestData{Format: "song-library", Version: 1}, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("GET", "/dumplibrary", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.DumpLibrary(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "attachment; filename=\"library-")
+	assert.Equal(t, "PK", rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestDumpLibrary_Error(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("DumpLibrary").
+		Return("", models.ManifestData{}, errors.New("Error dumping data"), http.StatusInternalServerError).
+		Once()
+	req, err := http.NewRequest("GET", "/dumplibrary", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.DumpLibrary(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.NotEqual(t, "application/zip", rr.Header().Get("Content-Type"))
+	mockinterface.AssertExpectations(t)
+}
+
+func TestRestoreLibrary(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	manifest := models.ManifestData{Format: "song-library", Version: 1, SchemaVersion: 1, Files: []models.ManifestFileData{{Name: "groups.jsonl", Records: 1}}}
+	mockinterface.On("RestoreLibrary", "PK archive", true).
+		Return(manifest, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("POST", "/restorelibrary?mode=replace", strings.NewReader("PK archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.RestoreLibrary(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var actualResponse models.ManifestData
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&actualResponse))
+	assert.Equal(t, manifest, actualResponse)
+	req, err = http.NewRequest("POST", "/restorelibrary?mode=overwrite", strings.NewReader("PK archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	handler.RestoreLibrary(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockinterface.AssertExpectations(t)
+}
+
 func TestGetSongs_ParseIntPageError(t *testing.T) {
 	mockinterface := NewMockInterface()
 	handler := &Handler{
@@ -414,7 +903,17 @@ func TestGetSongs_GetSongsError(t *testing.T) {
 	date := "16.07.2006"
 	text := "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight"
 	link := "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
-	mockinterface.On("GetSongs", int64(page), int64(items), group, song, date, text, link).
+	mockinterface.On("GetSongs", models.SongsQuery{
+		Page:  int64(page),
+		Items: int64(items),
+		Filters: []models.FilterData{
+			{Field: "group", Operator: models.OperatorEq, Values: []string{group}},
+			{Field: "link", Operator: models.OperatorEq, Values: []string{link}},
+			{Field: "releaseDate", Operator: models.OperatorEq, Values: []string{date}},
+			{Field: "song", Operator: models.OperatorEq, Values: []string{song}},
+			{Field: "text", Operator: models.OperatorEq, Values: []string{text}},
+		},
+	}).
 		Return(models.AnswerData{}, errors.New("error getting songs"), http.StatusInternalServerError).
 		Once()
 	urlStr := fmt.Sprintf("/getdata?page=%d&items=%d&group=%s&song=%s&releaseDate=%s&text=%s&link=%s",
@@ -462,7 +961,17 @@ func TestGetSongs_NewEncoderEncodeError(t *testing.T) {
 			},
 		},
 	}
-	mockinterface.On("GetSongs", int64(page), int64(items), group, song, date, text, link).
+	mockinterface.On("GetSongs", models.SongsQuery{
+		Page:  int64(page),
+		Items: int64(items),
+		Filters: []models.FilterData{
+			{Field: "group", Operator: models.OperatorEq, Values: []string{group}},
+			{Field: "link", Operator: models.OperatorEq, Values: []string{link}},
+			{Field: "releaseDate", Operator: models.OperatorEq, Values: []string{date}},
+			{Field: "song", Operator: models.OperatorEq, Values: []string{song}},
+			{Field: "text", Operator: models.OperatorEq, Values: []string{text}},
+		},
+	}).
 		Return(expectedResponse, nil, http.StatusOK).
 		Once()
 	urlStr := fmt.Sprintf("/getdata?page=%d&items=%d&group=%s&song=%s&releaseDate=%s&text=%s&link=%s",
@@ -489,7 +998,7 @@ func TestGetSongText(t *testing.T) {
 	expectedResponse := models.AnswerCoupletData{
 		Text: "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?",
 	}
-	mockinterface.On("GetSongText", int64(couplet), group, song).
+	mockinterface.On("GetSongText", int64(couplet), group, song, false, []string(nil), "").
 		Return(expectedResponse, nil, http.StatusOK).
 		Once()
 	urlStr := fmt.Sprintf("/getsongtext?couplet=%d&group=%s&song=%s",
@@ -538,7 +1047,7 @@ func TestGetSongText_GetSongsError(t *testing.T) {
 	couplet := 1
 	group := "Muse"
 	song := "Supermassive Black Hole"
-	mockinterface.On("GetSongText", int64(couplet), group, song).
+	mockinterface.On("GetSongText", int64(couplet), group, song, false, []string(nil), "").
 		Return(models.AnswerCoupletData{}, errors.New("error getting song text"), http.StatusInternalServerError).
 		Once()
 	urlStr := fmt.Sprintf("/getsongtext?couplet=%d&group=%s&song=%s",
@@ -554,6 +1063,25 @@ func TestGetSongText_GetSongsError(t *testing.T) {
 	mockinterface.AssertExpectations(t)
 }
 
+func TestGetSongText_NoSuchSong(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("GetSongText", int64(1), "Muse", "Unknown", false, []string(nil), "").
+		Return(models.AnswerCoupletData{}, errors.New("The song is not found"), http.StatusNotFound).
+		Once()
+	req, err := http.NewRequest("GET", "/getsongtext?couplet=1&group=Muse&song=Unknown", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongText(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "The song is not found\n", rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
 func TestGetSongText_NewEncoderEncodeError(t *testing.T) {
 	mockinterface := NewMockInterface()
 	handler := &Handler{
@@ -565,7 +1093,7 @@ func TestGetSongText_NewEncoderEncodeError(t *testing.T) {
 	expectedResponse := models.AnswerCoupletData{
 		Text: "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?",
 	}
-	mockinterface.On("GetSongText", int64(couplet), group, song).
+	mockinterface.On("GetSongText", int64(couplet), group, song, false, []string(nil), "").
 		Return(expectedResponse, nil, http.StatusOK).
 		Once()
 	urlStr := fmt.Sprintf("/getsongtext?couplet=%d&group=%s&song=%s",
@@ -580,3 +1108,541 @@ func TestGetSongText_NewEncoderEncodeError(t *testing.T) {
 	assert.Contains(t, rr.Body.String(), "forced encoding error")
 	mockinterface.AssertExpectations(t)
 }
+
+func TestGetSongText_Compact(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	couplet := 3
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	expectedResponse := models.AnswerCoupletData{
+		Text: "[Repeat verse 2]",
+	}
+	mockinterface.On("GetSongText", int64(couplet), group, song, true, []string(nil), "").
+		Return(expectedResponse, nil, http.StatusOK).
+		Once()
+	urlStr := fmt.Sprintf("/getsongtext?couplet=%d&group=%s&song=%s&compact=true",
+		couplet, url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongText(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var actualResponse models.AnswerCoupletData
+	err = json.NewDecoder(rr.Body).Decode(&actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, expectedResponse, actualResponse)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetSongText_ParseBoolCompactError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	urlStr := fmt.Sprintf("/getsongtext?couplet=1&group=%s&song=%s&compact=maybe",
+		url.QueryEscape("Muse"), url.QueryEscape("Supermassive Black Hole"))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongText(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "strconv.ParseBool: parsing \"maybe\": invalid syntax")
+}
+
+func TestGetSongStructure(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	expectedResponse := models.AnswerStructureData{
+		Verses: []models.VerseData{
+			{Number: 1, Text: "Ooh\nYou set my soul alight"},
+			{Number: 2, Text: "[Repeat verse 1]", RepeatOf: 1, Exact: true},
+		},
+		Unique:  1,
+		Repeats: 1,
+		Hook:    1,
+	}
+	mockinterface.On("GetSongStructure", group, song).
+		Return(expectedResponse, nil, http.StatusOK).
+		Once()
+	urlStr := fmt.Sprintf("/getsongstructure?group=%s&song=%s",
+		url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongStructure(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var actualResponse models.AnswerStructureData
+	err = json.NewDecoder(rr.Body).Decode(&actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, expectedResponse, actualResponse)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetSongStructure_GetSongStructureError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	mockinterface.On("GetSongStructure", group, song).
+		Return(models.AnswerStructureData{}, errors.New("error getting song structure"), http.StatusInternalServerError).
+		Once()
+	urlStr := fmt.Sprintf("/getsongstructure?group=%s&song=%s",
+		url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongStructure(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, rr.Body.String(), "error getting song structure\n")
+	mockinterface.AssertExpectations(t)
+}
+
+func TestAddLrc(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	requestData := models.LrcRequestData{
+		Group: "Muse",
+		Song:  "Supermassive Black Hole",
+		Lrc:   "[00:12.00]Ooh baby, don't you know I suffer?",
+	}
+	requestBody, _ := json.Marshal(requestData)
+	mockinterface.On("AddLrc", requestData.Group, requestData.Song, requestData.Lrc).
+		Return(nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("POST", "/addlrc", bytes.NewReader(requestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.AddLrc(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestAddLrc_UnmarshalError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	req, err := http.NewRequest("POST", "/addlrc", bytes.NewReader([]byte(`{"group": "Muse", "lrc":`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.AddLrc(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unexpected end of JSON input")
+}
+
+func TestAddLrc_AddLrcError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	requestData := models.LrcRequestData{
+		Group: "Muse",
+		Song:  "Supermassive Black Hole",
+		Lrc:   "Ooh baby, don't you know I suffer?",
+	}
+	requestBody, _ := json.Marshal(requestData)
+	mockinterface.On("AddLrc", requestData.Group, requestData.Song, requestData.Lrc).
+		Return(errors.New("There are no timed lines"), http.StatusBadRequest).
+		Once()
+	req, err := http.NewRequest("POST", "/addlrc", bytes.NewReader(requestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.AddLrc(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "There are no timed lines\n", rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetLrc(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	expectedResponse := "[ar:Muse]\n[ti:Supermassive Black Hole]\n[00:12.00]Ooh baby, don't you know I suffer?\n"
+	mockinterface.On("GetLrc", group, song).
+		Return(expectedResponse, nil, http.StatusOK).
+		Once()
+	urlStr := fmt.Sprintf("/getlrc?group=%s&song=%s", url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetLrc(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, expectedResponse, rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetLrc_GetLrcError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	mockinterface.On("GetLrc", group, song).
+		Return("", errors.New("There are no timed lines for the song"), http.StatusNotFound).
+		Once()
+	urlStr := fmt.Sprintf("/getlrc?group=%s&song=%s", url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetLrc(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestAddChords(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	requestData := models.ChordsRequestData{
+		Group:    "Muse",
+		Song:     "Supermassive Black Hole",
+		ChordPro: "[Em]Ooh baby, don't you [G]know I suffer?",
+	}
+	requestBody, _ := json.Marshal(requestData)
+	mockinterface.On("AddChords", requestData.Group, requestData.Song, requestData.ChordPro).
+		Return(nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("POST", "/addchords", bytes.NewReader(requestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.AddChords(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestAddChords_UnmarshalError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	req, err := http.NewRequest("POST", "/addchords", bytes.NewReader([]byte(`{"group": "Muse", "chordpro":`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.AddChords(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unexpected end of JSON input")
+}
+
+func TestGetChords(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	expectedResponse := "F#m                 A\nOoh baby, don't you know I suffer?\n"
+	mockinterface.On("GetChords", group, song, 2, "text").
+		Return(expectedResponse, nil, http.StatusOK).
+		Once()
+	urlStr := fmt.Sprintf("/getchords?group=%s&song=%s&transpose=+2", url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetChords(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, expectedResponse, rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetChords_ParseTransposeError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	req, err := http.NewRequest("GET", "/getchords?group=Muse&song=Uprising&transpose=up", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetChords(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetChords_GetChordsError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("GetChords", "Muse", "Uprising", -3, "chordpro").
+		Return("", errors.New("There are no chords for the song"), http.StatusNotFound).
+		Once()
+	req, err := http.NewRequest("GET", "/getchords?group=Muse&song=Uprising&transpose=-3&format=chordpro", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetChords(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "There are no chords for the song\n", rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetSongLine(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	next := int64(16000)
+	expectedResponse := models.AnswerLineData{
+		Index:    1,
+		Time:     12000,
+		Text:     "Ooh baby, don't you know I suffer?",
+		NextTime: &next,
+	}
+	mockinterface.On("GetSongLine", group, song, int64(12700)).
+		Return(expectedResponse, nil, http.StatusOK).
+		Once()
+	urlStr := fmt.Sprintf("/getsongline?group=%s&song=%s&offset=12700", url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongLine(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var actualResponse models.AnswerLineData
+	err = json.NewDecoder(rr.Body).Decode(&actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, expectedResponse, actualResponse)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetSongLine_ParseIntOffsetError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	req, err := http.NewRequest("GET", "/getsongline?group=Muse&song=Uprising&offset=12s", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongLine(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "strconv.ParseInt: parsing \"12s\": invalid syntax")
+}
+
+func TestGetSongText_Lang(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	couplet := 1
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	expectedResponse := models.AnswerCoupletData{
+		Text:        "Ooh baby, don't you know I suffer?",
+		Lang:        "ru",
+		Kind:        "translation",
+		Translation: "О, детка, разве ты не знаешь, что я страдаю?",
+	}
+	mockinterface.On("GetSongText", int64(couplet), group, song, false, []string{"ru", "de-AT", "en"}, "translation").
+		Return(expectedResponse, nil, http.StatusOK).
+		Once()
+	urlStr := fmt.Sprintf("/getsongtext?couplet=%d&group=%s&song=%s&lang=ru&kind=translation",
+		couplet, url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Language", "de-AT, en;q=0.8")
+	rr := httptest.NewRecorder()
+	handler.GetSongText(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "Accept-Language", rr.Header().Get("Vary"))
+	var actualResponse models.AnswerCoupletData
+	err = json.NewDecoder(rr.Body).Decode(&actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, expectedResponse, actualResponse)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetSongText_LangError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	req, err := http.NewRequest("GET", "/getsongtext?couplet=1&group=Muse&song=Uprising&lang=e", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetSongText(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "Invalid language tag \"e\"\n", rr.Body.String())
+}
+
+func TestAddLyrics(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	requestData := models.LyricsRequestData{
+		Group: "Muse",
+		Song:  "Supermassive Black Hole",
+		Lang:  "ru",
+		Kind:  "translation",
+		Text:  "О, детка, разве ты не знаешь, что я страдаю?",
+	}
+	requestBody, _ := json.Marshal(requestData)
+	mockinterface.On("AddLyrics", requestData.Group, requestData.Song, requestData.Lang, requestData.Kind, requestData.Text).
+		Return(nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("POST", "/addlyrics", bytes.NewReader(requestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.AddLyrics(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestAddLyrics_UnmarshalError(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	req, err := http.NewRequest("POST", "/addlyrics", bytes.NewReader([]byte(`{"group": "Muse", "lang":`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.AddLyrics(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unexpected end of JSON input")
+}
+
+func TestDeleteLyrics(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	requestData := models.LyricsRequestData{
+		Group: "Muse",
+		Song:  "Supermassive Black Hole",
+		Lang:  "ru",
+		Kind:  "translation",
+	}
+	requestBody, _ := json.Marshal(requestData)
+	mockinterface.On("DeleteLyrics", requestData.Group, requestData.Song, requestData.Lang, requestData.Kind).
+		Return(errors.New("error deleting lyrics"), http.StatusInternalServerError).
+		Once()
+	req, err := http.NewRequest("POST", "/deletelyrics", bytes.NewReader(requestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.DeleteLyrics(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "error deleting lyrics\n", rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetLyrics(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	group := "Muse"
+	song := "Supermassive Black Hole"
+	expectedResponse := models.AnswerLyricsData{
+		Items: []models.LyricsData{{Lang: "ru", Kind: "translation", Text: "О, детка"}},
+	}
+	mockinterface.On("GetLyrics", group, song).
+		Return(expectedResponse, nil, http.StatusOK).
+		Once()
+	urlStr := fmt.Sprintf("/getlyrics?group=%s&song=%s", url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetLyrics(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var actualResponse models.AnswerLyricsData
+	err = json.NewDecoder(rr.Body).Decode(&actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, expectedResponse, actualResponse)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestLiftDeadlines(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dumplibrary" {
+			liftDeadlines(w)
+		}
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("archive"))
+	}))
+	server.Config.WriteTimeout = 20 * time.Millisecond
+	server.Start()
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/dumplibrary")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "archive", string(body))
+	}
+	_, err = http.Get(server.URL + "/getdata")
+	assert.Error(t, err)
+}
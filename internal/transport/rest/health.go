@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"test/internal/health"
+	"test/internal/models"
+)
+
+// ReadinessChecker checks the dependencies of the server, *health.Checker.
+type ReadinessChecker interface {
+	Ready(ctx context.Context) (models.HealthData, bool)
+}
+
+// Healthz godoc
+// @Summary Liveness
+// @Description Tell that the process is alive and serving, the dependencies are not checked.
+// @Tags health
+// @Produce  json
+// @Success 200 {object} models.HealthData
+// @Router /healthz [get]
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, models.HealthData{Status: health.OK})
+}
+
+// Readyz godoc
+// @Summary Readiness
+// @Description Check that the database answers within the timeout, that its tables are migrated to the schema version of the server and that the circuit of the metadata provider is not open. Every dependency is reported with its status, the time its check took and its error.
+// @Tags health
+// @Produce  json
+// @Success 200 {object} models.HealthData
+// @Failure 503 {object} models.HealthData
+// @Router /readyz [get]
+func Readyz(checker ReadinessChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, ready := checker.Ready(r.Context())
+		w.Header().Set("Cache-Control", "no-store")
+		if !ready {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		writeJSON(w, r, result)
+	}
+}
@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"test/internal/models"
+	"testing"
+)
+
+// readiness returns the result it holds.
+type readiness struct {
+	result models.HealthData
+	ready  bool
+}
+
+func (c *readiness) Ready(ctx context.Context) (models.HealthData, bool) {
+	return c.result, c.ready
+}
+
+func TestHealthz(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Healthz(rr, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	checker := &readiness{result: models.HealthData{Status: "ok", Checks: map[string]models.HealthCheckData{
+		"database": {Status: "ok", Duration: "1ms"},
+	}}, ready: true}
+	rr := httptest.NewRecorder()
+	Readyz(checker).ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"status":"ok","checks":{"database":{"status":"ok","duration":"1ms"}}}`, rr.Body.String())
+
+	checker.result = models.HealthData{Status: "fail", Checks: map[string]models.HealthCheckData{
+		"database": {Status: "fail", Duration: "2s", Error: "context deadline exceeded"},
+	}}
+	checker.ready = false
+	rr = httptest.NewRecorder()
+	Readyz(checker).ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"status":"fail","checks":{"database":{"status":"fail","duration":"2s","error":"context deadline exceeded"}}}`, rr.Body.String())
+}
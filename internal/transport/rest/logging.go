@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"test/internal/logging"
+	"time"
+)
+
+// RequestIDHeader carries the id of the request, the id of the caller is kept when it is valid.
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestLog attaches the id and the route of the request to its context, so every record logged
+// while serving it carries them, and logs the outcome of the request. It runs before the other
+// middlewares.
+func RequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := RequestID(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, id)
+		ctx := logging.WithRequest(r.Context(), id, r.URL.Path)
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		level := slog.LevelInfo
+		if recorder.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "Request completed", "method", r.Method, "status", recorder.Status(), "duration", time.Since(start).String())
+	})
+}
+
+// RequestID returns the id given by the caller when it is valid, otherwise a new random id.
+func RequestID(given string) string {
+	if requestIDPattern.MatchString(given) {
+		return given
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// statusRecorder keeps the status of the response for the log and the metrics.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// Status returns the status of the response, 200 when the handler wrote nothing.
+func (w *statusRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush lets the streamed exports and dumps through.
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
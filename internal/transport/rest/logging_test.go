@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/internal/logging"
+	"testing"
+)
+
+func TestRequestLog(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logging.New(&logs, slog.LevelDebug))
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("Authenticate", "sl_valid").
+		Return(alice, nil, http.StatusOK)
+	server := RequestLog(handler.Admit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slog.DebugContext(r.Context(), "Request data", "text", strings.Repeat("la ", 40))
+		http.Error(w, "Song not found", http.StatusNotFound)
+	}), nil, nil))
+	req, err := http.NewRequestWithContext(context.Background(), "GET", "/getsongtext", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", "sl_valid")
+	req.Header.Set(RequestIDHeader, "req-42")
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "req-42", rr.Header().Get(RequestIDHeader))
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if !assert.Len(t, lines, 2) {
+		return
+	}
+	var data, completed map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &data))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &completed))
+	assert.Equal(t, "req-42", data["request_id"])
+	assert.Equal(t, "/getsongtext", data["route"])
+	assert.Equal(t, alice.User, data["user"])
+	assert.Equal(t, "default", data["tenant"])
+	assert.Equal(t, strings.Repeat("la ", 21)+"l…(56 more bytes)", data["text"])
+	assert.Equal(t, "Request completed", completed["msg"])
+	assert.Equal(t, "GET", completed["method"])
+	assert.Equal(t, float64(http.StatusNotFound), completed["status"])
+	assert.Equal(t, alice.User, completed["user"])
+
+	// an invalid id of the caller is replaced
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	assert.Regexp(t, "^[0-9a-f]{16}$", rr.Header().Get(RequestIDHeader))
+}
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestObserver records the duration and the status of the served requests.
+type RequestObserver interface {
+	ObserveRequest(route string, method string, status int, duration time.Duration)
+}
+
+// Router finds the route pattern of a request, *http.ServeMux.
+type Router interface {
+	Handler(r *http.Request) (h http.Handler, pattern string)
+}
+
+// Instrument records every request with the pattern of its route in the router, the requests of
+// unknown routes are recorded as unmatched so the paths of the callers do not become labels. It
+// runs before the key and token checks so the rejected requests are recorded as well.
+func Instrument(observer RequestObserver, router Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		_, route := router.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		observer.ObserveRequest(route, r.Method, recorder.Status(), time.Since(start))
+	})
+}
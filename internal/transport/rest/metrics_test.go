@@ -0,0 +1,30 @@
+package rest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// observedRequests records the requests in memory.
+type observedRequests []string
+
+func (o *observedRequests) ObserveRequest(route string, method string, status int, duration time.Duration) {
+	*o = append(*o, method+" "+route+" "+http.StatusText(status))
+}
+
+func TestInstrument(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/getdata", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/addsong", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+	})
+	observed := &observedRequests{}
+	server := Instrument(observed, mux, mux)
+	for _, target := range []string{"/getdata?group=Muse", "/addsong", "/wp-admin/setup.php"} {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+	assert.Equal(t, &observedRequests{"GET /getdata OK", "GET /addsong Bad Request", "GET unmatched Not Found"}, observed)
+}
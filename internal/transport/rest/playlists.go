@@ -0,0 +1,386 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"test/internal/models"
+	"test/internal/playlist"
+)
+
+// playlistFormats are the formats of the imported playlists by their content types.
+var playlistFormats = map[string]string{
+	"application/vnd.apple.mpegurl": "m3u8",
+	"audio/x-mpegurl":               "m3u8",
+	"audio/mpegurl":                 "m3u8",
+	"application/xspf+xml":          "xspf",
+}
+
+// maxPlaylistSize is the largest playlist file that can be imported.
+const maxPlaylistSize = 8 << 20
+
+// readJSON decodes the JSON body of the request.
+func readJSON(r *http.Request, v interface{}) (err error, status int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read request body", "error", err)
+		return err, http.StatusInternalServerError
+	}
+	slog.DebugContext(r.Context(), "Request body", "body", string(body))
+	if err = json.Unmarshal(body, v); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to unmarshal request body", "error", err)
+		return err, http.StatusBadRequest
+	}
+	return nil, http.StatusOK
+}
+
+// writeJSON encodes the result as the JSON body of the response.
+func writeJSON(w http.ResponseWriter, r *http.Request, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// playlistID reads the id query parameter.
+func playlistID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// AddPlaylist godoc
+// @Summary Add a playlist
+// @Description Create an empty playlist based on title and description provided as json. The playlist belongs to the caller, the owner of the json names another user for the callers with users:manage.
+// @Tags playlists
+// @Accept json
+// @Produce  json
+// @Param data body models.PlaylistRequestData true "JSON with owner, title and description"
+// @Success 200 {object} models.PlaylistData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /addplaylist [post]
+func (h *Handler) AddPlaylist(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to add playlist")
+	var respdata models.PlaylistRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "owner", respdata.Owner, "title", respdata.Title)
+	result, err, status := h.service.CreatePlaylist(r.Context(), respdata.Owner, respdata.Title, respdata.Description)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// EditPlaylist godoc
+// @Summary Edit a playlist
+// @Description Change the title and description of the playlist based on id, title and description provided as json. Only the owner and the callers with users:manage change a playlist.
+// @Tags playlists
+// @Accept json
+// @Produce  json
+// @Param data body models.PlaylistRequestData true "JSON with id, title and description"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /editplaylist [post]
+func (h *Handler) EditPlaylist(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to edit playlist")
+	var respdata models.PlaylistRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "id", respdata.ID, "title", respdata.Title)
+	err, status := h.service.EditPlaylist(r.Context(), respdata.ID, respdata.Title, respdata.Description)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Edited playlist in the database")
+}
+
+// DeletePlaylist godoc
+// @Summary Delete a playlist
+// @Description Delete the playlist based on id provided as json, its songs are kept in the library.
+// @Tags playlists
+// @Accept json
+// @Produce  json
+// @Param data body models.PlaylistRequestData true "JSON with id"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /deleteplaylist [post]
+func (h *Handler) DeletePlaylist(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to delete playlist")
+	var respdata models.PlaylistRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "id", respdata.ID)
+	err, status := h.service.DeletePlaylist(r.Context(), respdata.ID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Deleted playlist from the database")
+}
+
+// GetPlaylist godoc
+// @Summary Get a playlist
+// @Description Retrieve the playlist with its songs in their order based on id provided as query parameter.
+// @Tags playlists
+// @Produce  json
+// @Param id query integer true "Playlist id" example(1)
+// @Success 200 {object} models.PlaylistData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /getplaylist [get]
+func (h *Handler) GetPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := playlistID(r)
+	if err != nil {
+		http.Error(w, "Invalid id: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "id", id)
+	result, err, status := h.service.GetPlaylist(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// GetPlaylists godoc
+// @Summary Get playlists
+// @Description Retrieve the playlists of the owner provided as query parameter, or of every owner without it, without their songs.
+// @Tags playlists
+// @Produce  json
+// @Param owner query string false "Owner" example("alice")
+// @Success 200 {object} models.AnswerPlaylistsData "OK"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /getplaylists [get]
+func (h *Handler) GetPlaylists(w http.ResponseWriter, r *http.Request) {
+	owner := r.URL.Query().Get("owner")
+	slog.InfoContext(r.Context(), "Request data", "owner", owner)
+	result, err, status := h.service.GetPlaylists(r.Context(), owner)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// AddPlaylistSong godoc
+// @Summary Add a song to a playlist
+// @Description Insert the song at the position of the playlist moving the following songs down, or append it without a position, based on id, group, song and position provided as json. The same song can be added several times.
+// @Tags playlists
+// @Accept json
+// @Produce  json
+// @Param data body models.PlaylistSongRequestData true "JSON with id, group, song and position"
+// @Success 200 {object} models.PlaylistData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /addplaylistsong [post]
+func (h *Handler) AddPlaylistSong(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to add song to playlist")
+	var respdata models.PlaylistSongRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "id", respdata.ID, "group", respdata.Group, "song", respdata.Song, "position", respdata.Position)
+	result, err, status := h.service.AddPlaylistSong(r.Context(), respdata.ID, respdata.Group, respdata.Song, respdata.Position)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// RemovePlaylistSong godoc
+// @Summary Remove a song from a playlist
+// @Description Remove the song at the position of the playlist moving the following songs up, based on id and position provided as json.
+// @Tags playlists
+// @Accept json
+// @Produce  json
+// @Param data body models.PlaylistSongRequestData true "JSON with id and position"
+// @Success 200 {object} models.PlaylistData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /removeplaylistsong [post]
+func (h *Handler) RemovePlaylistSong(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to remove song from playlist")
+	var respdata models.PlaylistSongRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "id", respdata.ID, "position", respdata.Position)
+	result, err, status := h.service.RemovePlaylistSong(r.Context(), respdata.ID, respdata.Position)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// MovePlaylistSong godoc
+// @Summary Reorder a playlist
+// @Description Move the song of the playlist from a position to another, the songs between them move by one position, based on id, from and to provided as json.
+// @Tags playlists
+// @Accept json
+// @Produce  json
+// @Param data body models.PlaylistMoveRequestData true "JSON with id, from and to"
+// @Success 200 {object} models.PlaylistData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /moveplaylistsong [post]
+func (h *Handler) MovePlaylistSong(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to move song in playlist")
+	var respdata models.PlaylistMoveRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "id", respdata.ID, "from", respdata.From, "to", respdata.To)
+	result, err, status := h.service.MovePlaylistSong(r.Context(), respdata.ID, respdata.From, respdata.To)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// ExportPlaylist godoc
+// @Summary Export a playlist
+// @Description Export the playlist as M3U8 or XSPF based on id and format provided as query parameters. Songs are located by their links, or by "group - song" without one.
+// @Tags playlists
+// @Produce  application/vnd.apple.mpegurl
+// @Produce  application/xspf+xml
+// @Param id query integer true "Playlist id" example(1)
+// @Param format query string true "Format" Enums(m3u8, xspf)
+// @Success 200 {string} string "Playlist"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /exportplaylist [get]
+func (h *Handler) ExportPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := playlistID(r)
+	if err != nil {
+		http.Error(w, "Invalid id: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	slog.InfoContext(r.Context(), "Request data", "id", id, "format", format)
+	result, err, status := h.service.ExportPlaylist(r.Context(), id, format)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", playlist.ContentTypes[format])
+	w.Header().Set("Content-Disposition", "attachment; filename=\"playlist-"+strconv.Itoa(id)+"."+format+"\"")
+	if _, err = io.WriteString(w, result); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write response", "error", err)
+		return
+	}
+}
+
+// ImportPlaylist godoc
+// @Summary Import a playlist
+// @Description Create a playlist of the caller, or of the owner for the callers with users:manage, from an M3U8 or XSPF file, given by the format parameter or the Content-Type header. The entries are matched to the songs of the library by group and song name ignoring the case, from the "group - song" titles of M3U8 and the creator and title of XSPF tracks, the entries without a match are returned as unresolved. The title of the file is used without the title parameter.
+// @Tags playlists
+// @Accept application/vnd.apple.mpegurl
+// @Accept application/xspf+xml
+// @Produce  json
+// @Param owner query string false "Owner" example("alice")
+// @Param title query string false "Title, the title of the file by default" example("Friday setlist")
+// @Param format query string false "Format of the file, overrides the Content-Type header" Enums(m3u8, xspf)
+// @Param data body string true "Playlist file"
+// @Success 200 {object} models.PlaylistImportData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /importplaylist [post]
+func (h *Handler) ImportPlaylist(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to import playlist")
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		format = playlistFormats[mediatype]
+	}
+	if format == "" {
+		http.Error(w, "The format of the playlist is required, expected m3u8 or xspf", http.StatusBadRequest)
+		return
+	}
+	slog.InfoContext(r.Context(), "Request data", "owner", query.Get("owner"), "title", query.Get("title"), "format", format)
+	result, err, status := h.service.ImportPlaylist(r.Context(), http.MaxBytesReader(w, r.Body, maxPlaylistSize), format, query.Get("owner"), query.Get("title"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
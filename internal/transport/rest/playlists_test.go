@@ -0,0 +1,192 @@
+package rest
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/internal/models"
+	"testing"
+)
+
+func TestAddPlaylist(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("CreatePlaylist", "alice", "Friday setlist", "Encore included").
+		Return(models.PlaylistData{ID: 1, Owner: "alice", Title: "Friday setlist", Description: "Encore included"}, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("POST", "/addplaylist", strings.NewReader(`{"owner": "alice", "title": "Friday setlist", "description": "Encore included"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.AddPlaylist(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"id": 1, "owner": "alice", "title": "Friday setlist", "description": "Encore included"}`, rr.Body.String())
+	req, err = http.NewRequest("POST", "/addplaylist", strings.NewReader(`{"owner": `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	handler.AddPlaylist(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestDeletePlaylist_NotFound(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("DeletePlaylist", 9).
+		Return(errors.New("The playlist is not found"), http.StatusNotFound).
+		Once()
+	req, err := http.NewRequest("POST", "/deleteplaylist", strings.NewReader(`{"id": 9}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.DeletePlaylist(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "The playlist is not found\n", rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetPlaylist(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("GetPlaylist", 1).
+		Return(models.PlaylistData{ID: 1, Owner: "alice", Title: "Friday setlist", Songs: []models.PlaylistSongData{{Position: 1, Group: "Muse", Song: "Uprising"}}}, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("GET", "/getplaylist?id=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetPlaylist(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"id": 1, "owner": "alice", "title": "Friday setlist", "songs": [{"position": 1, "group": "Muse", "song": "Uprising"}]}`, rr.Body.String())
+	req, err = http.NewRequest("GET", "/getplaylist?id=one", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	handler.GetPlaylist(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetPlaylists(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("GetPlaylists", "alice").
+		Return(models.AnswerPlaylistsData{Items: []models.PlaylistData{{ID: 1, Owner: "alice", Title: "Friday setlist"}}}, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("GET", "/getplaylists?owner=alice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetPlaylists(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"items": [{"id": 1, "owner": "alice", "title": "Friday setlist"}]}`, rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestPlaylistSongs(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	playlist := models.PlaylistData{ID: 1, Owner: "alice", Title: "Friday setlist"}
+	mockinterface.On("AddPlaylistSong", 1, "Muse", "Uprising", 2).
+		Return(playlist, nil, http.StatusOK).
+		Once()
+	mockinterface.On("RemovePlaylistSong", 1, 3).
+		Return(playlist, errors.New("Invalid position 3, the playlist has 2 songs"), http.StatusBadRequest).
+		Once()
+	mockinterface.On("MovePlaylistSong", 1, 2, 1).
+		Return(playlist, nil, http.StatusOK).
+		Once()
+	cases := []struct {
+		handle func(http.ResponseWriter, *http.Request)
+		body   string
+		status int
+	}{
+		{handler.AddPlaylistSong, `{"id": 1, "group": "Muse", "song": "Uprising", "position": 2}`, http.StatusOK},
+		{handler.RemovePlaylistSong, `{"id": 1, "position": 3}`, http.StatusBadRequest},
+		{handler.MovePlaylistSong, `{"id": 1, "from": 2, "to": 1}`, http.StatusOK},
+		{handler.MovePlaylistSong, `{"id": "1"}`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/", strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		c.handle(rr, req)
+		assert.Equal(t, c.status, rr.Code, c.body)
+	}
+	mockinterface.AssertExpectations(t)
+}
+
+func TestExportPlaylist(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	file := "#EXTM3U\n#PLAYLIST:Friday setlist\n"
+	mockinterface.On("ExportPlaylist", 1, "m3u8").
+		Return(file, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("GET", "/exportplaylist?id=1&format=m3u8", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.ExportPlaylist(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/vnd.apple.mpegurl", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=\"playlist-1.m3u8\"", rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, file, rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestImportPlaylist(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	file := `<playlist><trackList><track><creator>Muse</creator><title>Uprising</title></track></trackList></playlist>`
+	mockinterface.On("ImportPlaylist", file, "xspf", "alice", "").
+		Return(models.PlaylistImportData{
+			Playlist:   models.PlaylistData{ID: 2, Owner: "alice", Title: "Imported"},
+			Unresolved: []models.PlaylistSongData{{Position: 1, Group: "Muse", Song: "Uprising"}},
+		}, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("POST", "/importplaylist?owner=alice", strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/xspf+xml; charset=utf-8")
+	rr := httptest.NewRecorder()
+	handler.ImportPlaylist(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"playlist": {"id": 2, "owner": "alice", "title": "Imported"}, "unresolved": [{"position": 1, "group": "Muse", "song": "Uprising"}]}`, rr.Body.String())
+	req, err = http.NewRequest("POST", "/importplaylist?owner=alice", strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	rr = httptest.NewRecorder()
+	handler.ImportPlaylist(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockinterface.AssertExpectations(t)
+}
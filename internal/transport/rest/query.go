@@ -0,0 +1,224 @@
+package rest
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"test/internal/cursor"
+	"test/internal/models"
+	"time"
+)
+
+// filterOperators are the operators every field of the songs can be filtered with, a plain
+// field=value parameter is the eq operator.
+var filterOperators = map[string][]string{
+	"group":       {models.OperatorEq, models.OperatorPrefix, models.OperatorContains, models.OperatorIn},
+	"song":        {models.OperatorEq, models.OperatorPrefix, models.OperatorContains, models.OperatorIn},
+	"releaseDate": {models.OperatorEq, models.OperatorGt, models.OperatorGte, models.OperatorLt, models.OperatorLte},
+	"text":        {models.OperatorEq, models.OperatorContains},
+	"link":        {models.OperatorEq},
+	"lang":        {models.OperatorEq, models.OperatorIn},
+}
+
+var sortFields = []string{"group", "song", "releaseDate"}
+
+// includes are the resources related to the songs the include parameter adds to them.
+var includes = []string{"group"}
+
+// maxItems is the largest page of songs.
+const maxItems = 1000
+
+// parseSongsQuery reads the pagination, the filters in the field[operator]=value form, the
+// hasLink and hasLyrics predicates and the sort keys of /getdata. Values of the in operator are
+// given by repeating the parameter, sort keys are separated by commas and prefixed with - to
+// sort in the descending order. The page is not needed with a cursor, which is only valid for
+// the sort order it was made for. The fields and include parameters are comma-separated lists.
+// Unless the query is paged the items are optional, all the songs are selected without them.
+func parseSongsQuery(values url.Values, paged bool) (models.SongsQuery, error) {
+	var query models.SongsQuery
+	var err error
+	if token := values.Get("cursor"); token != "" {
+		position, err := cursor.Decode(token)
+		if err != nil {
+			return query, err
+		}
+		query.Cursor = &position
+	}
+	if paged || values.Get("items") != "" {
+		query.Items, err = strconv.ParseInt(values.Get("items"), 10, 64)
+		if err != nil {
+			return query, fmt.Errorf("Invalid items: %w", err)
+		}
+		if query.Items < 1 || query.Items > maxItems {
+			return query, fmt.Errorf("Invalid items: must be from 1 to %d", maxItems)
+		}
+		if query.Cursor == nil {
+			query.Page, err = strconv.ParseInt(values.Get("page"), 10, 64)
+			if err != nil {
+				return query, fmt.Errorf("Invalid page: %w", err)
+			}
+			if query.Page < 1 {
+				return query, fmt.Errorf("Invalid page: pages start from 1")
+			}
+		}
+	} else if values.Get("page") != "" {
+		return query, fmt.Errorf("Invalid page: the items are required with the page")
+	}
+	switch values.Get("total") {
+	case "":
+	case models.TotalExact, models.TotalEstimate:
+		query.Total = values.Get("total")
+	default:
+		return query, fmt.Errorf("Invalid total %q, expected exact or estimate", values.Get("total"))
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	// the order of the filters does not depend on the order of the map
+	sort.Strings(keys)
+	for _, key := range keys {
+		field, operator := key, models.OperatorEq
+		if open := strings.Index(key, "["); open >= 0 && strings.HasSuffix(key, "]") {
+			field, operator = key[:open], key[open+1:len(key)-1]
+		}
+		operators, found := filterOperators[field]
+		if !found {
+			if field != key {
+				return query, fmt.Errorf("Unknown filter %s", key)
+			}
+			continue
+		}
+		if !slices.Contains(operators, operator) {
+			return query, fmt.Errorf("Unsupported operator %s for %s", operator, field)
+		}
+		filter := models.FilterData{Field: field, Operator: operator, Values: values[key]}
+		if operator != models.OperatorIn {
+			filter.Values = filter.Values[:1]
+		}
+		if filter.Values[0] == "" && operator == models.OperatorEq {
+			// an empty value does not filter, as before the operators were added
+			continue
+		}
+		if field == "releaseDate" {
+			if _, err := time.Parse("02.01.2006", filter.Values[0]); err != nil {
+				return query, fmt.Errorf("Invalid release date %q, expected DD.MM.YYYY", filter.Values[0])
+			}
+		}
+		query.Filters = append(query.Filters, filter)
+	}
+	for _, name := range []string{"hasLink", "hasLyrics"} {
+		if values.Get(name) == "" {
+			continue
+		}
+		present, err := strconv.ParseBool(values.Get(name))
+		if err != nil {
+			return query, fmt.Errorf("Invalid %s: %w", name, err)
+		}
+		if name == "hasLink" {
+			query.HasLink = &present
+		} else {
+			query.HasLyrics = &present
+		}
+	}
+	if values.Get("sort") != "" {
+		for _, key := range strings.Split(values.Get("sort"), ",") {
+			key = strings.TrimSpace(key)
+			desc := strings.HasPrefix(key, "-")
+			key = strings.TrimPrefix(key, "-")
+			if !slices.Contains(sortFields, key) {
+				return query, fmt.Errorf("Unknown sort field %q", key)
+			}
+			query.Sort = append(query.Sort, models.SortData{Field: key, Desc: desc})
+		}
+	}
+	query.Fields, err = parseList(values.Get("fields"), models.SongFields, "field")
+	if err != nil {
+		return query, err
+	}
+	query.Include, err = parseList(values.Get("include"), includes, "include")
+	if err != nil {
+		return query, err
+	}
+	if query.Cursor != nil && query.Cursor.Sort != cursor.SortKey(query.Sort) {
+		return query, fmt.Errorf("The cursor was made for another sort order")
+	}
+	return query, nil
+}
+
+// ExportQuery reads the query of the exports of /getdata, the other transports give their
+// queries in its parameters to get the same checks.
+func ExportQuery(values url.Values) (models.SongsQuery, error) {
+	return parseSongsQuery(values, false)
+}
+
+// parseList splits the comma-separated list checking every item is one of the allowed ones.
+func parseList(value string, allowed []string, name string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if !slices.Contains(allowed, item) {
+			return nil, fmt.Errorf("Unknown %s %q", name, item)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// paginationLinks returns the Link header of the pages around the page of songs, the links keep
+// the parameters of the request and replace the page with the cursor.
+func paginationLinks(target *url.URL, pagination *models.PaginationData) string {
+	if pagination == nil {
+		return ""
+	}
+	var links []string
+	for _, link := range []struct{ rel, token string }{{"prev", pagination.Prev}, {"next", pagination.Next}} {
+		if link.token == "" {
+			continue
+		}
+		values := target.Query()
+		values.Del("page")
+		values.Set("cursor", link.token)
+		links = append(links, fmt.Sprintf("<%s?%s>; rel=\"%s\"", target.Path, values.Encode(), link.rel))
+	}
+	return strings.Join(links, ", ")
+}
+
+// songsQueryAttrs are the attributes the songs query is logged with. The filters are logged by
+// their field and operator only, their values can be whole lyrics.
+func songsQueryAttrs(query models.SongsQuery) []any {
+	filters := make([]string, 0, len(query.Filters))
+	for _, filter := range query.Filters {
+		filters = append(filters, filter.Field+"["+filter.Operator+"]")
+	}
+	if query.HasLink != nil {
+		filters = append(filters, "hasLink")
+	}
+	if query.HasLyrics != nil {
+		filters = append(filters, "hasLyrics")
+	}
+	keys := make([]string, 0, len(query.Sort))
+	for _, key := range query.Sort {
+		if key.Desc {
+			keys = append(keys, "-"+key.Field)
+		} else {
+			keys = append(keys, key.Field)
+		}
+	}
+	return []any{
+		"filters", strings.Join(filters, ","),
+		"sort", strings.Join(keys, ","),
+		"page", query.Page,
+		"items", query.Items,
+		"cursor", query.Cursor != nil,
+		"total", query.Total,
+		"fields", strings.Join(query.Fields, ","),
+		"include", strings.Join(query.Include, ","),
+	}
+}
@@ -0,0 +1,105 @@
+package rest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"test/internal/cursor"
+	"test/internal/models"
+	"testing"
+)
+
+func TestParseSongsQuery(t *testing.T) {
+	values, _ := url.ParseQuery("page=2&items=10&group[prefix]=Mu&song[contains]=black&lang[in]=en&lang[in]=ru&releaseDate[gte]=01.01.2006&releaseDate[lt]=01.01.2010&link=&hasLink=true&hasLyrics=0&sort=-releaseDate,%20song&cache=1")
+	query, err := parseSongsQuery(values, true)
+	assert.NoError(t, err)
+	hasLink := true
+	hasLyrics := false
+	assert.Equal(t, models.SongsQuery{
+		Page:  2,
+		Items: 10,
+		Filters: []models.FilterData{
+			{Field: "group", Operator: models.OperatorPrefix, Values: []string{"Mu"}},
+			{Field: "lang", Operator: models.OperatorIn, Values: []string{"en", "ru"}},
+			{Field: "releaseDate", Operator: models.OperatorGte, Values: []string{"01.01.2006"}},
+			{Field: "releaseDate", Operator: models.OperatorLt, Values: []string{"01.01.2010"}},
+			{Field: "song", Operator: models.OperatorContains, Values: []string{"black"}},
+		},
+		Sort:      []models.SortData{{Field: "releaseDate", Desc: true}, {Field: "song"}},
+		HasLink:   &hasLink,
+		HasLyrics: &hasLyrics,
+	}, query)
+}
+
+func TestParseSongsQuery_Errors(t *testing.T) {
+	cases := map[string]string{
+		"page=x&items=1":                        "Invalid page: strconv.ParseInt: parsing \"x\": invalid syntax",
+		"page=1&items=1&id[eq]=1":               "Unknown filter id[eq]",
+		"page=1&items=1&link[prefix]=https":     "Unsupported operator prefix for link",
+		"page=1&items=1&releaseDate[gt]=2006":   "Invalid release date \"2006\", expected DD.MM.YYYY",
+		"page=1&items=1&hasLink=maybe":          "Invalid hasLink: strconv.ParseBool: parsing \"maybe\": invalid syntax",
+		"page=1&items=1&sort=-text":             "Unknown sort field \"text\"",
+		"page=1&items=1&releaseDate=2006-07-16": "Invalid release date \"2006-07-16\", expected DD.MM.YYYY",
+	}
+	for raw, expected := range cases {
+		values, _ := url.ParseQuery(raw)
+		_, err := parseSongsQuery(values, true)
+		assert.EqualError(t, err, expected, raw)
+	}
+}
+
+func TestParseSongsQuery_Cursor(t *testing.T) {
+	position := models.CursorData{Sort: "-releaseDate", Values: []string{"2006-07-16 00:00:00"}, ID: 1}
+	values := url.Values{"items": {"5"}, "sort": {"-releaseDate"}, "cursor": {cursor.Encode(position)}, "total": {"estimate"}}
+	query, err := parseSongsQuery(values, true)
+	assert.NoError(t, err)
+	assert.Equal(t, models.SongsQuery{
+		Items:  5,
+		Cursor: &position,
+		Sort:   []models.SortData{{Field: "releaseDate", Desc: true}},
+		Total:  models.TotalEstimate,
+	}, query)
+}
+
+func TestParseSongsQuery_Export(t *testing.T) {
+	values, _ := url.ParseQuery("format=csv&group=Muse")
+	query, err := parseSongsQuery(values, false)
+	assert.NoError(t, err)
+	assert.Equal(t, models.SongsQuery{Filters: []models.FilterData{{Field: "group", Operator: models.OperatorEq, Values: []string{"Muse"}}}}, query)
+	_, err = parseSongsQuery(values, true)
+	assert.EqualError(t, err, "Invalid items: strconv.ParseInt: parsing \"\": invalid syntax")
+	values, _ = url.ParseQuery("page=2")
+	_, err = parseSongsQuery(values, false)
+	assert.EqualError(t, err, "Invalid page: the items are required with the page")
+}
+
+func TestPaginationLinks(t *testing.T) {
+	target, _ := url.Parse("/getdata?page=2&items=5&group=Muse")
+	links := paginationLinks(target, &models.PaginationData{Next: "bmV4dA", Prev: "cHJldg"})
+	assert.Equal(t, `</getdata?cursor=cHJldg&group=Muse&items=5>; rel="prev", </getdata?cursor=bmV4dA&group=Muse&items=5>; rel="next"`, links)
+	assert.Empty(t, paginationLinks(target, &models.PaginationData{}))
+	assert.Empty(t, paginationLinks(target, nil))
+}
+
+func TestParseSongsQuery_Fields(t *testing.T) {
+	values, _ := url.ParseQuery("page=1&items=5&fields=song,%20releaseDate&include=group")
+	query, err := parseSongsQuery(values, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"song", "releaseDate"}, query.Fields)
+	assert.Equal(t, []string{"group"}, query.Include)
+}
+
+func TestSongsQueryAttrs(t *testing.T) {
+	values, _ := url.ParseQuery("page=2&items=10&group=Muse&text[contains]=Ooh%20baby&hasLink=true&sort=-releaseDate,song&total=exact&fields=song")
+	query, err := parseSongsQuery(values, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{
+		"filters", "group[eq],text[contains],hasLink",
+		"sort", "-releaseDate,song",
+		"page", int64(2),
+		"items", int64(10),
+		"cursor", false,
+		"total", models.TotalExact,
+		"fields", "song",
+		"include", "",
+	}, songsQueryAttrs(query))
+}
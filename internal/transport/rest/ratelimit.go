@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"test/internal/auth"
+	"test/internal/ratelimit"
+	"time"
+)
+
+// QuotaCounter counts the requests of the clients per day for the daily quotas.
+type QuotaCounter interface {
+	UseQuotaQuery(ctx context.Context, client string, class string, day time.Time) (int, error)
+}
+
+// RouteClass returns the class of the route: /addsong calls the external API, the routes with a
+// read scope only read the library and the other routes write.
+func RouteClass(path string) string {
+	if path == "/addsong" {
+		return ratelimit.Enrich
+	}
+	if strings.HasSuffix(RouteScopes[path], ":read") || path == "/getkeys" || path == "/getroles" {
+		return ratelimit.Read
+	}
+	return ratelimit.Write
+}
+
+// client identifies the caller of the request for the limits, see auth.Client.
+func client(r *http.Request) string {
+	principal, _ := auth.PrincipalFrom(r.Context())
+	return auth.Client(principal, r.RemoteAddr)
+}
+
+// RateLimit takes a token of the class of the route from the bucket of the client and counts the
+// request against the daily quota of the class, it runs after Admit. The
+// requests over a limit get 429 with Retry-After, the RateLimit-* headers describe the bucket.
+// The requests are let through when the quotas can not be counted.
+func RateLimit(limiter *ratelimit.Limiter, quotas QuotaCounter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class := RouteClass(r.URL.Path)
+		caller := client(r)
+		decision := limiter.Take(caller, class)
+		if decision.Limit > 0 {
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			w.Header().Set("RateLimit-Reset", ceilSeconds(decision.Reset))
+		}
+		if !decision.Allowed {
+			slog.InfoContext(r.Context(), "Rate limited", "client", caller)
+			w.Header().Set("Retry-After", ceilSeconds(decision.RetryAfter))
+			http.Error(w, fmt.Sprintf("Too many %s requests, at most %d a minute", class, decision.Limit), http.StatusTooManyRequests)
+			return
+		}
+		if daily := limiter.Limit(class).Daily; daily > 0 && quotas != nil {
+			now := limiter.Now().UTC()
+			used, err := quotas.UseQuotaQuery(r.Context(), caller, class, now)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to count the quota", "client", caller, "error", err)
+			} else if used > daily {
+				slog.InfoContext(r.Context(), "Daily quota used up", "client", caller)
+				tomorrow := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+				w.Header().Set("Retry-After", ceilSeconds(tomorrow.Sub(now)))
+				http.Error(w, fmt.Sprintf("The daily quota of %d %s requests is used up", daily, class), http.StatusTooManyRequests)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ceilSeconds formats the duration in whole seconds rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"test/internal/auth"
+	"test/internal/models"
+	"test/internal/ratelimit"
+	"testing"
+	"time"
+)
+
+// countedQuotas counts the quotas in memory, or fails with err.
+type countedQuotas struct {
+	used map[string]int
+	err  error
+}
+
+func (q *countedQuotas) UseQuotaQuery(ctx context.Context, client string, class string, day time.Time) (int, error) {
+	q.used[client+" "+class+" "+day.Format(time.DateOnly)]++
+	return q.used[client+" "+class+" "+day.Format(time.DateOnly)], q.err
+}
+
+func TestRouteClass(t *testing.T) {
+	assert.Equal(t, ratelimit.Enrich, RouteClass("/addsong"))
+	assert.Equal(t, ratelimit.Read, RouteClass("/getdata"))
+	assert.Equal(t, ratelimit.Read, RouteClass("/getlyrics"))
+	assert.Equal(t, ratelimit.Read, RouteClass("/getkeys"))
+	assert.Equal(t, ratelimit.Write, RouteClass("/editsong"))
+	assert.Equal(t, ratelimit.Write, RouteClass("/dumplibrary"))
+	assert.Equal(t, ratelimit.Write, RouteClass("/unknown"))
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	limiter := ratelimit.New(map[string]ratelimit.Limit{
+		ratelimit.Read:   {PerMinute: 2},
+		ratelimit.Enrich: {PerMinute: 10, Daily: 1},
+	}, func() time.Time { return now })
+	quotas := &countedQuotas{used: map[string]int{}}
+	server := RateLimit(limiter, quotas, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(target string, principal models.PrincipalData, remote string) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(auth.WithPrincipal(context.Background(), principal), "GET", target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = remote
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		return rr
+	}
+	rr := serve("/getdata", alice, "10.0.0.1:5000")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rr.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, http.StatusOK, serve("/getdata", alice, "10.0.0.1:5000").Code)
+	rr = serve("/getdata", alice, "10.0.0.2:5000")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "Too many read requests, at most 2 a minute\n", rr.Body.String())
+	// the anonymous requests are limited by address
+	assert.Equal(t, http.StatusOK, serve("/getdata", auth.Anonymous, "10.0.0.1:5000").Code)
+	assert.Equal(t, http.StatusOK, serve("/getdata", auth.Anonymous, "10.0.0.1:5001").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("/getdata", auth.Anonymous, "10.0.0.1:5002").Code)
+	assert.Equal(t, http.StatusOK, serve("/getdata", auth.Anonymous, "10.0.0.2:5000").Code)
+	// writes are not limited here
+	rr = serve("/editsong", alice, "10.0.0.1:5000")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, http.StatusOK, serve("/addsong", alice, "10.0.0.1:5000").Code)
+	rr = serve("/addsong", alice, "10.0.0.1:5000")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "3600", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "The daily quota of 1 enrich requests is used up\n", rr.Body.String())
+	assert.Equal(t, map[string]int{"key:2 enrich 2024-05-01": 2}, quotas.used)
+	quotas.err = errors.New("connection reset")
+	assert.Equal(t, http.StatusOK, serve("/addsong", models.PrincipalData{User: "billing", Role: models.RoleService}, "10.0.0.1:5000").Code)
+	assert.Equal(t, 1, quotas.used["token:billing enrich 2024-05-01"])
+}
@@ -0,0 +1,36 @@
+package rest
+
+// RouteScopes are the scopes a JWT needs to call the routes, the scopes are the permissions of
+// the roles. The routes without a scope such as the user and key management can not be called
+// with tokens.
+var RouteScopes = map[string]string{
+	"/getdata":            "songs:read",
+	"/getsongtext":        "songs:read",
+	"/getsongstructure":   "lyrics:read",
+	"/getlrc":             "lyrics:read",
+	"/getchords":          "lyrics:read",
+	"/getsongline":        "lyrics:read",
+	"/getlyrics":          "lyrics:read",
+	"/addsong":            "songs:write",
+	"/editsong":           "songs:write",
+	"/importsongs":        "songs:write",
+	"/addlrc":             "songs:write",
+	"/addchords":          "songs:write",
+	"/addlyrics":          "songs:write",
+	"/deletelyrics":       "songs:write",
+	"/deletesong":         "songs:delete",
+	"/deletegroup":        "groups:manage",
+	"/renamegroup":        "groups:manage",
+	"/getplaylist":        "playlists:read",
+	"/getplaylists":       "playlists:read",
+	"/exportplaylist":     "playlists:read",
+	"/addplaylist":        "playlists:write",
+	"/editplaylist":       "playlists:write",
+	"/deleteplaylist":     "playlists:write",
+	"/addplaylistsong":    "playlists:write",
+	"/removeplaylistsong": "playlists:write",
+	"/moveplaylistsong":   "playlists:write",
+	"/importplaylist":     "playlists:write",
+	"/dumplibrary":        "library:dump",
+	"/restorelibrary":     "library:restore",
+}
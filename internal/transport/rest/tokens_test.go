@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"test/internal/auth"
+	"test/internal/models"
+	"testing"
+)
+
+// fakeVerifier accepts the tokens it knows.
+type fakeVerifier map[string]auth.Claims
+
+func (v fakeVerifier) Verify(ctx context.Context, token string) (auth.Claims, error) {
+	claims, found := v[token]
+	if !found {
+		return claims, fmt.Errorf("%w: bad signature", auth.ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+func TestAdmit_Tokens(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("Authenticate", "sl_9f86d081_secret").
+		Return(alice, nil, http.StatusOK)
+	verifier := fakeVerifier{
+		"a.reader.sig":    {Subject: "billing", Scopes: []string{"songs:read"}},
+		"a.anonymous.sig": {Scopes: []string{"songs:read"}},
+	}
+	var seen models.PrincipalData
+	server := handler.Admit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = auth.PrincipalFrom(r.Context())
+	}), verifier, nil)
+	cases := []struct {
+		target string
+		token  string
+		status int
+	}{
+		{"/getdata", "a.reader.sig", http.StatusOK},
+		{"/deletesong", "a.reader.sig", http.StatusForbidden},
+		{"/issuekey", "a.reader.sig", http.StatusForbidden},
+		{"/getdata", "a.forged.sig", http.StatusUnauthorized},
+		{"/getdata", "a.anonymous.sig", http.StatusUnauthorized},
+		{"/getdata", "", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", c.target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		assert.Equal(t, c.status, rr.Code, c.target+" "+c.token)
+	}
+	assert.Equal(t, models.PrincipalData{User: "billing", Role: models.RoleService, Permissions: []string{"songs:read"}}, seen)
+	// the API keys are still authenticated next to the tokens
+	req, _ := http.NewRequest("GET", "/getdata", nil)
+	req.Header.Set("Authorization", "Bearer sl_9f86d081_secret")
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, alice, seen)
+	req, _ = http.NewRequest("POST", "/deletesong", nil)
+	req.Header.Set("Authorization", "Bearer a.reader.sig")
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	assert.Equal(t, `Bearer realm="song-library", error="insufficient_scope", scope="songs:delete"`, rr.Header().Get("WWW-Authenticate"))
+}
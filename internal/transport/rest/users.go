@@ -0,0 +1,269 @@
+package rest
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"test/internal/auth"
+	"test/internal/logging"
+	"test/internal/models"
+	"test/internal/tenant"
+)
+
+// Admit authenticates every request by its API key or JWT and resolves its tenant with the
+// resolver shared with the gRPC methods, then attaches the principal and the tenant to its
+// context and adds them to the records logged for it. GET and HEAD requests to the public routes
+// are let through without a key, a key given to them is still checked. The JWTs are taken for
+// API keys when tokens is nil.
+func (h *Handler) Admit(next http.Handler, tokens auth.TokenVerifier, public map[string]bool) http.Handler {
+	resolver := &auth.Resolver{Keys: h.service, Tokens: tokens, Scopes: RouteScopes}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := auth.RequestKey(r)
+		open := public[r.URL.Path] && (r.Method == http.MethodGet || r.Method == http.MethodHead)
+		caller, err, status := resolver.Resolve(r.Context(), r.URL.Path, open, auth.Credentials{Key: key, Tenant: r.Header.Get(tenant.Header), Addr: r.RemoteAddr})
+		if err != nil {
+			var scope *auth.ScopeError
+			switch {
+			case errors.As(err, &scope):
+				w.Header().Set("WWW-Authenticate", `Bearer realm="song-library", error="insufficient_scope", scope="`+scope.Scope+`"`)
+			case status == http.StatusUnauthorized && key == "":
+				w.Header().Set("WWW-Authenticate", `Bearer realm="song-library"`)
+			case status == http.StatusUnauthorized:
+				w.Header().Set("WWW-Authenticate", `Bearer realm="song-library", error="invalid_token"`)
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		logging.SetPrincipal(r.Context(), caller.Principal.User, caller.Tenant)
+		ctx := tenant.With(auth.WithPrincipal(r.Context(), caller.Principal), caller.Tenant)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AddUser godoc
+// @Summary Add a user
+// @Description Create a user with a role based on name and role provided as json, the role is viewer by default. Needs the users:manage permission. The user gets no key, issue one with /issuekey.
+// @Tags users
+// @Accept json
+// @Produce  json
+// @Param data body models.UserRequestData true "JSON with name and role"
+// @Success 200 {object} models.UserData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 409 {object} string "Conflict"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /adduser [post]
+func (h *Handler) AddUser(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to add user")
+	var respdata models.UserRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	result, err, status := h.service.AddUser(r.Context(), respdata.Name, respdata.Role)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// IssueKey godoc
+// @Summary Issue an API key
+// @Description Issue a new API key to the user provided as json, to the caller without one. Issuing keys to other users needs the users:manage permission. The key is only returned once, the service keeps its hash.
+// @Tags users
+// @Accept json
+// @Produce  json
+// @Param data body models.KeyRequestData false "JSON with user"
+// @Success 200 {object} models.APIKeyData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /issuekey [post]
+func (h *Handler) IssueKey(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to issue API key")
+	var respdata models.KeyRequestData
+	if r.ContentLength != 0 {
+		if err, status := readJSON(r, &respdata); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+	result, err, status := h.service.IssueKey(r.Context(), respdata.User)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, result)
+}
+
+// RotateKey godoc
+// @Summary Rotate an API key
+// @Description Revoke the API key based on id provided as json and issue a new one to its user. Rotating the keys of other users needs the users:manage permission.
+// @Tags users
+// @Accept json
+// @Produce  json
+// @Param data body models.KeyRequestData true "JSON with id"
+// @Success 200 {object} models.APIKeyData "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /rotatekey [post]
+func (h *Handler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to rotate API key")
+	var respdata models.KeyRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	result, err, status := h.service.RotateKey(r.Context(), respdata.ID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, result)
+}
+
+// RevokeKey godoc
+// @Summary Revoke an API key
+// @Description Revoke the API key based on id provided as json, its requests are rejected from then on. Revoking the keys of other users needs the users:manage permission.
+// @Tags users
+// @Accept json
+// @Produce  json
+// @Param data body models.KeyRequestData true "JSON with id"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /revokekey [post]
+func (h *Handler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to revoke API key")
+	var respdata models.KeyRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	err, status := h.service.RevokeKey(r.Context(), respdata.ID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	slog.InfoContext(r.Context(), "Revoked API key")
+}
+
+// GetKeys godoc
+// @Summary Get API keys
+// @Description List the API keys of the user provided as query parameter, of the caller without one, with their prefixes and revocation times but without the keys. Listing the keys of other users, and of every user with user=*, needs the users:manage permission.
+// @Tags users
+// @Produce  json
+// @Param user query string false "User" example("alice")
+// @Success 200 {object} models.AnswerKeysData "OK"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /getkeys [get]
+func (h *Handler) GetKeys(w http.ResponseWriter, r *http.Request) {
+	result, err, status := h.service.GetKeys(r.Context(), r.URL.Query().Get("user"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// GetRoles godoc
+// @Summary Get roles
+// @Description List the roles with their permissions. Needs the users:manage permission.
+// @Tags users
+// @Produce  json
+// @Success 200 {object} models.AnswerRolesData "OK"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /getroles [get]
+func (h *Handler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	result, err, status := h.service.GetRoles(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// SetRole godoc
+// @Summary Set a role
+// @Description Create the role or replace its permissions based on name and permissions provided as json. The roles are shared by the tenants, so only the users of the default tenant change them. Needs the users:manage permission.
+// @Tags users
+// @Accept json
+// @Produce  json
+// @Param data body models.RoleData true "JSON with name and permissions"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /setrole [post]
+func (h *Handler) SetRole(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to set role")
+	var respdata models.RoleData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	err, status := h.service.SetRole(r.Context(), respdata.Name, respdata.Permissions)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+}
+
+// AssignRole godoc
+// @Summary Assign a role
+// @Description Give the user the role based on user and role provided as json, the keys of the user get the permissions of the role on their next request. Needs the users:manage permission.
+// @Tags users
+// @Accept json
+// @Produce  json
+// @Param data body models.AssignRoleRequestData true "JSON with user and role"
+// @Success 200 {object} nil "OK"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "Not Found"
+// @Failure 429 {object} string "Too Many Requests"
+// @Failure 500 {object} string "Internal Server Error"
+// @Security ApiKeyAuth
+// @Router /assignrole [post]
+func (h *Handler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "Received request to assign role")
+	var respdata models.AssignRoleRequestData
+	if err, status := readJSON(r, &respdata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	err, status := h.service.AssignRole(r.Context(), respdata.User, respdata.Role)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+}
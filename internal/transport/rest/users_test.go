@@ -0,0 +1,270 @@
+package rest
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/internal/auth"
+	"test/internal/models"
+	"test/internal/tenant"
+	"testing"
+)
+
+var alice = models.PrincipalData{UserID: 2, User: "alice", Role: models.RoleEditor, KeyID: 2, Permissions: models.DefaultRoles[models.RoleEditor]}
+
+func TestAdmit(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("Authenticate", "sl_valid").
+		Return(alice, nil, http.StatusOK)
+	mockinterface.On("Authenticate", "sl_revoked").
+		Return(models.PrincipalData{}, errors.New("The API key is not found or revoked"), http.StatusUnauthorized)
+	mockinterface.On("AuthenticateAnonymous").
+		Return(auth.Anonymous, nil, http.StatusOK)
+	var seen *models.PrincipalData
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = nil
+		if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+			seen = &principal
+		}
+	})
+	server := handler.Admit(next, nil, map[string]bool{"/getdata": true})
+	cases := []struct {
+		method    string
+		target    string
+		header    string
+		value     string
+		status    int
+		principal *models.PrincipalData
+	}{
+		{"POST", "/deletesong", "", "", http.StatusUnauthorized, nil},
+		{"POST", "/deletesong", "X-API-Key", "sl_revoked", http.StatusUnauthorized, nil},
+		{"POST", "/deletesong", "Authorization", "Bearer sl_valid", http.StatusOK, &alice},
+		{"GET", "/getdata", "", "", http.StatusOK, &auth.Anonymous},
+		{"POST", "/getdata", "", "", http.StatusUnauthorized, nil},
+		{"GET", "/getdata", "X-API-Key", "sl_valid", http.StatusOK, &alice},
+		{"GET", "/getdata", "X-API-Key", "sl_revoked", http.StatusUnauthorized, nil},
+		{"GET", "/getlyrics", "", "", http.StatusUnauthorized, nil},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest(c.method, c.target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.header != "" {
+			req.Header.Set(c.header, c.value)
+		}
+		seen = nil
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		assert.Equal(t, c.status, rr.Code, c.method+" "+c.target+" "+c.value)
+		if c.status == http.StatusUnauthorized {
+			assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+		}
+		assert.Equal(t, c.principal, seen, c.method+" "+c.target+" "+c.value)
+	}
+}
+
+func TestAdmit_Tenant(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("Authenticate", "sl_acme").
+		Return(models.PrincipalData{User: "alice", KeyID: 4, Tenant: "acme"}, nil, http.StatusOK)
+	mockinterface.On("AuthenticateAnonymous").
+		Return(auth.Anonymous, nil, http.StatusOK)
+	var seen string
+	server := handler.Admit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = tenant.From(r.Context())
+	}), nil, map[string]bool{"/getdata": true})
+	cases := []struct {
+		key    string
+		header string
+		status int
+		tenant string
+	}{
+		{"sl_acme", "", http.StatusOK, "acme"},
+		{"sl_acme", "acme", http.StatusOK, "acme"},
+		{"sl_acme", "globex", http.StatusForbidden, ""},
+		{"", "globex", http.StatusForbidden, ""},
+		{"", tenant.Default, http.StatusOK, tenant.Default},
+		{"", "", http.StatusOK, tenant.Default},
+		{"", "Globex Corp", http.StatusBadRequest, ""},
+	}
+	for _, c := range cases {
+		seen = ""
+		req, err := http.NewRequest("GET", "/getdata", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.key != "" {
+			req.Header.Set("X-API-Key", c.key)
+		}
+		if c.header != "" {
+			req.Header.Set(tenant.Header, c.header)
+		}
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		assert.Equal(t, c.status, rr.Code, c.key+" "+c.header)
+		assert.Equal(t, c.tenant, seen, c.key+" "+c.header)
+	}
+}
+
+func TestIssueKey(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("IssueKey", "").
+		Return(models.APIKeyData{ID: 5, User: "alice", Prefix: "9f86d081", Key: "sl_9f86d081_secret"}, nil, http.StatusOK).
+		Once()
+	req, err := http.NewRequest("POST", "/issuekey", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.IssueKey(rr, req.WithContext(auth.WithPrincipal(req.Context(), alice)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"id": 5, "user": "alice", "prefix": "9f86d081", "key": "sl_9f86d081_secret", "createdAt": "0001-01-01T00:00:00Z"}`, rr.Body.String())
+	mockinterface.AssertExpectations(t)
+}
+
+func TestUsersAndKeys(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("AddUser", "bob", "").
+		Return(models.UserData{}, errors.New("The permission users:manage is required"), http.StatusForbidden).
+		Once()
+	mockinterface.On("RotateKey", 2).
+		Return(models.APIKeyData{ID: 6, User: "alice"}, nil, http.StatusOK).
+		Once()
+	mockinterface.On("RevokeKey", 9).
+		Return(errors.New("The API key is not found or revoked"), http.StatusNotFound).
+		Once()
+	cases := []struct {
+		handle func(http.ResponseWriter, *http.Request)
+		body   string
+		status int
+	}{
+		{handler.AddUser, `{"name": "bob"}`, http.StatusForbidden},
+		{handler.RotateKey, `{"id": 2}`, http.StatusOK},
+		{handler.RevokeKey, `{"id": 9}`, http.StatusNotFound},
+		{handler.RevokeKey, `{"id": "9"}`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/", strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		c.handle(rr, req.WithContext(auth.WithPrincipal(req.Context(), alice)))
+		assert.Equal(t, c.status, rr.Code, c.body)
+	}
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGetKeys(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("GetKeys", "").
+		Return(models.AnswerKeysData{Items: []models.APIKeyData{}}, nil, http.StatusOK).
+		Once()
+	mockinterface.On("GetKeys", "*").
+		Return(models.AnswerKeysData{}, errors.New("The permission users:manage is required"), http.StatusForbidden).
+		Once()
+	for target, status := range map[string]int{"/getkeys": http.StatusOK, "/getkeys?user=*": http.StatusForbidden} {
+		req, err := http.NewRequest("GET", target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		handler.GetKeys(rr, req.WithContext(auth.WithPrincipal(req.Context(), alice)))
+		assert.Equal(t, status, rr.Code, target)
+	}
+	mockinterface.AssertExpectations(t)
+}
+
+func TestRoles(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("GetRoles").
+		Return(models.AnswerRolesData{Items: []models.RoleData{{Name: "viewer", Permissions: []string{"songs:read"}}}}, nil, http.StatusOK).
+		Once()
+	mockinterface.On("SetRole", "curator", []string{"songs:read", "groups:manage"}).
+		Return(nil, http.StatusOK).
+		Once()
+	mockinterface.On("AssignRole", "bob", "owner").
+		Return(errors.New("The role is not found"), http.StatusNotFound).
+		Once()
+	req, err := http.NewRequest("GET", "/getroles", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.GetRoles(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"items": [{"name": "viewer", "permissions": ["songs:read"]}]}`, rr.Body.String())
+	cases := []struct {
+		handle func(http.ResponseWriter, *http.Request)
+		body   string
+		status int
+	}{
+		{handler.SetRole, `{"name": "curator", "permissions": ["songs:read", "groups:manage"]}`, http.StatusOK},
+		{handler.SetRole, `{"name": "curator", "permissions": "songs:read"}`, http.StatusBadRequest},
+		{handler.AssignRole, `{"user": "bob", "role": "owner"}`, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/", strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		c.handle(rr, req)
+		assert.Equal(t, c.status, rr.Code, c.body)
+	}
+	mockinterface.AssertExpectations(t)
+}
+
+func TestGroups(t *testing.T) {
+	mockinterface := NewMockInterface()
+	handler := &Handler{
+		mockinterface,
+	}
+	mockinterface.On("DeleteGroup", "Muse").
+		Return(nil, http.StatusOK).
+		Once()
+	mockinterface.On("RenameGroup", "Muse", "Queen").
+		Return(errors.New("The group already exists"), http.StatusConflict).
+		Once()
+	cases := []struct {
+		handle func(http.ResponseWriter, *http.Request)
+		body   string
+		status int
+	}{
+		{handler.DeleteGroup, `{"group": "Muse"}`, http.StatusOK},
+		{handler.DeleteGroup, `{"group": `, http.StatusBadRequest},
+		{handler.RenameGroup, `{"group": "Muse", "name": "Queen"}`, http.StatusConflict},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/", strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		c.handle(rr, req)
+		assert.Equal(t, c.status, rr.Code, c.body)
+	}
+	mockinterface.AssertExpectations(t)
+}
@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// codeOfStatus are the gRPC codes of the HTTP statuses the service fails with.
+var codeOfStatus = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.AlreadyExists,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusNotImplemented:        codes.Unimplemented,
+	http.StatusServiceUnavailable:    codes.Unavailable,
+	http.StatusGatewayTimeout:        codes.DeadlineExceeded,
+}
+
+// Code returns the gRPC code of the HTTP status, Internal for the unknown errors.
+func Code(httpStatus int) codes.Code {
+	if code, found := codeOfStatus[httpStatus]; found {
+		return code
+	}
+	if httpStatus < http.StatusBadRequest {
+		return codes.OK
+	}
+	if httpStatus < http.StatusInternalServerError {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
+
+// Error returns the error of the service with the code of its HTTP status.
+func Error(err error, httpStatus int) error {
+	return status.Error(Code(httpStatus), err.Error())
+}
@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net/http"
+	"strings"
+	"test/internal/auth"
+	"test/internal/logging"
+	"test/internal/ratelimit"
+	"test/internal/tenant"
+	"test/internal/transport/rest"
+	"test/internal/transport/rpc/musicpb"
+	"time"
+)
+
+// Routes are the REST routes of the methods, the methods need the scopes of their routes, are
+// public when their routes are and are limited with the classes of their routes.
+var Routes = map[string]string{
+	musicpb.Music_AddSong_FullMethodName:    "/addsong",
+	musicpb.Music_DeleteSong_FullMethodName: "/deletesong",
+	musicpb.Music_EditSong_FullMethodName:   "/editsong",
+	musicpb.Music_ListSongs_FullMethodName:  "/getdata",
+	musicpb.Music_GetVerse_FullMethodName:   "/getsongtext",
+}
+
+// reflectionPrefix starts the methods of the reflection service, they only describe the services
+// and are served to anyone.
+const reflectionPrefix = "/grpc.reflection."
+
+// Guard admits the calls like the middlewares of the REST routes: it logs them, authenticates
+// them by their API key or JWT and resolves their tenant with the resolver of the routes, and
+// limits their rate. The key is read from the x-api-key metadata or the authorization metadata as
+// a bearer token, the tenant from x-tenant and the request id from x-request-id.
+type Guard struct {
+	Keys auth.KeyAuthenticator
+	// Tokens verifies the JWTs, they are taken for API keys when it is nil.
+	Tokens auth.TokenVerifier
+	// Public are the REST routes served without a key.
+	Public  map[string]bool
+	Limiter *ratelimit.Limiter
+	Quotas  rest.QuotaCounter
+}
+
+// Unary returns the interceptor of the unary calls.
+func (g *Guard) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var resp any
+		err := g.serve(ctx, info.FullMethod, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+// Stream returns the interceptor of the streaming calls.
+func (g *Guard) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return g.serve(stream.Context(), info.FullMethod, func(ctx context.Context) error {
+			return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		})
+	}
+}
+
+// contextStream is the stream of a call with the context the guard made for it.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// serve admits the call and logs its outcome.
+func (g *Guard) serve(ctx context.Context, method string, handler func(ctx context.Context) error) error {
+	if strings.HasPrefix(method, reflectionPrefix) {
+		return handler(ctx)
+	}
+	start := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = logging.WithRequest(ctx, rest.RequestID(first(md, "x-request-id")), method)
+	ctx, err := g.admit(ctx, method, md)
+	if err == nil {
+		err = handler(ctx)
+	}
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, "Request completed", "method", "gRPC", "code", code.String(), "duration", time.Since(start).String())
+	return err
+}
+
+// admit returns the context of the call with its principal and tenant, or the error rejecting it.
+func (g *Guard) admit(ctx context.Context, method string, md metadata.MD) (context.Context, error) {
+	route, found := Routes[method]
+	if !found {
+		return ctx, status.Errorf(codes.Unimplemented, "The method %s is not served", method)
+	}
+	addr := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	resolver := &auth.Resolver{Keys: g.Keys, Tokens: g.Tokens, Scopes: rest.RouteScopes}
+	caller, err, httpStatus := resolver.Resolve(ctx, route, g.Public[route], auth.Credentials{
+		Key:    auth.PresentedKey(first(md, "x-api-key"), first(md, "authorization")),
+		Tenant: first(md, strings.ToLower(tenant.Header)),
+		Addr:   addr,
+	})
+	if err != nil {
+		return ctx, Error(err, httpStatus)
+	}
+	logging.SetPrincipal(ctx, caller.Principal.User, caller.Tenant)
+	ctx = tenant.With(auth.WithPrincipal(ctx, caller.Principal), caller.Tenant)
+	return ctx, g.limit(ctx, route, caller.Client)
+}
+
+// limit takes a token of the class of the route from the bucket of the caller and counts the
+// call against the daily quota of the class, like rest.RateLimit. The buckets are shared with
+// the routes.
+func (g *Guard) limit(ctx context.Context, route string, caller string) error {
+	if g.Limiter == nil {
+		return nil
+	}
+	class := rest.RouteClass(route)
+	decision := g.Limiter.Take(caller, class)
+	if !decision.Allowed {
+		slog.InfoContext(ctx, "Rate limited", "client", caller)
+		return Error(fmt.Errorf("Too many %s requests, at most %d a minute", class, decision.Limit), http.StatusTooManyRequests)
+	}
+	if daily := g.Limiter.Limit(class).Daily; daily > 0 && g.Quotas != nil {
+		used, err := g.Quotas.UseQuotaQuery(ctx, caller, class, g.Limiter.Now().UTC())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to count the quota", "client", caller, "error", err)
+		} else if used > daily {
+			slog.InfoContext(ctx, "Daily quota used up", "client", caller)
+			return Error(fmt.Errorf("The daily quota of %d %s requests is used up", daily, class), http.StatusTooManyRequests)
+		}
+	}
+	return nil
+}
+
+// first returns the first value of the metadata key.
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
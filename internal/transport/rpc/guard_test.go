@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"net/http"
+	"test/internal/auth"
+	"test/internal/models"
+	"test/internal/ratelimit"
+	"test/internal/tenant"
+	"test/internal/transport/rpc/musicpb"
+	"testing"
+	"time"
+)
+
+var alice = models.PrincipalData{UserID: 2, User: "alice", Role: models.RoleEditor, KeyID: 2, Permissions: models.DefaultRoles[models.RoleEditor]}
+
+// fixedKeys authenticates the keys of its map.
+type fixedKeys map[string]models.PrincipalData
+
+func (k fixedKeys) Authenticate(ctx context.Context, key string) (models.PrincipalData, error, int) {
+	if principal, found := k[key]; found {
+		return principal, nil, http.StatusOK
+	}
+	return models.PrincipalData{}, errors.New("Invalid API key"), http.StatusUnauthorized
+}
+
+func keys() fixedKeys {
+	return fixedKeys{"secret": alice, "acme": {UserID: 3, User: "bob", Role: models.RoleEditor, KeyID: 3, Tenant: "acme"}}
+}
+
+// fixedTokens accepts the token "a.b.c" with its claims.
+type fixedTokens auth.Claims
+
+func (c fixedTokens) Verify(ctx context.Context, token string) (auth.Claims, error) {
+	if token != "a.b.c" {
+		return auth.Claims{}, auth.ErrInvalidToken
+	}
+	return auth.Claims(c), nil
+}
+
+// seen records the principal and the tenant of the calls the guard lets through.
+type seen struct {
+	MockService
+	principal models.PrincipalData
+	tenant    string
+}
+
+func (s *seen) AddSong(ctx context.Context, group string, song string) (err error, status int) {
+	s.principal, _ = auth.PrincipalFrom(ctx)
+	s.tenant = tenant.From(ctx)
+	return nil, http.StatusOK
+}
+
+func (s *seen) StreamSongs(ctx context.Context, query models.SongsQuery, each func(models.RowDbData) error) (err error, status int) {
+	s.principal, _ = auth.PrincipalFrom(ctx)
+	s.tenant = tenant.From(ctx)
+	return nil, http.StatusOK
+}
+
+func TestGuard_Keys(t *testing.T) {
+	service := &seen{}
+	client := serve(t, service, &Guard{Keys: keys()})
+	add := func(ctx context.Context) error {
+		_, err := client.AddSong(ctx, &musicpb.AddSongRequest{Group: "Muse", Song: "Uprising"})
+		return err
+	}
+	err := add(context.Background())
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, "An API key is required", status.Convert(err).Message())
+	assert.Equal(t, codes.Unauthenticated, status.Code(add(withKey("wrong"))))
+	assert.NoError(t, add(metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")))
+	assert.Equal(t, alice, service.principal)
+	assert.Equal(t, tenant.Default, service.tenant)
+	// the tenant of the key is used, another one is refused
+	assert.NoError(t, add(withKey("acme")))
+	assert.Equal(t, "acme", service.tenant)
+	err = add(metadata.AppendToOutgoingContext(withKey("acme"), "x-tenant", "globex"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	// a key without a tenant acts on the default one
+	assert.NoError(t, add(metadata.AppendToOutgoingContext(withKey("secret"), "x-tenant", tenant.Default)))
+	assert.Equal(t, tenant.Default, service.tenant)
+	err = add(metadata.AppendToOutgoingContext(withKey("secret"), "x-tenant", "globex"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	err = add(metadata.AppendToOutgoingContext(withKey("secret"), "x-tenant", "not a tenant!"))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGuard_Public(t *testing.T) {
+	service := &seen{}
+	client := serve(t, service, &Guard{Keys: keys(), Public: map[string]bool{"/getdata": true}})
+	stream, err := client.ListSongs(context.Background(), &musicpb.ListSongsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "anonymous", service.principal.User)
+	// only the public routes are served without a key
+	_, err = client.AddSong(context.Background(), &musicpb.AddSongRequest{Group: "Muse", Song: "Uprising"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGuard_Tokens(t *testing.T) {
+	service := &seen{}
+	tokens := fixedTokens{Subject: "billing", Scopes: []string{"songs:write"}, Tenant: "acme"}
+	client := serve(t, service, &Guard{Keys: keys(), Tokens: tokens})
+	bearer := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+	_, err := client.AddSong(bearer("a.b.c"), &musicpb.AddSongRequest{Group: "Muse", Song: "Uprising"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.PrincipalData{User: "billing", Role: models.RoleService, Permissions: []string{"songs:write"}, Tenant: "acme"}, service.principal)
+	assert.Equal(t, "acme", service.tenant)
+	_, err = client.AddSong(bearer("x.y.z"), &musicpb.AddSongRequest{Group: "Muse", Song: "Uprising"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	stream, err := client.ListSongs(bearer("a.b.c"), &musicpb.ListSongsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestGuard_RateLimit(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	limiter := ratelimit.New(map[string]ratelimit.Limit{
+		ratelimit.Enrich: {PerMinute: 1},
+	}, func() time.Time { return now })
+	client := serve(t, &seen{}, &Guard{Keys: keys(), Limiter: limiter})
+	_, err := client.AddSong(withKey("secret"), &musicpb.AddSongRequest{Group: "Muse", Song: "Uprising"})
+	assert.NoError(t, err)
+	_, err = client.AddSong(withKey("secret"), &musicpb.AddSongRequest{Group: "Muse", Song: "Uprising"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, "Too many enrich requests, at most 1 a minute", status.Convert(err).Message())
+	// the buckets are per key
+	_, err = client.AddSong(withKey("acme"), &musicpb.AddSongRequest{Group: "Muse", Song: "Uprising"})
+	assert.NoError(t, err)
+}
+
+func TestRoutes(t *testing.T) {
+	for _, method := range []string{
+		musicpb.Music_AddSong_FullMethodName, musicpb.Music_DeleteSong_FullMethodName, musicpb.Music_EditSong_FullMethodName,
+		musicpb.Music_ListSongs_FullMethodName, musicpb.Music_GetVerse_FullMethodName,
+	} {
+		assert.Contains(t, Routes, method)
+	}
+	assert.Len(t, Routes, len(musicpb.Music_ServiceDesc.Methods)+len(musicpb.Music_ServiceDesc.Streams))
+}
@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"test/internal/lyrics"
+	"test/internal/models"
+	"test/internal/transport/rest"
+	"test/internal/transport/rpc/musicpb"
+)
+
+// ServiceInterface is the part of rest.ServiceInterface served over gRPC.
+type ServiceInterface interface {
+	AddSong(ctx context.Context, group string, song string) (err error, status int)
+	DeleteSong(ctx context.Context, group string, song string) (err error, status int)
+	EditSong(ctx context.Context, group string, song string, date string, text string, link string) (err error, status int)
+	StreamSongs(ctx context.Context, query models.SongsQuery, each func(models.RowDbData) error) (err error, status int)
+	GetSongText(ctx context.Context, couplet int64, group string, song string, compact bool, langs []string, kind string) (result models.AnswerCoupletData, err error, status int)
+}
+
+// Server serves the Music service with the service of the REST routes.
+type Server struct {
+	musicpb.UnimplementedMusicServer
+	service ServiceInterface
+}
+
+func NewServer(service ServiceInterface) *Server {
+	return &Server{service: service}
+}
+
+func (s *Server) AddSong(ctx context.Context, req *musicpb.AddSongRequest) (*musicpb.AddSongResponse, error) {
+	slog.InfoContext(ctx, "Request data", "group", req.Group, "song", req.Song)
+	if err, status := s.service.AddSong(ctx, req.Group, req.Song); err != nil {
+		return nil, Error(err, status)
+	}
+	return &musicpb.AddSongResponse{}, nil
+}
+
+func (s *Server) DeleteSong(ctx context.Context, req *musicpb.DeleteSongRequest) (*musicpb.DeleteSongResponse, error) {
+	slog.InfoContext(ctx, "Request data", "group", req.Group, "song", req.Song)
+	if err, status := s.service.DeleteSong(ctx, req.Group, req.Song); err != nil {
+		return nil, Error(err, status)
+	}
+	return &musicpb.DeleteSongResponse{}, nil
+}
+
+func (s *Server) EditSong(ctx context.Context, req *musicpb.EditSongRequest) (*musicpb.EditSongResponse, error) {
+	slog.InfoContext(ctx, "Request data", "group", req.Group, "song", req.Song, "releaseDate", req.ReleaseDate)
+	if err, status := s.service.EditSong(ctx, req.Group, req.Song, req.ReleaseDate, req.Text, req.Link); err != nil {
+		return nil, Error(err, status)
+	}
+	return &musicpb.EditSongResponse{}, nil
+}
+
+// ListSongs sends the songs as they are read from the database, the query is checked like the
+// query of the exports of /getdata.
+func (s *Server) ListSongs(req *musicpb.ListSongsRequest, stream musicpb.Music_ListSongsServer) error {
+	ctx := stream.Context()
+	query, err := rest.ExportQuery(songsValues(req))
+	if err != nil {
+		return Error(err, http.StatusBadRequest)
+	}
+	rows := 0
+	err, status := s.service.StreamSongs(ctx, query, func(row models.RowDbData) error {
+		rows++
+		return stream.Send(&musicpb.Song{
+			Group:          row.Group,
+			Song:           row.Song,
+			ReleaseDate:    row.Date,
+			Text:           row.Text,
+			Link:           row.Link,
+			Lang:           row.Lang,
+			LangConfidence: row.LangConfidence,
+		})
+	})
+	if err != nil {
+		return Error(err, status)
+	}
+	slog.InfoContext(ctx, "Listed songs", "rows", rows)
+	return nil
+}
+
+// songsValues returns the parameters of /getdata asking for the songs of the request. The
+// filters always name their operator, so the unknown fields are rejected rather than ignored
+// like the unknown parameters.
+func songsValues(req *musicpb.ListSongsRequest) url.Values {
+	values := url.Values{}
+	for _, filter := range req.Filters {
+		operator := filter.Operator
+		if operator == "" {
+			operator = models.OperatorEq
+		}
+		key := filter.Field + "[" + operator + "]"
+		values[key] = append(values[key], filter.Values...)
+		if len(values[key]) == 0 {
+			// a filter without a value is reported like a parameter without one
+			values[key] = []string{""}
+		}
+	}
+	var sort []string
+	for _, key := range req.Sort {
+		if key.Desc {
+			sort = append(sort, "-"+key.Field)
+		} else {
+			sort = append(sort, key.Field)
+		}
+	}
+	if len(sort) > 0 {
+		values.Set("sort", strings.Join(sort, ","))
+	}
+	if req.Limit != 0 {
+		// the limit is the first page of that many songs
+		values.Set("items", strconv.FormatInt(req.Limit, 10))
+		values.Set("page", "1")
+	}
+	if len(req.Fields) > 0 {
+		values.Set("fields", strings.Join(req.Fields, ","))
+	}
+	if req.HasLink != nil {
+		values.Set("hasLink", strconv.FormatBool(*req.HasLink))
+	}
+	if req.HasLyrics != nil {
+		values.Set("hasLyrics", strconv.FormatBool(*req.HasLyrics))
+	}
+	return values
+}
+
+func (s *Server) GetVerse(ctx context.Context, req *musicpb.GetVerseRequest) (*musicpb.Verse, error) {
+	langs := make([]string, 0, len(req.Langs))
+	for _, lang := range req.Langs {
+		canonical, err := lyrics.CanonicalLanguage(lang)
+		if err != nil {
+			return nil, Error(err, http.StatusBadRequest)
+		}
+		langs = append(langs, canonical)
+	}
+	slog.InfoContext(ctx, "Request data", "group", req.Group, "song", req.Song, "couplet", req.Couplet, "compact", req.Compact, "langs", langs, "kind", req.Kind)
+	result, err, status := s.service.GetSongText(ctx, req.Couplet, req.Group, req.Song, req.Compact, langs, req.Kind)
+	if err != nil {
+		return nil, Error(err, status)
+	}
+	return &musicpb.Verse{Text: result.Text, Lang: result.Lang, Kind: result.Kind, Translation: result.Translation}, nil
+}
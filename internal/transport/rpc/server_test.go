@@ -0,0 +1,224 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"test/internal/models"
+	"test/internal/transport/rpc/musicpb"
+	"testing"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) AddSong(ctx context.Context, group string, song string) (err error, status int) {
+	args := m.Called(group, song)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockService) DeleteSong(ctx context.Context, group string, song string) (err error, status int) {
+	args := m.Called(group, song)
+	return args.Error(0), args.Get(1).(int)
+}
+
+func (m *MockService) EditSong(ctx context.Context, group string, song string, date string, text string, link string) (err error, status int) {
+	args := m.Called(group, song, date, text, link)
+	return args.Error(0), args.Get(1).(int)
+}
+
+// StreamSongs passes the rows given to Return to each before returning the error and status.
+func (m *MockService) StreamSongs(ctx context.Context, query models.SongsQuery, each func(models.RowDbData) error) (err error, status int) {
+	args := m.Called(query)
+	for _, row := range args.Get(0).([]models.RowDbData) {
+		if err := each(row); err != nil {
+			return err, http.StatusInternalServerError
+		}
+	}
+	return args.Error(1), args.Get(2).(int)
+}
+
+func (m *MockService) GetSongText(ctx context.Context, couplet int64, group string, song string, compact bool, langs []string, kind string) (result models.AnswerCoupletData, err error, status int) {
+	args := m.Called(couplet, group, song, compact, langs, kind)
+	return args.Get(0).(models.AnswerCoupletData), args.Error(1), args.Get(2).(int)
+}
+
+// serve serves the service behind the guard on an in-memory listener and returns a client of it.
+func serve(t *testing.T, service ServiceInterface, guard *Guard) musicpb.MusicClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(guard.Unary()), grpc.ChainStreamInterceptor(guard.Stream()))
+	musicpb.RegisterMusicServer(server, NewServer(service))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return musicpb.NewMusicClient(conn)
+}
+
+// withKey returns the context of a call made with the API key.
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func TestAddSong(t *testing.T) {
+	service := &MockService{}
+	client := serve(t, service, &Guard{Keys: keys()})
+	service.On("AddSong", "Muse", "Uprising").Return(nil, http.StatusOK).Once()
+	_, err := client.AddSong(withKey("secret"), &musicpb.AddSongRequest{Group: "Muse", Song: "Uprising"})
+	assert.NoError(t, err)
+	service.On("AddSong", "Muse", "Uprising").Return(errors.New("The song already exists"), http.StatusConflict).Once()
+	_, err = client.AddSong(withKey("secret"), &musicpb.AddSongRequest{Group: "Muse", Song: "Uprising"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	assert.Equal(t, "The song already exists", status.Convert(err).Message())
+	service.AssertExpectations(t)
+}
+
+func TestDeleteSong(t *testing.T) {
+	service := &MockService{}
+	client := serve(t, service, &Guard{Keys: keys()})
+	service.On("DeleteSong", "Muse", "Uprising").Return(errors.New("Song not found"), http.StatusNotFound).Once()
+	_, err := client.DeleteSong(withKey("secret"), &musicpb.DeleteSongRequest{Group: "Muse", Song: "Uprising"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	service.AssertExpectations(t)
+}
+
+func TestEditSong(t *testing.T) {
+	service := &MockService{}
+	client := serve(t, service, &Guard{Keys: keys()})
+	service.On("EditSong", "Muse", "Uprising", "07.09.2009", "", "").Return(nil, http.StatusOK).Once()
+	_, err := client.EditSong(withKey("secret"), &musicpb.EditSongRequest{Group: "Muse", Song: "Uprising", ReleaseDate: "07.09.2009"})
+	assert.NoError(t, err)
+	service.AssertExpectations(t)
+}
+
+func TestListSongs(t *testing.T) {
+	service := &MockService{}
+	client := serve(t, service, &Guard{Keys: keys()})
+	query := models.SongsQuery{
+		Filters: []models.FilterData{
+			{Field: "group", Operator: models.OperatorEq, Values: []string{"Muse"}},
+			{Field: "releaseDate", Operator: models.OperatorGte, Values: []string{"01.01.2000"}},
+		},
+		Sort:   []models.SortData{{Field: "releaseDate", Desc: true}},
+		Items:  2,
+		Page:   1,
+		Fields: []string{"song", "releaseDate"},
+	}
+	rows := []models.RowDbData{{Song: "Uprising", Date: "07.09.2009"}, {Song: "Starlight", Date: "05.09.2006"}}
+	service.On("StreamSongs", query).Return(rows, nil, http.StatusOK).Once()
+	stream, err := client.ListSongs(withKey("secret"), &musicpb.ListSongsRequest{
+		Filters: []*musicpb.Filter{
+			{Field: "releaseDate", Operator: "gte", Values: []string{"01.01.2000"}},
+			{Field: "group", Values: []string{"Muse"}},
+		},
+		Sort:   []*musicpb.SortKey{{Field: "releaseDate", Desc: true}},
+		Limit:  2,
+		Fields: []string{"song", "releaseDate"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var songs []string
+	for {
+		song, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		songs = append(songs, song.Song+" "+song.ReleaseDate)
+	}
+	assert.Equal(t, []string{"Uprising 07.09.2009", "Starlight 05.09.2006"}, songs)
+	service.AssertExpectations(t)
+}
+
+func TestListSongs_Errors(t *testing.T) {
+	service := &MockService{}
+	client := serve(t, service, &Guard{Keys: keys()})
+	recv := func(req *musicpb.ListSongsRequest) error {
+		stream, err := client.ListSongs(withKey("secret"), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			if _, err := stream.Recv(); err != nil {
+				return err
+			}
+		}
+	}
+	err := recv(&musicpb.ListSongsRequest{Filters: []*musicpb.Filter{{Field: "album", Values: []string{"Resistance"}}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "Unknown filter album[eq]", status.Convert(err).Message())
+	err = recv(&musicpb.ListSongsRequest{Limit: 5000})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	service.On("StreamSongs", models.SongsQuery{}).Return([]models.RowDbData{}, errors.New("Error selecting data"), http.StatusInternalServerError).Once()
+	err = recv(&musicpb.ListSongsRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	service.AssertExpectations(t)
+}
+
+func TestSongsValues(t *testing.T) {
+	yes := true
+	values := songsValues(&musicpb.ListSongsRequest{
+		Filters: []*musicpb.Filter{
+			{Field: "group", Operator: "in", Values: []string{"Muse", "Queen"}},
+			{Field: "song", Operator: "prefix"},
+		},
+		Sort:    []*musicpb.SortKey{{Field: "group"}, {Field: "song", Desc: true}},
+		HasLink: &yes,
+	})
+	assert.Equal(t, url.Values{
+		"group[in]":    {"Muse", "Queen"},
+		"song[prefix]": {""},
+		"sort":         {"group,-song"},
+		"hasLink":      {"true"},
+	}, values)
+}
+
+func TestGetVerse(t *testing.T) {
+	service := &MockService{}
+	client := serve(t, service, &Guard{Keys: keys()})
+	service.On("GetSongText", int64(2), "Muse", "Uprising", false, []string{"de"}, "translation").
+		Return(models.AnswerCoupletData{Text: "Rise up", Lang: "de", Kind: "translation", Translation: "Steh auf"}, nil, http.StatusOK).
+		Once()
+	verse, err := client.GetVerse(withKey("secret"), &musicpb.GetVerseRequest{Group: "Muse", Song: "Uprising", Couplet: 2, Langs: []string{"DE"}, Kind: "translation"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Steh auf", verse.Translation)
+	assert.Equal(t, "de", verse.Lang)
+	_, err = client.GetVerse(withKey("secret"), &musicpb.GetVerseRequest{Group: "Muse", Song: "Uprising", Couplet: 2, Langs: []string{"not a tag"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	service.AssertExpectations(t)
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, codes.OK, Code(http.StatusOK))
+	assert.Equal(t, codes.InvalidArgument, Code(http.StatusBadRequest))
+	assert.Equal(t, codes.Unauthenticated, Code(http.StatusUnauthorized))
+	assert.Equal(t, codes.PermissionDenied, Code(http.StatusForbidden))
+	assert.Equal(t, codes.NotFound, Code(http.StatusNotFound))
+	assert.Equal(t, codes.ResourceExhausted, Code(http.StatusTooManyRequests))
+	assert.Equal(t, codes.FailedPrecondition, Code(http.StatusUnprocessableEntity))
+	assert.Equal(t, codes.Unavailable, Code(http.StatusServiceUnavailable))
+	assert.Equal(t, codes.Internal, Code(http.StatusInternalServerError))
+	assert.Equal(t, codes.Internal, Code(http.StatusBadGateway))
+}